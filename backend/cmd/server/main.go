@@ -10,9 +10,11 @@ import (
 	"strings"
 
 	"github.com/tinx/pat-quest-editor/backend/internal/adapters/filesystem"
+	"github.com/tinx/pat-quest-editor/backend/internal/adapters/git"
 	httpAdapter "github.com/tinx/pat-quest-editor/backend/internal/adapters/http"
 	"github.com/tinx/pat-quest-editor/backend/internal/adapters/storage"
 	"github.com/tinx/pat-quest-editor/backend/internal/app"
+	"github.com/tinx/pat-quest-editor/backend/internal/ports"
 )
 
 // allowedDevOrigins contains origins allowed in development mode
@@ -40,7 +42,8 @@ func corsMiddleware(next http.Handler) http.Handler {
 		if allowed {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, If-Match, If-None-Match")
+			w.Header().Set("Access-Control-Expose-Headers", "ETag")
 			w.Header().Set("Vary", "Origin")
 		}
 
@@ -82,7 +85,12 @@ func main() {
 	addr := flag.String("addr", ":8080", "HTTP server address")
 	questsDir := flag.String("quests", "../quests", "Path to quests directory")
 	dataDir := flag.String("data", "../data", "Path to reference data directory")
+	schemasDir := flag.String("schemas", "../schemas", "Path to JSON schemas directory")
 	dbPath := flag.String("db", "editor.db", "Path to SQLite database")
+	backups := flag.Int("backups", 10, "Number of previous versions kept per quest (0 disables backups)")
+	revisions := flag.Int("revisions", 100, "Number of saved revisions kept per quest (0 keeps all)")
+	revisionAge := flag.Duration("revision-age", 0, "Age after which saved revisions are removed, e.g. 2160h (0 keeps them)")
+	authorHeader := flag.String("author-header", "", "Request header with the user authenticated by a proxy, recorded with revisions, e.g. X-Forwarded-User; only set it behind a proxy that removes the header from client requests")
 	staticDir := flag.String("static", "../frontend/dist", "Path to frontend static files")
 	devMode := flag.Bool("dev", false, "Enable development mode (CORS headers)")
 	flag.Parse()
@@ -98,6 +106,11 @@ func main() {
 		log.Printf("Warning: failed to resolve data path: %v", err)
 		dataPath = *dataDir
 	}
+	schemasPath, err := filepath.Abs(*schemasDir)
+	if err != nil {
+		log.Printf("Warning: failed to resolve schemas path: %v", err)
+		schemasPath = *schemasDir
+	}
 	dbPathAbs, err := filepath.Abs(*dbPath)
 	if err != nil {
 		log.Printf("Warning: failed to resolve database path: %v", err)
@@ -115,23 +128,42 @@ func main() {
 	}
 
 	// Initialize repositories
-	questRepo := filesystem.NewQuestFileRepository(questsPath)
+	questRepo := filesystem.NewQuestFileRepository(questsPath, *backups)
 	refDataRepo, err := filesystem.NewReferenceDataFileRepository(dataPath)
 	if err != nil {
 		log.Fatalf("Failed to initialize reference data repository: %v", err)
 	}
+	schemaRepo := filesystem.NewSchemaFileRepository(schemasPath)
 
-	metadataRepo, err := storage.NewSQLiteMetadataRepository(dbPathAbs)
+	metadataRepo, err := storage.NewSQLiteMetadataRepository(dbPathAbs, storage.RevisionRetention{
+		MaxCount: *revisions,
+		MaxAge:   *revisionAge,
+	})
 	if err != nil {
 		log.Fatalf("Failed to initialize metadata repository: %v", err)
 	}
 	defer metadataRepo.Close()
 
+	// Version control is optional: without a git working tree, the editor
+	// works on the files alone
+	var vcs ports.VersionControl
+	if versionControl, err := git.NewVersionControl(questsPath, questRepo); err != nil {
+		log.Printf("Version control disabled: %v", err)
+	} else {
+		vcs = versionControl
+	}
+
 	// Initialize services
-	validator := app.NewQuestValidatorService(refDataRepo)
+	validator := app.NewQuestValidatorService(refDataRepo, schemaRepo)
+	for _, verr := range validator.ValidateReferenceData().Errors {
+		log.Printf("Warning: reference data: %s", verr.Message)
+	}
+	analyzer := app.NewQuestAnalyzerService()
+	fixer := app.NewQuestFixerService()
+	differ := app.NewQuestDifferService()
 
 	// Initialize HTTP handler
-	handler := httpAdapter.NewHandler(questRepo, refDataRepo, metadataRepo, validator)
+	handler := httpAdapter.NewHandler(questRepo, refDataRepo, metadataRepo, metadataRepo, validator, analyzer, fixer, differ, vcs, *authorHeader)
 
 	// Set up routes
 	mux := http.NewServeMux()
@@ -157,6 +189,7 @@ func main() {
 	log.Printf("Starting server on %s", *addr)
 	log.Printf("Quest files: %s", questsPath)
 	log.Printf("Reference data: %s", dataPath)
+	log.Printf("Schemas: %s", schemasPath)
 	log.Printf("Database: %s", dbPathAbs)
 
 	if err := http.ListenAndServe(*addr, finalHandler); err != nil {
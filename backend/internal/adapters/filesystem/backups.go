@@ -0,0 +1,174 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+// backupDir is the hidden directory below the quests directory that holds
+// the backups of each quest in a subdirectory named after its QuestID.
+const backupDir = ".backups"
+
+// backupTimeFormat names backup files after the time they were taken, so
+// that their names sort by age.
+const backupTimeFormat = "20060102T150405.000000000Z"
+
+// validBackupIDPattern matches the names of backup files without extension.
+var validBackupIDPattern = regexp.MustCompile(`^\d{8}T\d{6}\.\d{9}Z$`)
+
+// writeFileAtomic replaces the file at path with data, so that a crash
+// leaves either the old or the new file behind, never a truncated one. The
+// data is written to a temporary file in the same directory, synced, and
+// renamed over path.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+
+	// Sync the directory so the rename itself survives a crash
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// questBackupDir returns the backup directory of a quest.
+func (r *QuestFileRepository) questBackupDir(questID string) string {
+	return filepath.Join(r.basePath, backupDir, sanitizeFilename(questID))
+}
+
+// backup keeps a copy of the quest file at path before it is replaced or
+// deleted, and removes the oldest backups beyond the configured count.
+func (r *QuestFileRepository) backup(questID, path string) error {
+	if r.backups <= 0 {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read quest file for backup: %w", err)
+	}
+
+	dir := r.questBackupDir(questID)
+	if err := validatePathWithinBase(r.basePath, dir); err != nil {
+		return fmt.Errorf("invalid backup path: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	name := time.Now().UTC().Format(backupTimeFormat) + ".yaml"
+	if err := writeFileAtomic(filepath.Join(dir, name), data, 0644); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	backups, err := r.ListBackups(questID)
+	if err != nil {
+		return err
+	}
+	for _, old := range backups[min(r.backups, len(backups)):] {
+		if err := os.Remove(filepath.Join(dir, old.ID+".yaml")); err != nil {
+			return fmt.Errorf("failed to remove old backup: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListBackups returns the backups of a quest, newest first.
+func (r *QuestFileRepository) ListBackups(questID string) ([]domain.QuestBackup, error) {
+	entries, err := os.ReadDir(r.questBackupDir(questID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []domain.QuestBackup{}, nil
+		}
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	backups := []domain.QuestBackup{}
+	for _, entry := range entries {
+		id := strings.TrimSuffix(entry.Name(), ".yaml")
+		if entry.IsDir() || !validBackupIDPattern.MatchString(id) {
+			continue
+		}
+		created, err := time.Parse(backupTimeFormat, id)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list backups: %w", err)
+		}
+		backups = append(backups, domain.QuestBackup{ID: id, Created: created, Size: info.Size()})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].ID > backups[j].ID
+	})
+	return backups, nil
+}
+
+// RestoreBackup replaces a quest with one of its backups. The current
+// version of the quest is backed up first, so a restore can be undone. A
+// deleted quest is restored to a new file.
+func (r *QuestFileRepository) RestoreBackup(questID, backupID string) error {
+	if !validBackupIDPattern.MatchString(backupID) {
+		return fmt.Errorf("%w: backup ID %q", domain.ErrInvalidInput, backupID)
+	}
+	data, err := os.ReadFile(filepath.Join(r.questBackupDir(questID), backupID+".yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: backup %s of quest %s", domain.ErrNotFound, backupID, questID)
+		}
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	path, err := r.findQuestFile(questID)
+	switch {
+	case err == nil:
+		if err := r.backup(questID, path); err != nil {
+			return err
+		}
+	case errors.Is(err, domain.ErrNotFound):
+		path = filepath.Join(r.basePath, sanitizeFilename(questID)+".yaml")
+	default:
+		return err
+	}
+	// Validate path is within base directory to prevent path traversal
+	if err := validatePathWithinBase(r.basePath, path); err != nil {
+		return fmt.Errorf("invalid quest path: %w", err)
+	}
+
+	if err := writeFileAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write quest file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package filesystem
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+func newTestQuest(version int) *domain.Quest {
+	return &domain.Quest{
+		QuestTypeVersion: 1,
+		QuestVersion:     version,
+		QuestID:          "Backed:Up",
+		QuestType:        "SideQuest",
+		DisplayName:      domain.I18nString{EnUS: "Backed up", DeDE: "Gesichert"},
+		QuestNodes:       []domain.QuestNode{{NodeID: 0, NodeType: "EntryPoint"}},
+	}
+}
+
+func TestSave_RotatesBackups(t *testing.T) {
+	dir := t.TempDir()
+	repo := NewQuestFileRepository(dir, 2)
+
+	for version := 1; version <= 4; version++ {
+		if err := repo.Save(newTestQuest(version)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Saving an unchanged quest writes nothing, so it needs no backup
+	if err := repo.Save(newTestQuest(4)); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := repo.ListBackups("Backed:Up")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups, got %v", backups)
+	}
+	var versions []int
+	for _, backup := range backups {
+		data, err := os.ReadFile(filepath.Join(dir, backupDir, "Backed_Up", backup.ID+".yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var quest domain.Quest
+		if err := yaml.Unmarshal(data, &quest); err != nil {
+			t.Fatal(err)
+		}
+		versions = append(versions, quest.QuestVersion)
+	}
+	if want := []int{3, 2}; !reflect.DeepEqual(versions, want) {
+		t.Errorf("expected backups of versions %v, newest first, got %v", want, versions)
+	}
+
+	// Backups are not quests, and no temporary files are left behind
+	questIDs, err := repo.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(questIDs, []string{"Backed:Up"}) {
+		t.Errorf("expected only the quest itself, got %v", questIDs)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected the quest file and the backup directory, got %v", entries)
+	}
+}
+
+func TestRestoreBackup(t *testing.T) {
+	dir := t.TempDir()
+	repo := NewQuestFileRepository(dir, 5)
+
+	for version := 1; version <= 2; version++ {
+		if err := repo.Save(newTestQuest(version)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backups, err := repo.ListBackups("Backed:Up")
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("expected 1 backup, got %v, %v", backups, err)
+	}
+
+	if err := repo.RestoreBackup("Backed:Up", backups[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	quest, err := repo.Get("Backed:Up")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quest.QuestVersion != 1 {
+		t.Errorf("expected version 1 to be restored, got %d", quest.QuestVersion)
+	}
+	// The replaced version was backed up, so the restore can be undone
+	if backups, _ := repo.ListBackups("Backed:Up"); len(backups) != 2 {
+		t.Errorf("expected 2 backups after restoring, got %v", backups)
+	}
+
+	// Deleted quests can be restored, too
+	if err := repo.Delete("Backed:Up"); err != nil {
+		t.Fatal(err)
+	}
+	backups, _ = repo.ListBackups("Backed:Up")
+	if err := repo.RestoreBackup("Backed:Up", backups[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if quest, err := repo.Get("Backed:Up"); err != nil || quest.QuestVersion != 1 {
+		t.Errorf("expected the deleted quest to be restored, got %v, %v", quest, err)
+	}
+
+	if err := repo.RestoreBackup("Backed:Up", "20000101T000000.000000000Z"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing backup, got %v", err)
+	}
+	if err := repo.RestoreBackup("Backed:Up", "../../etc/passwd"); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput for an invalid backup ID, got %v", err)
+	}
+}
@@ -0,0 +1,240 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+// questIndex maps QuestIDs to quest files. It is built by walking the
+// quests directory once. Before each access, only the modification times of
+// the indexed directories are compared with those they were read with: a
+// file that is added, removed or replaced, as editors and git do when they
+// save, changes the time of its directory and makes the index walk the
+// tree again, parsing only new and changed files. Files edited in place
+// leave their directory alone, so the files a lookup finds are checked
+// themselves, and a QuestID that is not found makes the index walk again
+// before giving up.
+type questIndex struct {
+	basePath string
+
+	mu    sync.Mutex
+	dirs  map[string]time.Time // modification time of each walked directory
+	paths []string             // in walk order
+	files map[string]indexEntry
+	walks int // number of walks, for tests
+}
+
+// indexEntry is the indexed state of a quest file.
+type indexEntry struct {
+	summary questSummary
+	modTime time.Time
+	size    int64
+	// parsed is false for files that are not valid YAML; they are skipped
+	parsed bool
+}
+
+// questSummary holds the fields of a quest file that identify the quest.
+type questSummary struct {
+	QuestID      string            `yaml:"QuestID"`
+	QuestVersion int               `yaml:"QuestVersion"`
+	QuestType    string            `yaml:"QuestType"`
+	DisplayName  domain.I18nString `yaml:"DisplayName"`
+}
+
+func newQuestIndex(basePath string) *questIndex {
+	return &questIndex{basePath: basePath, files: make(map[string]indexEntry)}
+}
+
+// refresh walks the quests directory again if it has not been walked yet,
+// or if one of its directories changed since. The caller must hold x.mu.
+func (x *questIndex) refresh() error {
+	if x.dirs != nil && !x.dirsChanged() {
+		return nil
+	}
+	return x.walk()
+}
+
+// dirsChanged reports whether a directory was changed or removed since it
+// was walked.
+func (x *questIndex) dirsChanged() bool {
+	for dir, modTime := range x.dirs {
+		info, err := os.Stat(dir)
+		if err != nil || !info.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// walk indexes the quests directory. Files whose modification time and
+// size did not change are not parsed again. The caller must hold x.mu.
+func (x *questIndex) walk() error {
+	var paths []string
+	dirs := make(map[string]time.Time)
+	files := make(map[string]indexEntry, len(x.files))
+
+	err := filepath.Walk(x.basePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if skip := skipDir(x.basePath, path, info); skip != nil {
+			return skip
+		}
+		if info.IsDir() {
+			dirs[path] = info.ModTime()
+			return nil
+		}
+		if !isQuestFile(path) {
+			return nil
+		}
+
+		entry, ok := x.files[path]
+		if !ok || !entry.modTime.Equal(info.ModTime()) || entry.size != info.Size() {
+			entry = indexFile(path, info)
+		}
+		paths = append(paths, path)
+		files[path] = entry
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to index quests: %w", err)
+	}
+
+	x.dirs, x.paths, x.files = dirs, paths, files
+	x.walks++
+	return nil
+}
+
+// verify brings the entries of files up to date, for files edited in place.
+// It reports whether one of them changed. The caller must hold x.mu.
+func (x *questIndex) verify(paths []string) bool {
+	changed := false
+	for _, path := range paths {
+		entry := x.files[path]
+		info, err := os.Stat(path)
+		if err != nil {
+			// Removing a file changes its directory, so the next refresh
+			// drops it
+			entry.parsed = false
+		} else if !entry.modTime.Equal(info.ModTime()) || entry.size != info.Size() {
+			entry = indexFile(path, info)
+		} else {
+			continue
+		}
+		x.files[path] = entry
+		changed = true
+	}
+	return changed
+}
+
+// indexFile reads the summary of a quest file.
+func indexFile(path string, info os.FileInfo) indexEntry {
+	entry := indexEntry{modTime: info.ModTime(), size: info.Size()}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return entry
+	}
+	if err := yaml.Unmarshal(data, &entry.summary); err != nil {
+		return indexEntry{modTime: info.ModTime(), size: info.Size()}
+	}
+	entry.parsed = true
+	return entry
+}
+
+// lookup returns the path of the quest file with the given QuestID. It
+// fails with domain.ErrDuplicateQuestID if several files have that ID.
+func (x *questIndex) lookup(questID string) (string, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if err := x.refresh(); err != nil {
+		return "", err
+	}
+
+	found := x.find(questID)
+	if x.verify(found) {
+		found = x.find(questID)
+	}
+	if len(found) == 0 {
+		// The quest may be in a file edited in place
+		if err := x.walk(); err != nil {
+			return "", err
+		}
+		found = x.find(questID)
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("%w: quest %s", domain.ErrNotFound, questID)
+	case 1:
+		return found[0], nil
+	}
+	return "", x.duplicateError(questID, found)
+}
+
+// find returns the paths of the files with the given QuestID. The caller
+// must hold x.mu.
+func (x *questIndex) find(questID string) []string {
+	var found []string
+	for _, path := range x.paths {
+		if entry := x.files[path]; entry.parsed && entry.summary.QuestID == questID {
+			found = append(found, path)
+		}
+	}
+	return found
+}
+
+// duplicateError returns the error for a QuestID defined in several files.
+func (x *questIndex) duplicateError(questID string, paths []string) error {
+	files := make([]string, len(paths))
+	for i, path := range paths {
+		files[i] = path
+		if rel, err := filepath.Rel(x.basePath, path); err == nil {
+			files[i] = rel
+		}
+	}
+	return fmt.Errorf("%w: quest %s is defined in %s", domain.ErrDuplicateQuestID, questID, strings.Join(files, ", "))
+}
+
+// questIDs returns the QuestIDs of all quest files that can be parsed, in
+// walk order. Files without a QuestID are left out. It fails with
+// domain.ErrDuplicateQuestID if several files have the same QuestID.
+func (x *questIndex) questIDs() ([]string, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if err := x.refresh(); err != nil {
+		return nil, err
+	}
+
+	var questIDs []string
+	found := make(map[string][]string)
+	for _, path := range x.paths {
+		entry := x.files[path]
+		questID := entry.summary.QuestID
+		if !entry.parsed || questID == "" {
+			continue
+		}
+		if len(found[questID]) == 0 {
+			questIDs = append(questIDs, questID)
+		}
+		found[questID] = append(found[questID], path)
+	}
+
+	var errs []error
+	for _, questID := range questIDs {
+		if paths := found[questID]; len(paths) > 1 {
+			errs = append(errs, x.duplicateError(questID, paths))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return questIDs, nil
+}
@@ -0,0 +1,140 @@
+package filesystem
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+func writeQuestFile(t *testing.T, path, questID string) {
+	t.Helper()
+	data := "QuestID: " + questID + "\nQuestNodes: []\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQuestIndex_FollowsFileChanges(t *testing.T) {
+	dir := t.TempDir()
+	writeQuestFile(t, filepath.Join(dir, "a.yaml"), "QuestA")
+	repo := NewQuestFileRepository(dir, 0)
+
+	// Files added, changed and removed behind the repository's back
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeQuestFile(t, filepath.Join(dir, "sub", "b.yaml"), "QuestB")
+	writeQuestFile(t, filepath.Join(dir, "a.yaml"), "QuestRenamed")
+	// Make sure the change is visible even on coarse file system clocks
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(dir, "a.yaml"), later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	questIDs, err := repo.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"QuestRenamed", "QuestB"}; !reflect.DeepEqual(questIDs, want) {
+		t.Errorf("expected %v, got %v", want, questIDs)
+	}
+	if _, err := repo.Get("QuestA"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for the old QuestID, got %v", err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "sub", "b.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := repo.Exists("QuestB"); err != nil || exists {
+		t.Errorf("expected removed quest to be gone, got %v, %v", exists, err)
+	}
+}
+
+func TestQuestIndex_DuplicateQuestIDs(t *testing.T) {
+	dir := t.TempDir()
+	writeQuestFile(t, filepath.Join(dir, "one.yaml"), "Twin")
+	writeQuestFile(t, filepath.Join(dir, "two.yaml"), "Twin")
+	repo := NewQuestFileRepository(dir, 0)
+
+	if _, err := repo.Get("Twin"); !errors.Is(err, domain.ErrDuplicateQuestID) {
+		t.Errorf("expected ErrDuplicateQuestID from Get, got %v", err)
+	}
+	if err := repo.Save(&domain.Quest{QuestID: "Twin"}); !errors.Is(err, domain.ErrDuplicateQuestID) {
+		t.Errorf("expected ErrDuplicateQuestID from Save, got %v", err)
+	}
+	if err := repo.Delete("Twin"); !errors.Is(err, domain.ErrDuplicateQuestID) {
+		t.Errorf("expected ErrDuplicateQuestID from Delete, got %v", err)
+	}
+	if _, err := repo.Exists("Twin"); !errors.Is(err, domain.ErrDuplicateQuestID) {
+		t.Errorf("expected ErrDuplicateQuestID from Exists, got %v", err)
+	}
+	_, err := repo.List()
+	if !errors.Is(err, domain.ErrDuplicateQuestID) {
+		t.Errorf("expected ErrDuplicateQuestID from List, got %v", err)
+	} else if !strings.Contains(err.Error(), "one.yaml, two.yaml") {
+		t.Errorf("expected the error to name both files, got %v", err)
+	}
+
+	// Both files are left untouched
+	for _, name := range []string{"one.yaml", "two.yaml"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s to be kept: %v", name, err)
+		}
+	}
+}
+
+func TestQuestIndex_ListSkipsQuestsWithoutID(t *testing.T) {
+	dir := t.TempDir()
+	writeQuestFile(t, filepath.Join(dir, "a.yaml"), "QuestA")
+	writeQuestFile(t, filepath.Join(dir, "draft.yaml"), "")
+	writeQuestFile(t, filepath.Join(dir, "other-draft.yaml"), "")
+	repo := NewQuestFileRepository(dir, 0)
+
+	questIDs, err := repo.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"QuestA"}; !reflect.DeepEqual(questIDs, want) {
+		t.Errorf("expected %v, got %v", want, questIDs)
+	}
+}
+
+func TestQuestIndex_WalksOnlyWhenDirectoriesChange(t *testing.T) {
+	dir := t.TempDir()
+	writeQuestFile(t, filepath.Join(dir, "a.yaml"), "QuestA")
+	repo := NewQuestFileRepository(dir, 0)
+
+	for i := 0; i < 3; i++ {
+		if _, err := repo.Get("QuestA"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.List(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if repo.index.walks != 1 {
+		t.Errorf("expected the unchanged tree to be walked once, got %d walks", repo.index.walks)
+	}
+	if summary := repo.index.files[filepath.Join(dir, "a.yaml")].summary; summary.QuestID != "QuestA" {
+		t.Errorf("expected the summary of a.yaml to be indexed, got %+v", summary)
+	}
+
+	// A file edited in place leaves its directory unchanged
+	writeQuestFile(t, filepath.Join(dir, "a.yaml"), "QuestRenamed")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(dir, "a.yaml"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Get("QuestA"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for the old QuestID, got %v", err)
+	}
+	if _, err := repo.Get("QuestRenamed"); err != nil {
+		t.Errorf("expected the new QuestID to be found, got %v", err)
+	}
+}
@@ -1,6 +1,8 @@
 package filesystem
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,40 +11,36 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+	"github.com/tinx/pat-quest-editor/backend/internal/schema"
 )
 
 // QuestFileRepository implements QuestRepository using the filesystem.
+// Quest files are replaced atomically, and the previous version of a file
+// is kept as a backup.
 type QuestFileRepository struct {
 	basePath string
+	backups  int
+	index    *questIndex
 }
 
-// NewQuestFileRepository creates a new filesystem-based quest repository.
-func NewQuestFileRepository(basePath string) *QuestFileRepository {
-	return &QuestFileRepository{basePath: basePath}
+// NewQuestFileRepository creates a new filesystem-based quest repository
+// that keeps up to backups previous versions of each quest. If backups is
+// 0, no backups are kept.
+func NewQuestFileRepository(basePath string, backups int) *QuestFileRepository {
+	r := &QuestFileRepository{basePath: basePath, backups: backups, index: newQuestIndex(basePath)}
+	// Build the index now, so the first request doesn't have to. Errors are
+	// reported by the lookups, which retry.
+	r.index.questIDs()
+	return r
 }
 
-// List returns all quest IDs available in the repository.
+// List returns all quest IDs available in the repository. It fails with
+// domain.ErrDuplicateQuestID if several files have the same QuestID.
 func (r *QuestFileRepository) List() ([]string, error) {
-	var questIDs []string
-
-	err := filepath.Walk(r.basePath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() && (strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml")) {
-			quest, err := r.loadQuestFile(path)
-			if err != nil {
-				return nil // Skip files that can't be parsed
-			}
-			questIDs = append(questIDs, quest.QuestID)
-		}
-		return nil
-	})
-
+	questIDs, err := r.index.questIDs()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list quests: %w", err)
 	}
-
 	return questIDs, nil
 }
 
@@ -55,11 +53,17 @@ func (r *QuestFileRepository) Get(questID string) (*domain.Quest, error) {
 	return r.loadQuestFile(path)
 }
 
-// Save persists a quest to storage.
+// Save persists a quest to storage in the canonical layout of quest files.
+// An existing quest file is updated in place, so its comments are kept.
+// Afterwards, quest holds the written quest with its source positions.
 func (r *QuestFileRepository) Save(quest *domain.Quest) error {
 	// Try to find existing file, otherwise create new one
 	path, err := r.findQuestFile(quest.QuestID)
-	if err != nil {
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+	exists := err == nil
+	if !exists {
 		// Create new file with sanitized quest ID as filename
 		filename := sanitizeFilename(quest.QuestID) + ".yaml"
 		path = filepath.Join(r.basePath, filename)
@@ -75,10 +79,44 @@ func (r *QuestFileRepository) Save(quest *domain.Quest) error {
 		return fmt.Errorf("failed to marshal quest: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
-		return fmt.Errorf("failed to write quest file: %w", err)
+	var existing []byte
+	if exists {
+		if existing, err = os.ReadFile(path); err != nil {
+			return fmt.Errorf("failed to read quest file: %w", err)
+		}
+	}
+	doc, err := mergeQuestYAML(existing, data)
+	if err != nil {
+		return err
+	}
+	if data, err = encodeCanonical(doc); err != nil {
+		return err
+	}
+
+	// Leave the file alone if nothing changed
+	if !exists || !bytes.Equal(data, existing) {
+		if exists {
+			if err := r.backup(quest.QuestID, path); err != nil {
+				return err
+			}
+		}
+		if err := writeFileAtomic(path, data, 0644); err != nil {
+			return fmt.Errorf("failed to write quest file: %w", err)
+		}
 	}
 
+	// Take the quest from the written file, as encoding sorts its nodes and
+	// keeps settings of the existing file, so that Source matches it. The
+	// document as sent is kept for the schema check.
+	saved, err := r.parseQuestFile(path, data)
+	if err != nil {
+		return err
+	}
+	if quest.Document != nil {
+		saved.Document = quest.Document
+	}
+	*quest = *saved
+
 	return nil
 }
 
@@ -92,6 +130,10 @@ func (r *QuestFileRepository) Delete(questID string) error {
 	if err := validatePathWithinBase(r.basePath, path); err != nil {
 		return fmt.Errorf("invalid quest path: %w", err)
 	}
+	// Keep a backup, so that a deleted quest can be restored
+	if err := r.backup(questID, path); err != nil {
+		return err
+	}
 	return os.Remove(path)
 }
 
@@ -99,7 +141,7 @@ func (r *QuestFileRepository) Delete(questID string) error {
 func (r *QuestFileRepository) Exists(questID string) (bool, error) {
 	_, err := r.findQuestFile(questID)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
+		if errors.Is(err, domain.ErrNotFound) {
 			return false, nil
 		}
 		return false, err
@@ -107,35 +149,78 @@ func (r *QuestFileRepository) Exists(questID string) (bool, error) {
 	return true, nil
 }
 
-func (r *QuestFileRepository) findQuestFile(questID string) (string, error) {
-	var foundPath string
+// GetSource retrieves the stored file of a quest.
+func (r *QuestFileRepository) GetSource(questID string) ([]byte, error) {
+	path, err := r.findQuestFile(questID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quest file: %w", err)
+	}
+	return data, nil
+}
 
-	err := filepath.Walk(r.basePath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
+// GetWithSource retrieves a quest together with the stored file it was
+// parsed from, reading the file only once.
+func (r *QuestFileRepository) GetWithSource(questID string) (*domain.Quest, []byte, error) {
+	path, err := r.findQuestFile(questID)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read quest file: %w", err)
+	}
+	quest, err := r.parseQuestFile(path, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return quest, data, nil
+}
+
+// SaveSource replaces the stored file of a quest, or creates it if the quest
+// doesn't exist. The source must be a quest with the same QuestID.
+func (r *QuestFileRepository) SaveSource(questID string, source []byte) error {
+	var quest domain.Quest
+	if err := yaml.Unmarshal(source, &quest); err != nil {
+		return fmt.Errorf("failed to parse quest: %w", err)
+	}
+	if quest.QuestID != questID {
+		return fmt.Errorf("%w: quest ID mismatch: %s", domain.ErrInvalidInput, quest.QuestID)
+	}
+
+	path, err := r.findQuestFile(questID)
+	switch {
+	case err == nil:
+		if err := r.backup(questID, path); err != nil {
 			return err
 		}
-		if !info.IsDir() && (strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml")) {
-			quest, err := r.loadQuestFile(path)
-			if err != nil {
-				return nil
-			}
-			if quest.QuestID == questID {
-				foundPath = path
-				return filepath.SkipAll
-			}
-		}
-		return nil
-	})
-
-	if err != nil {
-		return "", fmt.Errorf("error searching for quest: %w", err)
+	case errors.Is(err, domain.ErrNotFound):
+		path = filepath.Join(r.basePath, sanitizeFilename(questID)+".yaml")
+	default:
+		return err
+	}
+	// Validate path is within base directory to prevent path traversal
+	if err := validatePathWithinBase(r.basePath, path); err != nil {
+		return fmt.Errorf("invalid quest path: %w", err)
 	}
 
-	if foundPath == "" {
-		return "", fmt.Errorf("%w: quest %s", domain.ErrNotFound, questID)
+	if err := writeFileAtomic(path, source, 0644); err != nil {
+		return fmt.Errorf("failed to write quest file: %w", err)
 	}
+	return nil
+}
 
-	return foundPath, nil
+// Path returns the path of the file of a quest.
+func (r *QuestFileRepository) Path(questID string) (string, error) {
+	return r.findQuestFile(questID)
+}
+
+// findQuestFile returns the path of the file of a quest.
+func (r *QuestFileRepository) findQuestFile(questID string) (string, error) {
+	return r.index.lookup(questID)
 }
 
 func (r *QuestFileRepository) loadQuestFile(path string) (*domain.Quest, error) {
@@ -143,15 +228,80 @@ func (r *QuestFileRepository) loadQuestFile(path string) (*domain.Quest, error)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read quest file: %w", err)
 	}
+	return r.parseQuestFile(path, data)
+}
 
-	var quest domain.Quest
-	if err := yaml.Unmarshal(data, &quest); err != nil {
+// parseQuestFile parses the contents of the quest file at path.
+func (r *QuestFileRepository) parseQuestFile(path string, data []byte) (*domain.Quest, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
 		return nil, fmt.Errorf("failed to parse quest file: %w", err)
 	}
+	var quest domain.Quest
+	if root.Kind != 0 { // empty files decode to an empty quest
+		if err := root.Decode(&quest); err != nil {
+			return nil, fmt.Errorf("failed to parse quest file: %w", err)
+		}
+		if err := root.Decode(&quest.Document); err != nil {
+			return nil, fmt.Errorf("failed to parse quest file: %w", err)
+		}
+	}
+	quest.Source = r.sourceMap(path, &root)
 
 	return &quest, nil
 }
 
+// sourceMap records the position of every value in a parsed quest file.
+// The file name is relative to the repository's base path.
+func (r *QuestFileRepository) sourceMap(path string, root *yaml.Node) *domain.SourceMap {
+	file := path
+	if rel, err := filepath.Rel(r.basePath, path); err == nil {
+		file = rel
+	}
+	source := &domain.SourceMap{File: file, Positions: make(map[string]domain.Position)}
+	node := root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if node.Kind != 0 {
+		addPositions(source.Positions, node, "$", domain.Position{Line: node.Line, Column: node.Column})
+	}
+	return source
+}
+
+func addPositions(positions map[string]domain.Position, node *yaml.Node, path string, pos domain.Position) {
+	positions[path] = pos
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			addPositions(positions, value, schema.PropertyPath(path, key.Value), domain.Position{Line: key.Line, Column: key.Column})
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			addPositions(positions, item, fmt.Sprintf("%s[%d]", path, i), domain.Position{Line: item.Line, Column: item.Column})
+		}
+	}
+}
+
+// skipDir returns filepath.SkipDir for hidden directories below the base
+// path, such as the backup directory, and nil for everything else.
+func skipDir(basePath, path string, info os.FileInfo) error {
+	if info.IsDir() && path != basePath && strings.HasPrefix(info.Name(), ".") {
+		return filepath.SkipDir
+	}
+	return nil
+}
+
+// isQuestFile reports whether path is a quest YAML file. Quest test
+// scenarios (*.test.yaml) live next to the quests but are not quests.
+func isQuestFile(path string) bool {
+	if strings.HasSuffix(path, ".test.yaml") || strings.HasSuffix(path, ".test.yml") {
+		return false
+	}
+	return strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml")
+}
+
 func sanitizeFilename(s string) string {
 	// Replace characters that are problematic in filenames
 	replacer := strings.NewReplacer(
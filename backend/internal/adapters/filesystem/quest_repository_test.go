@@ -0,0 +1,208 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+// The repository's quests are formatted with "checker fmt", so saving them
+// unchanged must reproduce their files exactly.
+func TestSave_UnchangedQuestsKeepTheirFiles(t *testing.T) {
+	dir := t.TempDir()
+	files, err := filepath.Glob("../../../../quests/*.yaml")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no quests found: %v", err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, filepath.Base(file)), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo := NewQuestFileRepository(dir, 0)
+	questIDs, err := repo.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, questID := range questIDs {
+		before, err := repo.GetSource(questID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		quest, err := repo.Get(questID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.Save(quest); err != nil {
+			t.Fatal(err)
+		}
+		after, err := repo.GetSource(questID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(after) != string(before) {
+			t.Errorf("%s: saving an unchanged quest changed its file:\n%s", questID, after)
+		}
+	}
+}
+
+func TestSave_KeepsCommentsInCanonicalLayout(t *testing.T) {
+	dir := t.TempDir()
+	source := `# Hand-written notes
+QuestTypeVersion: 1
+QuestVersion: 1
+QuestID: Commented
+QuestType: SideQuest
+DisplayName:
+    de-DE: Kommentiert # German first
+    en-US: Commented
+QuestNodes:
+    # Where it starts
+    - NodeID: 0
+      NodeType: EntryPoint
+      NextNodes: [1]
+    - NodeID: 1
+      NodeType: Dialog
+      Speaker: Mayor
+      Text:
+        en-US: |
+            Hello there.
+            How are you?
+        de-DE: Hallo.
+      NextNodes: [2] # to the end
+    - NodeID: 2
+      NodeType: Actions
+      Actions:
+        - CompleteQuest
+    # Not used anymore
+    - NodeID: 3
+      NodeType: Actions
+      Actions:
+        - FailQuest
+`
+	path := filepath.Join(dir, "commented.yaml")
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := NewQuestFileRepository(dir, 0)
+	quest, err := repo.Get("Commented")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quest.QuestVersion = 2
+	quest.QuestNodes[1].Text.EnUS = "Hello again.\nHow are you?\n"
+	quest.QuestNodes = append(quest.QuestNodes[:3], domain.QuestNode{
+		NodeID:   4,
+		NodeType: "Actions",
+		Actions:  []domain.Action{"JournalEntry", "CompleteQuest"},
+	})
+	quest.QuestNodes[1].NextNodes = []int{2, 4}
+	if err := repo.Save(quest); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# Hand-written notes
+QuestTypeVersion: 1
+QuestVersion: 2
+QuestID: Commented
+QuestType: SideQuest
+DisplayName:
+    en-US: Commented
+    de-DE: Kommentiert # German first
+QuestNodes:
+    # Where it starts
+    - NodeID: 0
+      NodeType: EntryPoint
+      NextNodes:
+        - 1
+    - NodeID: 1
+      NodeType: Dialog
+      NextNodes:
+        - 2
+        - 4 # to the end
+      Speaker: Mayor
+      Text:
+        en-US: |
+            Hello again.
+            How are you?
+        de-DE: Hallo.
+    - NodeID: 2
+      NodeType: Actions
+      Actions:
+        - CompleteQuest
+    - NodeID: 4
+      NodeType: Actions
+      Actions:
+        - JournalEntry
+        - CompleteQuest
+`
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("unexpected file:\n%s", got)
+	}
+}
+
+// Saving sorts the nodes by NodeID, so the saved quest must follow the
+// written file for its source positions to point at the right nodes.
+func TestSave_SourceMatchesSortedNodes(t *testing.T) {
+	repo := NewQuestFileRepository(t.TempDir(), 0)
+	quest := &domain.Quest{
+		QuestID: "Unsorted",
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 5, NodeType: "Actions", Actions: []domain.Action{"CompleteQuest"}},
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{5}},
+		},
+	}
+	if err := repo.Save(quest); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := repo.GetSource("Unsorted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(data), "\n")
+	for i, node := range quest.QuestNodes {
+		pos, ok := quest.Source.Lookup(fmt.Sprintf("$.QuestNodes[%d].NodeID", i))
+		if !ok || pos.Line < 1 || pos.Line > len(lines) {
+			t.Fatalf("no position for node %d: %+v", node.NodeID, pos)
+		}
+		if want := fmt.Sprintf("NodeID: %d", node.NodeID); !strings.Contains(lines[pos.Line-1], want) {
+			t.Errorf("QuestNodes[%d] is node %d, but its position is line %d: %q", i, node.NodeID, pos.Line, lines[pos.Line-1])
+		}
+	}
+}
+
+func TestGetWithSource(t *testing.T) {
+	dir := t.TempDir()
+	writeQuestFile(t, filepath.Join(dir, "a.yaml"), "QuestA")
+	repo := NewQuestFileRepository(dir, 0)
+
+	quest, source, err := repo.GetWithSource("QuestA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quest.QuestID != "QuestA" {
+		t.Errorf("expected QuestA, got %q", quest.QuestID)
+	}
+	if want := "QuestID: QuestA\nQuestNodes: []\n"; string(source) != want {
+		t.Errorf("expected source %q, got %q", want, source)
+	}
+	if _, _, err := repo.GetWithSource("Missing"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package filesystem
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+	"github.com/tinx/pat-quest-editor/backend/internal/schema"
+)
+
+// schemaNames lists the schemas in the schemas directory, <name>.json.
+var schemaNames = map[string]bool{
+	"quest":    true,
+	"item":     true,
+	"faction":  true,
+	"resource": true,
+	"npc":      true,
+	"object":   true,
+}
+
+// SchemaFileRepository implements SchemaValidator with the schemas in a
+// directory. Schemas are compiled on first use and cached.
+type SchemaFileRepository struct {
+	basePath string
+
+	mu      sync.Mutex
+	schemas map[string]*schema.Schema
+}
+
+// NewSchemaFileRepository creates a new filesystem-based schema repository.
+func NewSchemaFileRepository(basePath string) *SchemaFileRepository {
+	return &SchemaFileRepository{basePath: basePath, schemas: make(map[string]*schema.Schema)}
+}
+
+// Validate checks a document against a schema.
+func (r *SchemaFileRepository) Validate(name string, document interface{}) ([]domain.SchemaViolation, error) {
+	s, err := r.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return schemaViolations(s.Validate(document)), nil
+}
+
+// ValidateEach checks every element of a list against a schema.
+func (r *SchemaFileRepository) ValidateEach(name string, list interface{}) ([]domain.SchemaViolation, error) {
+	s, err := r.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return schemaViolations(s.ValidateEach(list)), nil
+}
+
+func schemaViolations(violations []schema.Violation) []domain.SchemaViolation {
+	result := make([]domain.SchemaViolation, len(violations))
+	for i, v := range violations {
+		result[i] = domain.SchemaViolation{Path: v.Path, Message: v.Message}
+	}
+	return result
+}
+
+// get retrieves a compiled schema by name.
+func (r *SchemaFileRepository) get(name string) (*schema.Schema, error) {
+	if !schemaNames[name] {
+		return nil, fmt.Errorf("schema %q: %w", name, domain.ErrNotFound)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.schemas[name]; ok {
+		return s, nil
+	}
+
+	path := filepath.Join(r.basePath, name+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("schema %q: %w", name, domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to read schema file: %w", err)
+	}
+	s, err := schema.Compile(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema %s: %w", path, err)
+	}
+	r.schemas[name] = s
+	return s, nil
+}
@@ -0,0 +1,150 @@
+package filesystem
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// canonicalIndent is the indentation of quest files.
+const canonicalIndent = 4
+
+// languageOrder is the order of the languages of quest texts.
+var languageOrder = []string{"en-US", "de-DE"}
+
+// canonicalKeyOrder lists the key order of the mappings in a quest file, by
+// the key the mapping (or the list of mappings) belongs to; "" is the root
+// mapping. It follows the quest schema. Keys that are not listed follow in
+// their original order.
+var canonicalKeyOrder = map[string][]string{
+	"": {"QuestTypeVersion", "QuestVersion", "QuestID", "QuestType", "DisplayName", "Repeatable", "QuestNodes", "Migrations", "Suppressions"},
+	"QuestNodes": {
+		"NodeID", "NodeType", "NextNodes", "NextNodesIfTrue", "NextNodesIfFalse",
+		"Conditions", "ConditionsRequired", "ConversationPartner", "Speaker", "Text",
+		"Options", "Messages", "Actions", "Suppressions",
+	},
+	"Options":      {"Text", "Conditions", "NextNodes"},
+	"Messages":     {"Speaker", "Text"},
+	"Suppressions": {"Rule", "Reason"},
+	"Migrations":   {"FromVersion", "Nodes", "Variables"},
+	"Nodes":        {"From", "To"},
+	"Variables":    {"From", "To"},
+
+	// Conditions and actions
+	"ResourceAvailability": {"Resource", "Available"},
+	"FactionStanding":      {"Faction", "MinimumLevel", "MaximumLevel", "Points"},
+	"Inventory":            {"Type", "MinCount", "QuestItem"},
+	"ItemsGained":          {"Type", "Count", "QuestItem"},
+	"ItemsLost":            {"Type", "Count", "QuestItem"},
+	"Variable":             {"VariableName", "Comparison", "Value"},
+	"SetVariable":          {"VariableName", "Operation", "Value"},
+	"EventTriggered":       {"Event", "Count"},
+	"ItemUsedOnObject":     {"Item", "Object"},
+	"ItemUsedOnNPC":        {"Item", "NPC"},
+}
+
+// encodeCanonical writes a quest document in the canonical layout that
+// "checker fmt" produces: keys in schema order, QuestNodes sorted by
+// NodeID, texts in languageOrder, multi-line texts as literal block
+// scalars, block style lists and mappings, and an indentation of four.
+func encodeCanonical(doc *yaml.Node) ([]byte, error) {
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		canonicalize(doc.Content[0], "")
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(canonicalIndent)
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to marshal quest: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to marshal quest: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// canonicalize brings node, found under key, into the canonical layout.
+func canonicalize(node *yaml.Node, key string) {
+	// A flow collection's line comment moves to its last line
+	if node.Style&yaml.FlowStyle != 0 && node.LineComment != "" && len(node.Content) > 0 {
+		if last := node.Content[len(node.Content)-1]; last.LineComment == "" {
+			last.LineComment, node.LineComment = node.LineComment, ""
+		}
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		node.Style = 0
+		order := canonicalKeyOrder[key]
+		if isTextMapping(node) {
+			order = languageOrder
+		}
+		orderKeys(node, order)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			node.Content[i].Style = 0
+			canonicalize(node.Content[i+1], node.Content[i].Value)
+		}
+	case yaml.SequenceNode:
+		node.Style = 0
+		if key == "QuestNodes" {
+			sort.SliceStable(node.Content, func(i, j int) bool {
+				a, errA := strconv.Atoi(mappingScalar(node.Content[i], "NodeID"))
+				b, errB := strconv.Atoi(mappingScalar(node.Content[j], "NodeID"))
+				return errA == nil && (errB != nil || a < b)
+			})
+		}
+		for _, item := range node.Content {
+			canonicalize(item, key)
+		}
+	case yaml.ScalarNode:
+		if strings.Contains(node.Value, "\n") {
+			node.Style = yaml.LiteralStyle
+		} else {
+			node.Style = 0
+		}
+	}
+}
+
+// isTextMapping reports whether a mapping holds the translations of a text.
+func isTextMapping(node *yaml.Node) bool {
+	if len(node.Content) == 0 {
+		return false
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if keyRank(languageOrder, node.Content[i].Value) == len(languageOrder) {
+			return false
+		}
+	}
+	return true
+}
+
+// orderKeys sorts the keys of a mapping by order; others keep their
+// relative order after them.
+func orderKeys(node *yaml.Node, order []string) {
+	type pair struct{ key, value *yaml.Node }
+	pairs := make([]pair, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, pair{node.Content[i], node.Content[i+1]})
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return keyRank(order, pairs[i].key.Value) < keyRank(order, pairs[j].key.Value)
+	})
+	node.Content = node.Content[:0]
+	for _, p := range pairs {
+		node.Content = append(node.Content, p.key, p.value)
+	}
+}
+
+func keyRank(order []string, key string) int {
+	for i, k := range order {
+		if k == key {
+			return i
+		}
+	}
+	return len(order)
+}
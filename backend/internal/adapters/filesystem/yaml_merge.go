@@ -0,0 +1,159 @@
+package filesystem
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// mergeQuestYAML merges the newly marshaled quest into the document of its
+// existing file, so that comments stay with the keys and nodes they belong
+// to. It returns the document to write.
+func mergeQuestYAML(existing, updated []byte) (*yaml.Node, error) {
+	var dst, src yaml.Node
+	if err := yaml.Unmarshal(updated, &src); err != nil {
+		return nil, fmt.Errorf("failed to parse quest: %w", err)
+	}
+	if existing == nil {
+		return &src, nil
+	}
+	if err := yaml.Unmarshal(existing, &dst); err != nil {
+		return nil, fmt.Errorf("failed to parse quest file: %w", err)
+	}
+	if dst.Kind != yaml.DocumentNode || len(dst.Content) == 0 {
+		return &src, nil
+	}
+	return mergeNode(&dst, &src), nil
+}
+
+// mergeNode updates dst to the content of src and returns the node to use
+// in its place: dst itself if its kind allows an in-place update, otherwise
+// src with the comments of dst.
+func mergeNode(dst, src *yaml.Node) *yaml.Node {
+	if dst.Kind != src.Kind {
+		src.HeadComment, src.LineComment, src.FootComment = dst.HeadComment, dst.LineComment, dst.FootComment
+		return src
+	}
+	switch dst.Kind {
+	case yaml.DocumentNode:
+		for i := range src.Content {
+			if i < len(dst.Content) {
+				dst.Content[i] = mergeNode(dst.Content[i], src.Content[i])
+			} else {
+				dst.Content = append(dst.Content, src.Content[i])
+			}
+		}
+		dst.Content = dst.Content[:len(src.Content)]
+	case yaml.MappingNode:
+		mergeMapping(dst, src)
+	case yaml.SequenceNode:
+		mergeSequence(dst, src)
+	case yaml.ScalarNode:
+		mergeScalar(dst, src)
+	default:
+		return src
+	}
+	return dst
+}
+
+// mergeMapping keeps the keys of dst that src still has in their order,
+// and inserts the new keys of src after the key that precedes them in src.
+func mergeMapping(dst, src *yaml.Node) {
+	existing := make(map[string]int)
+	for i := 0; i+1 < len(dst.Content); i += 2 {
+		existing[dst.Content[i].Value] = i
+	}
+	wanted := make(map[string]bool)
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		wanted[src.Content[i].Value] = true
+	}
+
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		if j, ok := existing[src.Content[i].Value]; ok {
+			dst.Content[j+1] = mergeNode(dst.Content[j+1], src.Content[i+1])
+		}
+	}
+
+	var content []*yaml.Node
+	for i := 0; i+1 < len(dst.Content); i += 2 {
+		if wanted[dst.Content[i].Value] {
+			content = append(content, dst.Content[i], dst.Content[i+1])
+		}
+	}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		if _, ok := existing[key.Value]; ok {
+			continue
+		}
+
+		// Insert after the preceding key of src, or first
+		at := 0
+		for p := i - 2; p >= 0; p -= 2 {
+			if k := indexOfKey(content, src.Content[p].Value); k >= 0 {
+				at = k + 2
+				break
+			}
+		}
+		content = append(content[:at], append([]*yaml.Node{key, value}, content[at:]...)...)
+	}
+	dst.Content = content
+}
+
+func indexOfKey(content []*yaml.Node, key string) int {
+	for i := 0; i+1 < len(content); i += 2 {
+		if content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeSequence takes the items of src in their order. Items that are
+// mappings with a NodeID are merged with the item of dst with the same
+// NodeID, all others with the item of dst at the same index.
+func mergeSequence(dst, src *yaml.Node) {
+	byNodeID := make(map[string]*yaml.Node)
+	for _, item := range dst.Content {
+		if id := mappingScalar(item, "NodeID"); id != "" {
+			byNodeID[id] = item
+		}
+	}
+
+	content := make([]*yaml.Node, 0, len(src.Content))
+	for i, item := range src.Content {
+		var match *yaml.Node
+		if id := mappingScalar(item, "NodeID"); id != "" {
+			match = byNodeID[id]
+			delete(byNodeID, id)
+		} else if i < len(dst.Content) && mappingScalar(dst.Content[i], "NodeID") == "" {
+			match = dst.Content[i]
+		}
+		if match == nil {
+			content = append(content, item)
+			continue
+		}
+		content = append(content, mergeNode(match, item))
+	}
+	dst.Content = content
+}
+
+// mergeScalar rewrites dst if its value changed.
+func mergeScalar(dst, src *yaml.Node) {
+	if dst.ShortTag() == src.ShortTag() && dst.Value == src.Value {
+		return
+	}
+	dst.Tag, dst.Value, dst.Style = src.Tag, src.Value, src.Style
+}
+
+// mappingScalar returns the value of a scalar key of a mapping node, or "".
+func mappingScalar(m *yaml.Node, key string) string {
+	if m.Kind != yaml.MappingNode {
+		return ""
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key && m.Content[i+1].Kind == yaml.ScalarNode {
+			return m.Content[i+1].Value
+		}
+	}
+	return ""
+}
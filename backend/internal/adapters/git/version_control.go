@@ -0,0 +1,474 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+	"github.com/tinx/pat-quest-editor/backend/internal/ports"
+)
+
+// logFormat makes git log print one commit per record: the fields of
+// domain.Commit separated by unit separators, ended by a record separator.
+const logFormat = "--format=%H%x1f%an%x1f%ae%x1f%aI%x1f%s%x1e"
+
+// historyFormat makes git log -z --name-only print one commit per record:
+// a record separator, the fields of domain.Commit separated by unit
+// separators, and the path of the file, each ended by a NUL.
+const historyFormat = "--format=%x1e%H%x1f%an%x1f%ae%x1f%aI%x1f%s"
+
+// validRevisionPattern matches the revisions quests can be shown at: HEAD
+// or an abbreviated or full commit hash, optionally with an ancestor suffix.
+var validRevisionPattern = regexp.MustCompile(`^(HEAD|[0-9a-fA-F]{4,64})(~[0-9]{1,4})?$`)
+
+// validAuthorPattern matches a commit author of the form "Name <email>".
+var validAuthorPattern = regexp.MustCompile(`^[^<>\n]+ <[^<>\s]+>$`)
+
+// VersionControl implements VersionControl with the git working tree that
+// contains the quests directory, by running the local git binary.
+type VersionControl struct {
+	root       string // top-level directory of the working tree
+	questsPath string // quests directory
+	questsDir  string // quests directory, relative to root
+	quests     ports.QuestRepository
+
+	// mu serializes commits, which stage and commit in separate steps.
+	mu sync.Mutex
+}
+
+// NewVersionControl creates a git adapter for the working tree that
+// contains questsPath. It fails if git is not installed or questsPath is
+// not part of a working tree.
+func NewVersionControl(questsPath string, quests ports.QuestRepository) (*VersionControl, error) {
+	out, err := runGit(questsPath, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root := strings.TrimSpace(string(out))
+
+	// git reports the root with symlinks resolved
+	realQuestsPath, err := filepath.EvalSymlinks(questsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve quests path: %w", err)
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve repository path: %w", err)
+	}
+	questsDir, err := filepath.Rel(realRoot, realQuestsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve quests path: %w", err)
+	}
+
+	return &VersionControl{
+		root:       root,
+		questsPath: questsPath,
+		questsDir:  filepath.ToSlash(questsDir),
+		quests:     quests,
+	}, nil
+}
+
+// Status returns the quest files that differ from the last commit.
+func (v *VersionControl) Status() ([]domain.QuestVCSStatus, error) {
+	out, err := v.git("status", "--porcelain=v1", "-z", "--untracked-files=all", "--", v.questsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []domain.QuestVCSStatus{}
+	entries := strings.Split(string(out), "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		x, y, path := entry[0], entry[1], entry[3:]
+		if x == 'R' || x == 'C' {
+			i++ // followed by the original path
+		}
+		if !v.isQuestPath(path) {
+			continue
+		}
+		statuses = append(statuses, domain.QuestVCSStatus{
+			QuestID: v.questIDOf(path),
+			Path:    path,
+			Status:  vcsStatus(x, y),
+		})
+	}
+	return statuses, nil
+}
+
+// vcsStatus returns the status of a file from the XY code of git status.
+func vcsStatus(x, y byte) domain.VCSStatus {
+	switch {
+	case x == '?':
+		return domain.VCSUntracked
+	case x == 'U' || y == 'U' || (x == 'A' && y == 'A') || (x == 'D' && y == 'D'):
+		return domain.VCSConflicted
+	case x == 'D' || y == 'D':
+		return domain.VCSDeleted
+	case x == 'R':
+		return domain.VCSRenamed
+	case x == 'A':
+		return domain.VCSAdded
+	default:
+		return domain.VCSModified
+	}
+}
+
+// History returns the commits that changed the file of a quest, newest
+// first, following renames. Each commit holds the path of the file as of
+// that commit.
+func (v *VersionControl) History(questID string) ([]domain.Commit, error) {
+	path, err := v.resolve(questID)
+	if err != nil {
+		return nil, err
+	}
+	return v.history(path)
+}
+
+// history returns the commits that changed the file at path, newest first,
+// following renames.
+func (v *VersionControl) history(path string) ([]domain.Commit, error) {
+	if !v.hasCommits() {
+		return []domain.Commit{}, nil
+	}
+
+	out, err := v.git("log", "--follow", "-z", "--name-only", historyFormat, "--", path)
+	if err != nil {
+		return nil, err
+	}
+	commits := []domain.Commit{}
+	for _, record := range strings.Split(string(out), "\x1e") {
+		if record == "" {
+			continue
+		}
+		fields, file, _ := strings.Cut(record, "\x00")
+		commit, err := parseCommit(fields)
+		if err != nil {
+			return nil, err
+		}
+		commit.Path = strings.Trim(file, "\n\x00")
+		commits = append(commits, *commit)
+	}
+	return commits, nil
+}
+
+// Show retrieves the file of a quest as of a revision, by the path the file
+// had then.
+func (v *VersionControl) Show(questID, revision string) ([]byte, error) {
+	if !validRevisionPattern.MatchString(revision) {
+		return nil, fmt.Errorf("%w: revision %q", domain.ErrInvalidInput, revision)
+	}
+	path, err := v.resolve(questID)
+	if err != nil {
+		return nil, err
+	}
+	if path, err = v.pathAt(path, revision); err != nil {
+		return nil, fmt.Errorf("%w: quest %s at revision %s", domain.ErrNotFound, questID, revision)
+	}
+
+	object := revision + ":" + path
+	if _, err := v.git("cat-file", "-e", object); err != nil {
+		return nil, fmt.Errorf("%w: quest %s at revision %s", domain.ErrNotFound, questID, revision)
+	}
+	return v.git("cat-file", "blob", object)
+}
+
+// pathAt returns the path that the file at path had as of a revision: its
+// path in the newest commit of its history that the revision contains.
+func (v *VersionControl) pathAt(path, revision string) (string, error) {
+	out, err := v.git("rev-parse", "--verify", "--quiet", revision+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+	hash := strings.TrimSpace(string(out))
+
+	commits, err := v.history(path)
+	if err != nil {
+		return "", err
+	}
+	for _, commit := range commits {
+		if commit.Hash == hash {
+			return commit.Path, nil
+		}
+		if _, err := v.git("merge-base", "--is-ancestor", commit.Hash, hash); err == nil {
+			return commit.Path, nil
+		}
+	}
+	return path, nil
+}
+
+// Blame returns the lines of the file of a quest in the working tree with
+// the commits that last changed them.
+func (v *VersionControl) Blame(questID string) (*domain.Blame, error) {
+	path, err := v.resolve(questID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := v.git("ls-files", "--error-unmatch", "--", path); err != nil || !v.hasCommits() {
+		return nil, fmt.Errorf("%w: quest %s has not been committed", domain.ErrNotFound, questID)
+	}
+
+	out, err := v.git("blame", "--porcelain", "--", path)
+	if err != nil {
+		return nil, err
+	}
+	return parseBlame(out), nil
+}
+
+// parseBlame parses the output of git blame --porcelain. Each line of the
+// file is preceded by a header naming its commit; the first header of a
+// commit is followed by the details of the commit.
+func parseBlame(out []byte) *domain.Blame {
+	blame := &domain.Blame{Lines: []domain.BlameLine{}, Commits: map[string]domain.Commit{}}
+	var hash string
+	var commit domain.Commit
+	var authorTime int64
+	authorTZ := time.UTC
+	for _, line := range strings.Split(string(out), "\n") {
+		if text, ok := strings.CutPrefix(line, "\t"); ok {
+			if _, ok := blame.Commits[hash]; !ok && hash != "" {
+				commit.Hash = hash
+				commit.Date = time.Unix(authorTime, 0).In(authorTZ)
+				blame.Commits[hash] = commit
+			}
+			blame.Lines = append(blame.Lines, domain.BlameLine{Line: len(blame.Lines) + 1, Hash: hash, Text: text})
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "author":
+			commit.Author = value
+		case "author-mail":
+			commit.Email = strings.Trim(value, "<>")
+		case "author-time":
+			authorTime, _ = strconv.ParseInt(value, 10, 64)
+		case "author-tz":
+			if t, err := time.Parse("-0700", value); err == nil {
+				authorTZ = t.Location()
+			}
+		case "summary":
+			commit.Subject = value
+		default:
+			if !isCommitHash(key) {
+				continue
+			}
+			// Uncommitted lines are attributed to the null hash
+			hash = key
+			if strings.Trim(hash, "0") == "" {
+				hash = ""
+			}
+			commit, authorTime, authorTZ = domain.Commit{}, 0, time.UTC
+		}
+	}
+	return blame
+}
+
+func isCommitHash(s string) bool {
+	if len(s) != 40 && len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// Commit commits the changes to the files of the given quests, including
+// new and deleted files. Other changes, staged or not, are left alone.
+func (v *VersionControl) Commit(questIDs []string, message, author string) (*domain.Commit, error) {
+	if strings.TrimSpace(message) == "" {
+		return nil, fmt.Errorf("%w: commit message required", domain.ErrInvalidInput)
+	}
+	if len(questIDs) == 0 {
+		return nil, fmt.Errorf("%w: no quests to commit", domain.ErrInvalidInput)
+	}
+	if author != "" && !validAuthorPattern.MatchString(author) {
+		return nil, fmt.Errorf("%w: author must be \"Name <email>\": %q", domain.ErrInvalidInput, author)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	var paths []string
+	for _, questID := range questIDs {
+		path, err := v.resolve(questID)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	changed, err := v.hasChanges(paths)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return nil, fmt.Errorf("%w: no changes to commit", domain.ErrInvalidInput)
+	}
+
+	if _, err := v.git(append([]string{"add", "--all", "--"}, paths...)...); err != nil {
+		return nil, err
+	}
+	args := []string{"commit", "--message", message}
+	if author != "" {
+		args = append(args, "--author", author)
+	}
+	if _, err := v.git(append(append(args, "--"), paths...)...); err != nil {
+		return nil, err
+	}
+
+	out, err := v.git("log", "-1", logFormat, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return parseCommit(strings.TrimSpace(string(out)))
+}
+
+// hasChanges reports whether any of the files differ from the last commit.
+func (v *VersionControl) hasChanges(paths []string) (bool, error) {
+	statuses, err := v.Status()
+	if err != nil {
+		return false, err
+	}
+	for _, status := range statuses {
+		for _, path := range paths {
+			if status.Path == path {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// parseCommit parses a commit printed with logFormat.
+func parseCommit(record string) (*domain.Commit, error) {
+	fields := strings.Split(strings.TrimSuffix(record, "\x1e"), "\x1f")
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("unexpected git log output: %q", record)
+	}
+	date, err := time.Parse(time.RFC3339, fields[3])
+	if err != nil {
+		return nil, fmt.Errorf("unexpected git log date: %w", err)
+	}
+	return &domain.Commit{
+		Hash:    fields[0],
+		Author:  fields[1],
+		Email:   fields[2],
+		Date:    date,
+		Subject: fields[4],
+	}, nil
+}
+
+// resolve returns the path of the file of a quest, relative to the root of
+// the working tree. The file of a deleted quest is found in the last commit.
+func (v *VersionControl) resolve(questID string) (string, error) {
+	path, err := v.quests.Path(questID)
+	if err == nil {
+		rel, err := filepath.Rel(v.questsPath, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return "", fmt.Errorf("quest file %s is outside the quests directory", path)
+		}
+		return filepath.ToSlash(filepath.Join(v.questsDir, rel)), nil
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return "", err
+	}
+
+	statuses, statusErr := v.Status()
+	if statusErr != nil {
+		return "", statusErr
+	}
+	for _, status := range statuses {
+		if status.QuestID == questID && status.Status == domain.VCSDeleted {
+			return status.Path, nil
+		}
+	}
+	return "", err
+}
+
+// isQuestPath reports whether a path relative to the root of the working
+// tree is a quest file, with the rules of the quest repository: files in
+// hidden directories, like the backups, and test scenarios are not.
+func (v *VersionControl) isQuestPath(path string) bool {
+	rel := path
+	if v.questsDir != "." {
+		var ok bool
+		if rel, ok = strings.CutPrefix(path, v.questsDir+"/"); !ok {
+			return false
+		}
+	}
+	dirs := strings.Split(rel, "/")
+	for _, dir := range dirs[:len(dirs)-1] {
+		if strings.HasPrefix(dir, ".") {
+			return false
+		}
+	}
+	if strings.HasSuffix(rel, ".test.yaml") || strings.HasSuffix(rel, ".test.yml") {
+		return false
+	}
+	return strings.HasSuffix(rel, ".yaml") || strings.HasSuffix(rel, ".yml")
+}
+
+// questIDOf returns the QuestID of a quest file in the working tree, or of
+// its last committed version if it was deleted. It returns "" if the file
+// has none.
+func (v *VersionControl) questIDOf(path string) string {
+	data, err := os.ReadFile(filepath.Join(v.root, filepath.FromSlash(path)))
+	if errors.Is(err, os.ErrNotExist) {
+		data, err = v.git("cat-file", "blob", "HEAD:"+path)
+	}
+	if err != nil {
+		return ""
+	}
+	var quest struct {
+		QuestID string `yaml:"QuestID"`
+	}
+	if err := yaml.Unmarshal(data, &quest); err != nil {
+		return ""
+	}
+	return quest.QuestID
+}
+
+// hasCommits reports whether the current branch has any commits yet.
+func (v *VersionControl) hasCommits() bool {
+	_, err := v.git("rev-parse", "--verify", "--quiet", "HEAD")
+	return err == nil
+}
+
+// git runs a git command in the root of the working tree.
+func (v *VersionControl) git(args ...string) ([]byte, error) {
+	return runGit(v.root, args...)
+}
+
+// runGit runs a git command in dir and returns its output. Pathspecs are
+// taken literally, and read-only commands don't lock the index, so they
+// can't get in the way of a concurrent commit.
+func runGit(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"--literal-pathspecs"}, args...)...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_OPTIONAL_LOCKS=0", "GIT_TERMINAL_PROMPT=0", "LC_ALL=C")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s failed: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return stdout.Bytes(), nil
+}
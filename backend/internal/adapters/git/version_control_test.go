@@ -0,0 +1,235 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/adapters/filesystem"
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+const testQuest = `QuestTypeVersion: 1
+QuestVersion: 1
+QuestID: Versioned:Quest
+QuestType: SideQuest
+DisplayName:
+    en-US: Versioned
+    de-DE: Versioniert
+QuestNodes:
+    - NodeID: 0
+      NodeType: EntryPoint
+`
+
+// newTestRepository creates a git working tree with a quests directory and
+// returns the adapter for it.
+func newTestRepository(t *testing.T) (*VersionControl, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"config", "user.name", "Test"},
+		{"config", "user.email", "test@example.com"},
+	} {
+		if _, err := runGit(root, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	questsPath := filepath.Join(root, "quests")
+	if err := os.Mkdir(questsPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	vcs, err := NewVersionControl(questsPath, filesystem.NewQuestFileRepository(questsPath, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return vcs, questsPath
+}
+
+func writeQuest(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func statusOf(t *testing.T, vcs *VersionControl) []domain.QuestVCSStatus {
+	t.Helper()
+	statuses, err := vcs.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return statuses
+}
+
+func TestCommit_TracksQuestThroughItsLifecycle(t *testing.T) {
+	vcs, questsPath := newTestRepository(t)
+	path := filepath.Join(questsPath, "Versioned_Quest.yaml")
+	writeQuest(t, path, testQuest)
+	// Backups are not quests
+	if err := os.Mkdir(filepath.Join(questsPath, ".backups"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeQuest(t, filepath.Join(questsPath, ".backups", "Versioned_Quest.yaml"), testQuest)
+
+	want := []domain.QuestVCSStatus{{QuestID: "Versioned:Quest", Path: "quests/Versioned_Quest.yaml", Status: domain.VCSUntracked}}
+	if got := statusOf(t, vcs); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected status %v, got %v", want, got)
+	}
+
+	first, err := vcs.Commit([]string{"Versioned:Quest"}, "Add quest", "Designer <designer@example.com>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Subject != "Add quest" || first.Author != "Designer" || first.Email != "designer@example.com" {
+		t.Errorf("unexpected commit %+v", first)
+	}
+	if got := statusOf(t, vcs); len(got) != 0 {
+		t.Fatalf("expected a clean working tree, got %v", got)
+	}
+	if _, err := vcs.Commit([]string{"Versioned:Quest"}, "Again", ""); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Errorf("expected committing no changes to fail with ErrInvalidInput, got %v", err)
+	}
+
+	writeQuest(t, path, strings.Replace(testQuest, "QuestVersion: 1", "QuestVersion: 2", 1))
+	want[0].Status = domain.VCSModified
+	if got := statusOf(t, vcs); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected status %v, got %v", want, got)
+	}
+	second, err := vcs.Commit([]string{"Versioned:Quest"}, "Bump version", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := vcs.History("Versioned:Quest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Hash != second.Hash || history[1].Hash != first.Hash {
+		t.Fatalf("expected history [%s %s], got %+v", second.Hash, first.Hash, history)
+	}
+
+	old, err := vcs.Show("Versioned:Quest", first.Hash[:8])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(old) != testQuest {
+		t.Errorf("expected the first version, got:\n%s", old)
+	}
+	if _, err := vcs.Show("Versioned:Quest", "--output=/tmp/x"); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Errorf("expected an invalid revision to fail with ErrInvalidInput, got %v", err)
+	}
+
+	// Deleted quests can still be committed, by the QuestID of their last commit
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	want[0].Status = domain.VCSDeleted
+	if got := statusOf(t, vcs); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected status %v, got %v", want, got)
+	}
+	if _, err := vcs.Commit([]string{"Versioned:Quest"}, "Remove quest", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := statusOf(t, vcs); len(got) != 0 {
+		t.Fatalf("expected a clean working tree, got %v", got)
+	}
+}
+
+func TestCommit_LeavesOtherChangesAlone(t *testing.T) {
+	vcs, questsPath := newTestRepository(t)
+	writeQuest(t, filepath.Join(questsPath, "Versioned_Quest.yaml"), testQuest)
+	other := strings.Replace(testQuest, "Versioned:Quest", "Other:Quest", 1)
+	writeQuest(t, filepath.Join(questsPath, "Other_Quest.yaml"), other)
+
+	if _, err := vcs.Commit([]string{"Versioned:Quest"}, "Add quest", ""); err != nil {
+		t.Fatal(err)
+	}
+	want := []domain.QuestVCSStatus{{QuestID: "Other:Quest", Path: "quests/Other_Quest.yaml", Status: domain.VCSUntracked}}
+	if got := statusOf(t, vcs); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected status %v, got %v", want, got)
+	}
+}
+
+func TestBlame(t *testing.T) {
+	vcs, questsPath := newTestRepository(t)
+	path := filepath.Join(questsPath, "Versioned_Quest.yaml")
+	writeQuest(t, path, testQuest)
+
+	if _, err := vcs.Blame("Versioned:Quest"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected blaming an uncommitted quest to fail with ErrNotFound, got %v", err)
+	}
+
+	commit, err := vcs.Commit([]string{"Versioned:Quest"}, "Add quest", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeQuest(t, path, strings.Replace(testQuest, "QuestVersion: 1", "QuestVersion: 2", 1))
+
+	blame, err := vcs.Blame("Versioned:Quest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blame.Lines) != strings.Count(testQuest, "\n") {
+		t.Fatalf("expected a blame line per line, got %d", len(blame.Lines))
+	}
+	for _, line := range blame.Lines {
+		want := commit.Hash
+		if line.Text == "QuestVersion: 2" {
+			want = ""
+		}
+		if line.Hash != want {
+			t.Errorf("line %d %q: expected commit %q, got %q", line.Line, line.Text, want, line.Hash)
+		}
+	}
+	if got := blame.Commits[commit.Hash]; got.Subject != "Add quest" || got.Author != "Test" || !got.Date.Equal(commit.Date) {
+		t.Errorf("expected commit %+v, got %+v", *commit, got)
+	}
+}
+
+func TestShow_FollowsRenames(t *testing.T) {
+	vcs, questsPath := newTestRepository(t)
+	oldPath := filepath.Join(questsPath, "old.yaml")
+	writeQuest(t, oldPath, testQuest)
+	first, err := vcs.Commit([]string{"Versioned:Quest"}, "Add quest", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := runGit(questsPath, "mv", "old.yaml", "new.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runGit(questsPath, "commit", "--quiet", "--message", "Rename quest"); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := vcs.History("Versioned:Quest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, commit := range history {
+		paths = append(paths, commit.Path)
+	}
+	if want := []string{"quests/new.yaml", "quests/old.yaml"}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("expected paths %v, got %v", want, paths)
+	}
+
+	for _, revision := range []string{first.Hash, "HEAD~1", "HEAD"} {
+		old, err := vcs.Show("Versioned:Quest", revision)
+		if err != nil {
+			t.Fatalf("revision %s: %v", revision, err)
+		}
+		if string(old) != testQuest {
+			t.Errorf("revision %s: expected the quest, got:\n%s", revision, old)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+// computeETag returns a strong entity tag for the given representation
+// parts.
+func computeETag(parts ...[]byte) string {
+	h := sha256.New()
+	for _, part := range parts {
+		fmt.Fprintf(h, "%d:", len(part))
+		h.Write(part)
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+}
+
+// questETag returns the entity tag of a quest, which covers its file and its
+// editor metadata, as PUT /api/quests/{id} replaces both. It returns "" if
+// the quest does not exist.
+func (h *Handler) questETag(questID string) (string, error) {
+	source, err := h.quests.GetSource(questID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	metadata, err := h.metadataETagData(questID)
+	if err != nil {
+		return "", err
+	}
+	return computeETag(source, metadata), nil
+}
+
+// metadataETag returns the entity tag of the editor metadata of a quest.
+func (h *Handler) metadataETag(questID string) (string, error) {
+	data, err := h.metadataETagData(questID)
+	if err != nil {
+		return "", err
+	}
+	return computeETag(data), nil
+}
+
+func (h *Handler) metadataETagData(questID string) ([]byte, error) {
+	metadata, err := h.metadata.GetQuestMetadata(questID)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(metadata)
+}
+
+// checkPreconditions evaluates the If-Match and If-None-Match headers of a
+// write request against the current entity tag of the resource, or "" if it
+// doesn't exist. Writes to an existing resource must send If-Match, so that
+// changes made since the client read the resource aren't overwritten. It
+// returns 0 if the write may proceed, or the status code to fail with.
+func checkPreconditions(r *http.Request, current string) int {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		if current != "" && (ifNoneMatch == "*" || matchesETag(ifNoneMatch, current)) {
+			return http.StatusPreconditionFailed
+		}
+	}
+
+	ifMatch := r.Header.Get("If-Match")
+	switch {
+	case ifMatch == "":
+		if current != "" {
+			return http.StatusPreconditionRequired
+		}
+	case current == "":
+		return http.StatusPreconditionFailed
+	case ifMatch != "*" && !matchesETag(ifMatch, current):
+		return http.StatusPreconditionFailed
+	}
+	return 0
+}
+
+// matchesETag reports whether a list of entity tags from an If-Match or
+// If-None-Match header contains etag. Weak tags never match, since the tags
+// of this API are strong.
+func matchesETag(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimSpace(tag) == etag {
+			return true
+		}
+	}
+	return false
+}
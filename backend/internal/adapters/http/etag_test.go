@@ -0,0 +1,45 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckPreconditions(t *testing.T) {
+	const current = `"abc"`
+
+	tests := []struct {
+		name        string
+		current     string
+		ifMatch     string
+		ifNoneMatch string
+		want        int
+	}{
+		{"matching tag", current, `"abc"`, "", 0},
+		{"one of several tags", current, `"old", "abc"`, "", 0},
+		{"any version", current, "*", "", 0},
+		{"changed since read", current, `"old"`, "", http.StatusPreconditionFailed},
+		{"weak tag", current, `W/"abc"`, "", http.StatusPreconditionFailed},
+		{"missing If-Match", current, "", "", http.StatusPreconditionRequired},
+		{"new resource", "", "", "", 0},
+		{"new resource only", "", "", "*", 0},
+		{"created meanwhile", current, "", "*", http.StatusPreconditionFailed},
+		{"deleted meanwhile", "", `"abc"`, "", http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/api/quests/Q", nil)
+			if tt.ifMatch != "" {
+				r.Header.Set("If-Match", tt.ifMatch)
+			}
+			if tt.ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			if got := checkPreconditions(r, tt.current); got != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"unicode"
 
 	"github.com/tinx/pat-quest-editor/backend/internal/domain"
 	"github.com/tinx/pat-quest-editor/backend/internal/ports"
@@ -19,6 +23,9 @@ const maxRequestBodySize = 10 * 1024 * 1024
 // maxQuestIDLength is the maximum allowed length for quest IDs
 const maxQuestIDLength = 100
 
+// maxAuthorLength is the maximum length of the author recorded with a revision
+const maxAuthorLength = 200
+
 // validQuestIDPattern matches the schema pattern: must start with uppercase letter,
 // followed by alphanumeric, dots, hyphens, underscores, or colons.
 // Schema: ^[A-Z][A-Za-z0-9\.\-_:]*$
@@ -43,7 +50,21 @@ type Handler struct {
 	quests    ports.QuestRepository
 	refData   ports.ReferenceDataRepository
 	metadata  ports.MetadataRepository
+	revisions ports.RevisionRepository
 	validator ports.QuestValidator
+	analyzer  ports.QuestAnalyzer
+	fixer     ports.QuestFixer
+	differ    ports.QuestDiffer
+	vcs       ports.VersionControl // nil if the quests aren't under version control
+
+	// authorHeader names the request header that holds the user
+	// authenticated by a proxy in front of the editor, or is empty.
+	authorHeader string
+
+	// writeMu makes checking the preconditions of a write and the write
+	// itself atomic. Reads of a quest hold it for reading, so that they
+	// don't see a write half done.
+	writeMu sync.RWMutex
 }
 
 // NewHandler creates a new HTTP handler.
@@ -51,19 +72,31 @@ func NewHandler(
 	quests ports.QuestRepository,
 	refData ports.ReferenceDataRepository,
 	metadata ports.MetadataRepository,
+	revisions ports.RevisionRepository,
 	validator ports.QuestValidator,
+	analyzer ports.QuestAnalyzer,
+	fixer ports.QuestFixer,
+	differ ports.QuestDiffer,
+	vcs ports.VersionControl,
+	authorHeader string,
 ) *Handler {
 	return &Handler{
-		quests:    quests,
-		refData:   refData,
-		metadata:  metadata,
-		validator: validator,
+		quests:       quests,
+		refData:      refData,
+		metadata:     metadata,
+		revisions:    revisions,
+		validator:    validator,
+		analyzer:     analyzer,
+		fixer:        fixer,
+		differ:       differ,
+		vcs:          vcs,
+		authorHeader: authorHeader,
 	}
 }
 
 // RegisterRoutes registers all API routes on the given mux.
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
-	// Quest endpoints
+	// Quest endpoints (including /api/quests/{id}/paths, /fix, /diff, /backups and /revisions)
 	mux.HandleFunc("/api/quests", h.handleQuests)
 	mux.HandleFunc("/api/quests/", h.handleQuest)
 
@@ -79,6 +112,9 @@ func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 
 	// Validation endpoint
 	mux.HandleFunc("/api/validate", h.handleValidate)
+
+	// Version control endpoints
+	mux.HandleFunc("/api/vcs/", h.handleVCS)
 }
 
 func (h *Handler) handleQuests(w http.ResponseWriter, r *http.Request) {
@@ -91,7 +127,7 @@ func (h *Handler) handleQuests(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) handleQuest(w http.ResponseWriter, r *http.Request) {
-	questID := strings.TrimPrefix(r.URL.Path, "/api/quests/")
+	questID, subresource, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/quests/"), "/")
 	if questID == "" {
 		http.Error(w, "quest ID required", http.StatusBadRequest)
 		return
@@ -103,6 +139,11 @@ func (h *Handler) handleQuest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if subresource != "" {
+		h.handleQuestSubresource(w, r, questID, subresource)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		h.getQuest(w, r, questID)
@@ -115,41 +156,124 @@ func (h *Handler) handleQuest(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleQuestSubresource dispatches /api/quests/{id}/{subresource} requests.
+func (h *Handler) handleQuestSubresource(w http.ResponseWriter, r *http.Request, questID, subresource string) {
+	switch subresource {
+	case "paths":
+		h.handleQuestPaths(w, r, questID)
+	case "fix":
+		h.handleQuestFix(w, r, questID)
+	case "diff":
+		h.handleQuestDiff(w, r, questID)
+	case "backups":
+		h.listQuestBackups(w, r, questID)
+	case "revisions":
+		h.listQuestRevisions(w, r, questID)
+	default:
+		// /api/quests/{id}/revisions/{revisionId}[/restore]
+		if revision, ok := strings.CutPrefix(subresource, "revisions/"); ok {
+			revision, restore := strings.CutSuffix(revision, "/restore")
+			revisionID, err := strconv.ParseInt(revision, 10, 64)
+			if err != nil {
+				http.Error(w, "invalid revision ID", http.StatusBadRequest)
+				return
+			}
+			if restore {
+				h.restoreQuestRevision(w, r, questID, revisionID)
+			} else {
+				h.getQuestRevision(w, r, questID, revisionID)
+			}
+			return
+		}
+		// /api/quests/{id}/backups/{backupId}/restore
+		if backupID, ok := strings.CutPrefix(subresource, "backups/"); ok {
+			if backupID, ok := strings.CutSuffix(backupID, "/restore"); ok && !strings.Contains(backupID, "/") {
+				h.restoreQuestBackup(w, r, questID, backupID)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}
+}
+
 func (h *Handler) listQuests(w http.ResponseWriter, r *http.Request) {
 	questIDs, err := h.quests.List()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), repositoryErrorStatus(err))
 		return
 	}
 	h.writeJSON(w, questIDs)
 }
 
+// questResponse is the representation of a quest in the API.
+type questResponse struct {
+	Quest    *domain.Quest         `json:"quest"`
+	Metadata *domain.QuestMetadata `json:"metadata,omitempty"`
+}
+
 func (h *Handler) getQuest(w http.ResponseWriter, r *http.Request, questID string) {
-	quest, err := h.quests.Get(questID)
+	h.writeMu.RLock()
+	defer h.writeMu.RUnlock()
+	response, etag, err := h.currentQuest(questID)
 	if err != nil {
-		if errors.Is(err, domain.ErrNotFound) {
-			http.Error(w, err.Error(), http.StatusNotFound)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), repositoryErrorStatus(err))
 		return
 	}
 
-	// Include metadata in response (log error but don't fail the request)
+	w.Header().Set("ETag", etag)
+	h.writeJSON(w, response)
+}
+
+// currentQuest returns the stored version of a quest and its entity tag,
+// both built from a single read of the quest file and its metadata. The
+// caller must hold h.writeMu.
+func (h *Handler) currentQuest(questID string) (*questResponse, string, error) {
+	quest, source, err := h.quests.GetWithSource(questID)
+	if err != nil {
+		return nil, "", err
+	}
 	metadata, err := h.metadata.GetQuestMetadata(questID)
 	if err != nil {
-		log.Printf("Warning: failed to fetch metadata for quest %s: %v", questID, err)
+		return nil, "", err
+	}
+	metadataData, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, "", err
 	}
+	return &questResponse{Quest: quest, Metadata: metadata}, computeETag(source, metadataData), nil
+}
 
-	response := struct {
-		Quest    *domain.Quest         `json:"quest"`
-		Metadata *domain.QuestMetadata `json:"metadata,omitempty"`
-	}{
-		Quest:    quest,
-		Metadata: metadata,
+// checkQuestWrite checks the preconditions of a write to a quest, as
+// saveQuest does, and answers the request if they fail. The caller must
+// hold h.writeMu.
+func (h *Handler) checkQuestWrite(w http.ResponseWriter, r *http.Request, questID string) bool {
+	current, err := h.questETag(questID)
+	if err != nil {
+		http.Error(w, err.Error(), repositoryErrorStatus(err))
+		return false
 	}
+	if status := checkPreconditions(r, current); status != 0 {
+		h.rejectQuestWrite(w, questID, status)
+		return false
+	}
+	return true
+}
 
-	h.writeJSON(w, response)
+// rejectQuestWrite answers a write to a quest whose preconditions failed.
+// If the quest changed since the client read it, the response holds the
+// current version, so that the client can merge.
+func (h *Handler) rejectQuestWrite(w http.ResponseWriter, questID string, status int) {
+	if status == http.StatusPreconditionRequired {
+		http.Error(w, "If-Match header required", status)
+		return
+	}
+	response, etag, err := h.currentQuest(questID)
+	if err != nil {
+		http.Error(w, "quest has been changed", status)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	h.writeJSONStatus(w, status, response)
 }
 
 func (h *Handler) saveQuest(w http.ResponseWriter, r *http.Request, questID string) {
@@ -158,7 +282,7 @@ func (h *Handler) saveQuest(w http.ResponseWriter, r *http.Request, questID stri
 	}
 
 	var request struct {
-		Quest    domain.Quest          `json:"quest"`
+		Quest    json.RawMessage       `json:"quest"`
 		Metadata *domain.QuestMetadata `json:"metadata,omitempty"`
 	}
 
@@ -167,22 +291,41 @@ func (h *Handler) saveQuest(w http.ResponseWriter, r *http.Request, questID stri
 		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
 		return
 	}
+	var quest domain.Quest
+	if err := decodeQuest(request.Quest, &quest); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Ensure quest ID in URL matches body
-	if request.Quest.QuestID != questID {
+	if quest.QuestID != questID {
 		http.Error(w, "quest ID mismatch", http.StatusBadRequest)
 		return
 	}
 
-	// Validate the quest
-	validationResult := h.validator.Validate(&request.Quest)
+	// Refuse to overwrite changes the client hasn't seen
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+	current, err := h.questETag(questID)
+	if err != nil {
+		http.Error(w, err.Error(), repositoryErrorStatus(err))
+		return
+	}
+	if status := checkPreconditions(r, current); status != 0 {
+		h.rejectQuestWrite(w, questID, status)
+		return
+	}
 
 	// Save quest even if invalid (allows work-in-progress saves)
-	if err := h.quests.Save(&request.Quest); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	initial := h.initialRevision(questID)
+	if err := h.quests.Save(&quest); err != nil {
+		http.Error(w, err.Error(), repositoryErrorStatus(err))
 		return
 	}
 
+	// Validate the quest after saving, so issues point into the written file
+	validationResult := h.validator.Validate(&quest)
+
 	// Save metadata if provided
 	if request.Metadata != nil {
 		request.Metadata.QuestID = questID
@@ -191,16 +334,94 @@ func (h *Handler) saveQuest(w http.ResponseWriter, r *http.Request, questID stri
 		}
 	}
 
+	// Keep a revision of every save that changed the quest
+	if h.setQuestETag(w, questID) != current {
+		h.recordRevision(r, questID, initial)
+	}
 	h.writeJSON(w, validationResult)
 }
 
+// initialRevision returns the stored version of a quest if it has no
+// revisions yet, or nil. Passed to recordRevision after a write, it keeps
+// the version the quest had before the editor first changed it. The caller
+// must hold h.writeMu.
+func (h *Handler) initialRevision(questID string) *domain.QuestRevision {
+	revisions, err := h.revisions.ListRevisions(questID)
+	if err != nil {
+		log.Printf("Warning: failed to list revisions of quest %s: %v", questID, err)
+		return nil
+	}
+	if len(revisions) > 0 {
+		return nil
+	}
+	revision, err := h.storedRevision(questID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrNotFound) {
+			log.Printf("Warning: failed to record revision of quest %s: %v", questID, err)
+		}
+		return nil
+	}
+	return revision
+}
+
+// recordRevision adds the stored version of a quest to its revision
+// history, after initial, the version before the write, if not nil.
+// Failures are logged, as the quest itself has been saved.
+func (h *Handler) recordRevision(r *http.Request, questID string, initial *domain.QuestRevision) {
+	if initial != nil {
+		if err := h.revisions.SaveRevision(initial); err != nil {
+			log.Printf("Warning: failed to record revision of quest %s: %v", questID, err)
+		}
+	}
+
+	revision, err := h.storedRevision(questID)
+	if err != nil {
+		log.Printf("Warning: failed to record revision of quest %s: %v", questID, err)
+		return
+	}
+	revision.Author = h.requestAuthor(r)
+	if err := h.revisions.SaveRevision(revision); err != nil {
+		log.Printf("Warning: failed to record revision of quest %s: %v", questID, err)
+	}
+}
+
+// storedRevision returns the stored version of a quest as a revision
+// without an author.
+func (h *Handler) storedRevision(questID string) (*domain.QuestRevision, error) {
+	source, err := h.quests.GetSource(questID)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := h.metadata.GetQuestMetadata(questID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.QuestRevision{QuestID: questID, Source: string(source), Metadata: metadata}, nil
+}
+
+// requestAuthor returns the user a proxy authenticated the request for, or
+// "" if unknown.
+func (h *Handler) requestAuthor(r *http.Request) string {
+	if h.authorHeader == "" {
+		return ""
+	}
+	author := strings.TrimSpace(r.Header.Get(h.authorHeader))
+	if len(author) > maxAuthorLength || strings.ContainsFunc(author, unicode.IsControl) {
+		return ""
+	}
+	return author
+}
+
 func (h *Handler) deleteQuest(w http.ResponseWriter, r *http.Request, questID string) {
+	// Refuse to delete changes the client hasn't seen
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+	if !h.checkQuestWrite(w, r, questID) {
+		return
+	}
+
 	if err := h.quests.Delete(questID); err != nil {
-		if errors.Is(err, domain.ErrNotFound) {
-			http.Error(w, err.Error(), http.StatusNotFound)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), repositoryErrorStatus(err))
 		return
 	}
 
@@ -212,6 +433,364 @@ func (h *Handler) deleteQuest(w http.ResponseWriter, r *http.Request, questID st
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (h *Handler) handleQuestPaths(w http.ResponseWriter, r *http.Request, questID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	quest, err := h.quests.Get(questID)
+	if err != nil {
+		http.Error(w, err.Error(), repositoryErrorStatus(err))
+		return
+	}
+
+	h.writeJSON(w, h.analyzer.Paths(quest))
+}
+
+// handleQuestFix repairs the issues of a quest that have an unambiguous fix.
+// With ?dryRun=true, the fixes are only previewed.
+func (h *Handler) handleQuestFix(w http.ResponseWriter, r *http.Request, questID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+
+	// Applying the fixes writes the quest, so like a PUT it must not
+	// overwrite changes the client hasn't seen
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+	if !dryRun && !h.checkQuestWrite(w, r, questID) {
+		return
+	}
+
+	source, err := h.quests.GetSource(questID)
+	if err != nil {
+		http.Error(w, err.Error(), repositoryErrorStatus(err))
+		return
+	}
+
+	result, err := h.fixer.Fix(source)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if dryRun || len(result.Fixes) == 0 {
+		h.writeJSON(w, result)
+		return
+	}
+
+	initial := h.initialRevision(questID)
+	if err := h.quests.SaveSource(questID, result.Source); err != nil {
+		http.Error(w, err.Error(), repositoryErrorStatus(err))
+		return
+	}
+	result.Applied = true
+
+	quest, err := h.quests.Get(questID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result.Validation = h.validator.Validate(quest)
+
+	h.setQuestETag(w, questID)
+	h.recordRevision(r, questID, initial)
+	h.writeJSON(w, result)
+}
+
+// handleQuestDiff compares an earlier version of a quest, a stored revision
+// (?revision=) or a commit (?commit=), with the current quest file.
+func (h *Handler) handleQuestDiff(w http.ResponseWriter, r *http.Request, questID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var from []byte
+	diff := &domain.QuestDiff{QuestID: questID}
+	revision, commit := r.URL.Query().Get("revision"), r.URL.Query().Get("commit")
+	switch {
+	case revision != "" && commit == "":
+		revisionID, err := strconv.ParseInt(revision, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid revision ID", http.StatusBadRequest)
+			return
+		}
+		stored, err := h.revisions.GetRevision(questID, revisionID)
+		if err != nil {
+			http.Error(w, err.Error(), repositoryErrorStatus(err))
+			return
+		}
+		from, diff.From = []byte(stored.Source), "revision "+revision
+	case commit != "" && revision == "":
+		if h.vcs == nil {
+			http.Error(w, "quests are not under version control", http.StatusNotImplemented)
+			return
+		}
+		var err error
+		if from, err = h.vcs.Show(questID, commit); err != nil {
+			http.Error(w, err.Error(), repositoryErrorStatus(err))
+			return
+		}
+		diff.From = "commit " + commit
+	default:
+		http.Error(w, "either revision or commit required", http.StatusBadRequest)
+		return
+	}
+
+	// A deleted quest is compared as an empty one
+	current, err := h.quests.GetSource(questID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		http.Error(w, err.Error(), repositoryErrorStatus(err))
+		return
+	}
+
+	if diff.Changes, err = h.differ.Diff(from, current); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	h.writeJSON(w, diff)
+}
+
+func (h *Handler) listQuestBackups(w http.ResponseWriter, r *http.Request, questID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	backups, err := h.quests.ListBackups(questID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.writeJSON(w, backups)
+}
+
+// restoreQuestBackup replaces a quest with one of its backups and returns
+// the validation result of the restored quest.
+func (h *Handler) restoreQuestBackup(w http.ResponseWriter, r *http.Request, questID, backupID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+	if !h.checkQuestWrite(w, r, questID) {
+		return
+	}
+
+	initial := h.initialRevision(questID)
+	if err := h.quests.RestoreBackup(questID, backupID); err != nil {
+		http.Error(w, err.Error(), repositoryErrorStatus(err))
+		return
+	}
+
+	quest, err := h.quests.Get(questID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.setQuestETag(w, questID)
+	h.recordRevision(r, questID, initial)
+	h.writeJSON(w, h.validator.Validate(quest))
+}
+
+// handleVCS dispatches the /api/vcs requests: the status of the quest files,
+// commits, and the history of a quest at /api/vcs/quests/{id}/history,
+// /blame and /revisions/{revision}.
+func (h *Handler) handleVCS(w http.ResponseWriter, r *http.Request) {
+	if h.vcs == nil {
+		http.Error(w, "quests are not under version control", http.StatusNotImplemented)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/vcs/")
+	switch path {
+	case "status":
+		h.vcsStatus(w, r)
+		return
+	case "commit":
+		h.vcsCommit(w, r)
+		return
+	}
+
+	questPath, ok := strings.CutPrefix(path, "quests/")
+	questID, subresource, _ := strings.Cut(questPath, "/")
+	if !ok || questID == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if err := validateQuestID(questID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch subresource {
+	case "history":
+		commits, err := h.vcs.History(questID)
+		if err != nil {
+			http.Error(w, err.Error(), repositoryErrorStatus(err))
+			return
+		}
+		h.writeJSON(w, commits)
+	case "blame":
+		blame, err := h.vcs.Blame(questID)
+		if err != nil {
+			http.Error(w, err.Error(), repositoryErrorStatus(err))
+			return
+		}
+		h.writeJSON(w, blame)
+	default:
+		revision, ok := strings.CutPrefix(subresource, "revisions/")
+		if !ok || revision == "" || strings.Contains(revision, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		source, err := h.vcs.Show(questID, revision)
+		if err != nil {
+			http.Error(w, err.Error(), repositoryErrorStatus(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+		w.Write(source)
+	}
+}
+
+func (h *Handler) vcsStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	statuses, err := h.vcs.Status()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.writeJSON(w, statuses)
+}
+
+// vcsCommit commits the changes to the given quests and returns the new
+// commit.
+func (h *Handler) vcsCommit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireJSONContentType(w, r) {
+		return
+	}
+
+	var request struct {
+		QuestIDs []string `json:"questIds"`
+		Message  string   `json:"message"`
+		Author   string   `json:"author,omitempty"`
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, questID := range request.QuestIDs {
+		if err := validateQuestID(questID); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Don't commit a quest while it is being written
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+
+	commit, err := h.vcs.Commit(request.QuestIDs, request.Message, request.Author)
+	if err != nil {
+		http.Error(w, err.Error(), repositoryErrorStatus(err))
+		return
+	}
+	h.writeJSONStatus(w, http.StatusCreated, commit)
+}
+
+func (h *Handler) listQuestRevisions(w http.ResponseWriter, r *http.Request, questID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	revisions, err := h.revisions.ListRevisions(questID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.writeJSON(w, revisions)
+}
+
+func (h *Handler) getQuestRevision(w http.ResponseWriter, r *http.Request, questID string, revisionID int64) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	revision, err := h.revisions.GetRevision(questID, revisionID)
+	if err != nil {
+		http.Error(w, err.Error(), repositoryErrorStatus(err))
+		return
+	}
+	h.writeJSON(w, revision)
+}
+
+// restoreQuestRevision replaces a quest and its metadata with one of its
+// revisions and returns the validation result of the restored quest. The
+// restore is recorded as a new revision, so it can be undone.
+func (h *Handler) restoreQuestRevision(w http.ResponseWriter, r *http.Request, questID string, revisionID int64) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+
+	revision, err := h.revisions.GetRevision(questID, revisionID)
+	if err != nil {
+		http.Error(w, err.Error(), repositoryErrorStatus(err))
+		return
+	}
+	current, err := h.questETag(questID)
+	if err != nil {
+		http.Error(w, err.Error(), repositoryErrorStatus(err))
+		return
+	}
+	if status := checkPreconditions(r, current); status != 0 {
+		h.rejectQuestWrite(w, questID, status)
+		return
+	}
+	if err := h.quests.SaveSource(questID, []byte(revision.Source)); err != nil {
+		http.Error(w, err.Error(), repositoryErrorStatus(err))
+		return
+	}
+	if err := h.metadata.SaveQuestMetadata(revision.Metadata); err != nil {
+		log.Printf("Warning: failed to restore metadata for quest %s: %v", questID, err)
+	}
+
+	quest, err := h.quests.Get(questID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if h.setQuestETag(w, questID) != current {
+		h.recordRevision(r, questID, nil)
+	}
+	h.writeJSON(w, h.validator.Validate(quest))
+}
+
 func (h *Handler) handleItems(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -302,6 +881,12 @@ func (h *Handler) handleMetadata(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		etag, err := h.metadataETag(questID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("ETag", etag)
 		h.writeJSON(w, metadata)
 
 	case http.MethodPut:
@@ -315,10 +900,27 @@ func (h *Handler) handleMetadata(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		metadata.QuestID = questID
+
+		// Refuse to overwrite changes the client hasn't seen
+		h.writeMu.Lock()
+		defer h.writeMu.Unlock()
+		current, err := h.metadataETag(questID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if status := checkPreconditions(r, current); status != 0 {
+			h.rejectMetadataWrite(w, questID, status)
+			return
+		}
+
 		if err := h.metadata.SaveQuestMetadata(&metadata); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		if etag, err := h.metadataETag(questID); err == nil {
+			w.Header().Set("ETag", etag)
+		}
 		w.WriteHeader(http.StatusNoContent)
 
 	default:
@@ -326,6 +928,24 @@ func (h *Handler) handleMetadata(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// rejectMetadataWrite answers a write to quest metadata whose preconditions
+// failed, with the current metadata if it changed.
+func (h *Handler) rejectMetadataWrite(w http.ResponseWriter, questID string, status int) {
+	if status == http.StatusPreconditionRequired {
+		http.Error(w, "If-Match header required", status)
+		return
+	}
+	metadata, err := h.metadata.GetQuestMetadata(questID)
+	if err != nil {
+		http.Error(w, "metadata has been changed", status)
+		return
+	}
+	if etag, err := h.metadataETag(questID); err == nil {
+		w.Header().Set("ETag", etag)
+	}
+	h.writeJSONStatus(w, status, metadata)
+}
+
 func (h *Handler) handleValidate(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -336,9 +956,14 @@ func (h *Handler) handleValidate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var quest domain.Quest
 	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
-	if err := json.NewDecoder(r.Body).Decode(&quest); err != nil {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	var quest domain.Quest
+	if err := decodeQuest(data, &quest); err != nil {
 		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -347,13 +972,54 @@ func (h *Handler) handleValidate(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, result)
 }
 
+// decodeQuest decodes a quest sent as JSON, keeping the decoded document
+// for the schema validation.
+func decodeQuest(data []byte, quest *domain.Quest) error {
+	if err := json.Unmarshal(data, quest); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &quest.Document)
+}
+
 func (h *Handler) writeJSON(w http.ResponseWriter, data interface{}) {
+	h.writeJSONStatus(w, http.StatusOK, data)
+}
+
+func (h *Handler) writeJSONStatus(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
 		log.Printf("Error encoding JSON response: %v", err)
 	}
 }
 
+// setQuestETag sets the ETag header to the entity tag of a quest that was
+// just written, and returns it.
+func (h *Handler) setQuestETag(w http.ResponseWriter, questID string) string {
+	etag, err := h.questETag(questID)
+	if err != nil {
+		log.Printf("Warning: failed to compute ETag for quest %s: %v", questID, err)
+		return ""
+	}
+	w.Header().Set("ETag", etag)
+	return etag
+}
+
+// repositoryErrorStatus returns the HTTP status code for an error of the
+// quest repository.
+func repositoryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrDuplicateQuestID):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrInvalidInput):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // requireJSONContentType validates that the request has application/json content type.
 // Returns true if valid, false if an error response was sent.
 func requireJSONContentType(w http.ResponseWriter, r *http.Request) bool {
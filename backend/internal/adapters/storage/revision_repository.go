@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+// RevisionRetention limits the revisions kept of each quest. A zero value
+// means no limit.
+type RevisionRetention struct {
+	// MaxCount is the number of revisions kept per quest.
+	MaxCount int
+	// MaxAge is the age after which revisions are removed.
+	MaxAge time.Duration
+}
+
+// SaveRevision records a version of a quest and assigns its ID and creation
+// time. Revisions beyond the retention policy are removed.
+func (r *SQLiteMetadataRepository) SaveRevision(revision *domain.QuestRevision) error {
+	positions := map[int]domain.NodePosition{}
+	if revision.Metadata != nil && revision.Metadata.NodePositions != nil {
+		positions = revision.Metadata.NodePositions
+	}
+	positionsJSON, err := json.Marshal(positions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal positions: %w", err)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to save revision: %w", err)
+	}
+	defer tx.Rollback()
+
+	created := time.Now().UTC()
+	result, err := tx.Exec(`
+		INSERT INTO quest_revisions (quest_id, created, author, source, node_positions)
+		VALUES (?, ?, ?, ?, ?)
+	`, revision.QuestID, created.UnixNano(), revision.Author, revision.Source, string(positionsJSON))
+	if err != nil {
+		return fmt.Errorf("failed to save revision: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to save revision: %w", err)
+	}
+
+	if r.retention.MaxCount > 0 {
+		_, err := tx.Exec(`
+			DELETE FROM quest_revisions WHERE quest_id = ? AND id NOT IN (
+				SELECT id FROM quest_revisions WHERE quest_id = ? ORDER BY id DESC LIMIT ?
+			)
+		`, revision.QuestID, revision.QuestID, r.retention.MaxCount)
+		if err != nil {
+			return fmt.Errorf("failed to remove old revisions: %w", err)
+		}
+	}
+	if r.retention.MaxAge > 0 {
+		cutoff := created.Add(-r.retention.MaxAge)
+		if _, err := tx.Exec("DELETE FROM quest_revisions WHERE created < ?", cutoff.UnixNano()); err != nil {
+			return fmt.Errorf("failed to remove old revisions: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save revision: %w", err)
+	}
+	revision.ID, revision.Created, revision.Size = id, created, int64(len(revision.Source))
+	return nil
+}
+
+// ListRevisions returns the revisions of a quest, newest first, without
+// their content.
+func (r *SQLiteMetadataRepository) ListRevisions(questID string) ([]domain.QuestRevision, error) {
+	rows, err := r.db.Query(`
+		SELECT id, created, author, length(CAST(source AS BLOB)) FROM quest_revisions
+		WHERE quest_id = ? ORDER BY id DESC
+	`, questID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []domain.QuestRevision{}
+	for rows.Next() {
+		revision := domain.QuestRevision{QuestID: questID}
+		var created int64
+		if err := rows.Scan(&revision.ID, &created, &revision.Author, &revision.Size); err != nil {
+			return nil, fmt.Errorf("failed to list revisions: %w", err)
+		}
+		revision.Created = time.Unix(0, created).UTC()
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+// GetRevision retrieves a revision of a quest.
+func (r *SQLiteMetadataRepository) GetRevision(questID string, revisionID int64) (*domain.QuestRevision, error) {
+	revision := domain.QuestRevision{ID: revisionID, QuestID: questID}
+	var created int64
+	var positionsJSON string
+	err := r.db.QueryRow(`
+		SELECT created, author, source, node_positions FROM quest_revisions
+		WHERE quest_id = ? AND id = ?
+	`, questID, revisionID).Scan(&created, &revision.Author, &revision.Source, &positionsJSON)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: revision %d of quest %s", domain.ErrNotFound, revisionID, questID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revision: %w", err)
+	}
+
+	var positions map[int]domain.NodePosition
+	if err := json.Unmarshal([]byte(positionsJSON), &positions); err != nil {
+		return nil, fmt.Errorf("failed to parse positions: %w", err)
+	}
+	revision.Created = time.Unix(0, created).UTC()
+	revision.Size = int64(len(revision.Source))
+	revision.Metadata = &domain.QuestMetadata{QuestID: questID, NodePositions: positions}
+	return &revision, nil
+}
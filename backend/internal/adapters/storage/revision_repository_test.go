@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+func newTestRepository(t *testing.T, retention RevisionRetention) *SQLiteMetadataRepository {
+	t.Helper()
+	repo, err := NewSQLiteMetadataRepository(filepath.Join(t.TempDir(), "editor.db"), retention)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+func TestSaveRevision_RoundTrip(t *testing.T) {
+	repo := newTestRepository(t, RevisionRetention{})
+	metadata := &domain.QuestMetadata{
+		QuestID:       "Revised:Quest",
+		NodePositions: map[int]domain.NodePosition{0: {X: 10, Y: 20}},
+	}
+	saved := &domain.QuestRevision{QuestID: "Revised:Quest", Author: "designer", Source: "QuestID: Revised:Quest\n", Metadata: metadata}
+	if err := repo.SaveRevision(saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.ID == 0 || saved.Created.IsZero() {
+		t.Fatalf("expected ID and creation time to be assigned, got %+v", saved)
+	}
+
+	got, err := repo.GetRevision("Revised:Quest", saved.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Source != saved.Source || got.Author != "designer" || !reflect.DeepEqual(got.Metadata, metadata) {
+		t.Errorf("expected %+v, got %+v", saved, got)
+	}
+	if !got.Created.Equal(saved.Created) {
+		t.Errorf("expected creation time %v, got %v", saved.Created, got.Created)
+	}
+
+	if _, err := repo.GetRevision("Other:Quest", saved.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected a revision of another quest to be ErrNotFound, got %v", err)
+	}
+}
+
+func TestSaveRevision_AppliesRetention(t *testing.T) {
+	repo := newTestRepository(t, RevisionRetention{MaxCount: 2})
+
+	var ids []int64
+	for _, questID := range []string{"Revised:Quest", "Revised:Quest", "Other:Quest", "Revised:Quest"} {
+		revision := &domain.QuestRevision{QuestID: questID, Source: questID}
+		if err := repo.SaveRevision(revision); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, revision.ID)
+	}
+
+	revisions, err := repo.ListRevisions("Revised:Quest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int64
+	for _, revision := range revisions {
+		got = append(got, revision.ID)
+	}
+	if want := []int64{ids[3], ids[1]}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the newest revisions %v, got %v", want, got)
+	}
+	if others, err := repo.ListRevisions("Other:Quest"); err != nil || len(others) != 1 {
+		t.Errorf("expected the revision of another quest to be kept, got %v, %v", others, err)
+	}
+}
+
+func TestSaveRevision_RemovesExpiredRevisions(t *testing.T) {
+	repo := newTestRepository(t, RevisionRetention{MaxAge: time.Hour})
+	if _, err := repo.db.Exec(`
+		INSERT INTO quest_revisions (quest_id, created, author, source, node_positions)
+		VALUES ('Other:Quest', ?, '', '', '{}')
+	`, time.Now().Add(-2*time.Hour).UnixNano()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.SaveRevision(&domain.QuestRevision{QuestID: "Revised:Quest"}); err != nil {
+		t.Fatal(err)
+	}
+	if revisions, err := repo.ListRevisions("Other:Quest"); err != nil || len(revisions) != 0 {
+		t.Errorf("expected the expired revision to be removed, got %v, %v", revisions, err)
+	}
+}
@@ -10,13 +10,16 @@ import (
 	"github.com/tinx/pat-quest-editor/backend/internal/domain"
 )
 
-// SQLiteMetadataRepository implements MetadataRepository using SQLite.
+// SQLiteMetadataRepository implements MetadataRepository and
+// RevisionRepository using SQLite.
 type SQLiteMetadataRepository struct {
-	db *sql.DB
+	db        *sql.DB
+	retention RevisionRetention
 }
 
-// NewSQLiteMetadataRepository creates a new SQLite-based metadata repository.
-func NewSQLiteMetadataRepository(dbPath string) (*SQLiteMetadataRepository, error) {
+// NewSQLiteMetadataRepository creates a new SQLite-based metadata repository
+// that keeps quest revisions according to retention.
+func NewSQLiteMetadataRepository(dbPath string, retention RevisionRetention) (*SQLiteMetadataRepository, error) {
 	db, err := sql.Open("sqlite3", dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
@@ -32,7 +35,7 @@ func NewSQLiteMetadataRepository(dbPath string) (*SQLiteMetadataRepository, erro
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	repo := &SQLiteMetadataRepository{db: db}
+	repo := &SQLiteMetadataRepository{db: db, retention: retention}
 	if err := repo.initSchema(); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to initialize schema: %w", err)
@@ -52,6 +55,16 @@ func (r *SQLiteMetadataRepository) initSchema() error {
 			quest_id TEXT PRIMARY KEY,
 			node_positions TEXT NOT NULL
 		);
+
+		CREATE TABLE IF NOT EXISTS quest_revisions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			quest_id TEXT NOT NULL,
+			created INTEGER NOT NULL,
+			author TEXT NOT NULL,
+			source TEXT NOT NULL,
+			node_positions TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS quest_revisions_quest_id ON quest_revisions (quest_id, id);
 	`
 	_, err := r.db.Exec(schema)
 	return err
@@ -0,0 +1,118 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContextLines is the number of unchanged lines around each hunk.
+const diffContextLines = 3
+
+// unifiedDiff returns the changes from a to b, two versions of the file
+// name, as a unified diff of their lines, or "" if they are equal.
+func unifiedDiff(name string, a, b []byte) string {
+	if string(a) == string(b) {
+		return ""
+	}
+	linesA := diffSplit(string(a))
+	linesB := diffSplit(string(b))
+	edits := lineEdits(linesA, linesB)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", name, name)
+	i := 0
+	for i < len(edits) {
+		// Skip to the next change
+		for i < len(edits) && edits[i].op == ' ' {
+			i++
+		}
+		if i == len(edits) {
+			break
+		}
+		// Extend the hunk while changes are close enough to share context
+		end := i + 1
+		for j := end; j < len(edits); j++ {
+			if edits[j].op != ' ' {
+				end = j + 1
+			} else if j-end >= 2*diffContextLines {
+				break
+			}
+		}
+		start := max(i-diffContextLines, 0)
+		stop := min(end+diffContextLines, len(edits))
+
+		posA, posB := edits[start].posA, edits[start].posB
+		countA, countB := 0, 0
+		for _, e := range edits[start:stop] {
+			if e.op != '+' {
+				countA++
+			}
+			if e.op != '-' {
+				countB++
+			}
+		}
+		if countA > 0 {
+			posA++
+		}
+		if countB > 0 {
+			posB++
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", posA, countA, posB, countB)
+		for _, e := range edits[start:stop] {
+			fmt.Fprintf(&out, "%c%s\n", e.op, e.line)
+		}
+		i = stop
+	}
+	return out.String()
+}
+
+// lineEdit is a kept (' '), removed ('-') or added ('+') line, with the
+// number of lines of a and b that precede it.
+type lineEdit struct {
+	op         byte
+	line       string
+	posA, posB int
+}
+
+// lineEdits returns the edits from a to b along their longest common
+// subsequence, with removals before additions.
+func lineEdits(a, b []string) []lineEdit {
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var edits []lineEdit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, lineEdit{' ', a[i], i, j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || common[i+1][j] >= common[i][j+1]):
+			edits = append(edits, lineEdit{'-', a[i], i, j})
+			i++
+		default:
+			edits = append(edits, lineEdit{'+', b[j], i, j})
+			j++
+		}
+	}
+	return edits
+}
+
+func diffSplit(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,236 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+// QuestDifferService compares versions of a quest at the level of the quest
+// model: nodes, edges, conditions, actions and texts, not YAML lines.
+type QuestDifferService struct{}
+
+// NewQuestDifferService creates a new quest differ.
+func NewQuestDifferService() *QuestDifferService {
+	return &QuestDifferService{}
+}
+
+// Diff returns the changes from one version of a quest file to another.
+// An empty file is an empty quest, so that added and deleted quests can be
+// compared, too.
+func (d *QuestDifferService) Diff(from, to []byte) ([]domain.QuestChange, error) {
+	var a, b domain.Quest
+	if err := yaml.Unmarshal(from, &a); err != nil {
+		return nil, fmt.Errorf("failed to parse old quest: %w", err)
+	}
+	if err := yaml.Unmarshal(to, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse new quest: %w", err)
+	}
+	return d.DiffQuests(&a, &b), nil
+}
+
+// DiffQuests returns the changes from quest a to quest b: the changes of
+// the quest's own properties, then those of its nodes by NodeID. The edges
+// of added and removed nodes are included, so that the changes describe
+// the whole change of the quest flow.
+func (d *QuestDifferService) DiffQuests(a, b *domain.Quest) []domain.QuestChange {
+	c := &changeCollector{changes: []domain.QuestChange{}}
+	c.value(nil, domain.ChangeQuest, "QuestTypeVersion", a.QuestTypeVersion, b.QuestTypeVersion)
+	c.value(nil, domain.ChangeQuest, "QuestVersion", a.QuestVersion, b.QuestVersion)
+	c.value(nil, domain.ChangeQuest, "QuestID", a.QuestID, b.QuestID)
+	c.value(nil, domain.ChangeQuest, "QuestType", a.QuestType, b.QuestType)
+	c.text(nil, "DisplayName", a.DisplayName, b.DisplayName)
+	c.value(nil, domain.ChangeQuest, "Repeatable", a.Repeatable, b.Repeatable)
+	c.value(nil, domain.ChangeQuest, "Migrations", a.Migrations, b.Migrations)
+	c.value(nil, domain.ChangeQuest, "Suppressions", a.Suppressions, b.Suppressions)
+
+	oldNodes, newNodes := questNodesByID(a), questNodesByID(b)
+	nodeIDs := make([]int, 0, len(newNodes))
+	for id := range oldNodes {
+		nodeIDs = append(nodeIDs, id)
+	}
+	for id := range newNodes {
+		if _, ok := oldNodes[id]; !ok {
+			nodeIDs = append(nodeIDs, id)
+		}
+	}
+	sort.Ints(nodeIDs)
+
+	for _, id := range nodeIDs {
+		oldNode, newNode := oldNodes[id], newNodes[id]
+		switch {
+		case oldNode == nil:
+			c.add(domain.QuestChange{Kind: domain.ChangeNodeAdded, NodeID: intRef(id), New: newNode.NodeType})
+			c.edges(id, &domain.QuestNode{}, newNode)
+		case newNode == nil:
+			c.add(domain.QuestChange{Kind: domain.ChangeNodeRemoved, NodeID: intRef(id), Old: oldNode.NodeType})
+			c.edges(id, oldNode, &domain.QuestNode{})
+		default:
+			c.node(id, oldNode, newNode)
+		}
+	}
+	return c.changes
+}
+
+func questNodesByID(quest *domain.Quest) map[int]*domain.QuestNode {
+	nodes := make(map[int]*domain.QuestNode, len(quest.QuestNodes))
+	for i := range quest.QuestNodes {
+		nodes[quest.QuestNodes[i].NodeID] = &quest.QuestNodes[i]
+	}
+	return nodes
+}
+
+func intRef(i int) *int {
+	return &i
+}
+
+// changeCollector collects the changes between two versions of a quest.
+type changeCollector struct {
+	changes []domain.QuestChange
+}
+
+func (c *changeCollector) add(change domain.QuestChange) {
+	c.changes = append(c.changes, change)
+}
+
+// node adds the changes between two versions of a node.
+func (c *changeCollector) node(id int, a, b *domain.QuestNode) {
+	nodeID := intRef(id)
+	c.value(nodeID, domain.ChangeNode, "NodeType", a.NodeType, b.NodeType)
+	c.edges(id, a, b)
+	c.value(nodeID, domain.ChangeConditions, "Conditions", a.Conditions, b.Conditions)
+	c.value(nodeID, domain.ChangeNode, "ConditionsRequired", a.ConditionsRequired, b.ConditionsRequired)
+	c.value(nodeID, domain.ChangeNode, "ConversationPartner", a.ConversationPartner, b.ConversationPartner)
+	c.value(nodeID, domain.ChangeNode, "Speaker", a.Speaker, b.Speaker)
+
+	var oldText, newText domain.I18nString
+	if a.Text != nil {
+		oldText = *a.Text
+	}
+	if b.Text != nil {
+		newText = *b.Text
+	}
+	c.text(nodeID, "Text", oldText, newText)
+
+	for i := 0; i < max(len(a.Options), len(b.Options)); i++ {
+		field := fmt.Sprintf("Options[%d]", i)
+		switch {
+		case i >= len(a.Options):
+			c.add(domain.QuestChange{Kind: domain.ChangeOptionAdded, NodeID: nodeID, Field: field, New: b.Options[i].Text.EnUS})
+		case i >= len(b.Options):
+			c.add(domain.QuestChange{Kind: domain.ChangeOptionRemoved, NodeID: nodeID, Field: field, Old: a.Options[i].Text.EnUS})
+		default:
+			c.text(nodeID, field+".Text", a.Options[i].Text, b.Options[i].Text)
+			c.value(nodeID, domain.ChangeConditions, field+".Conditions", a.Options[i].Conditions, b.Options[i].Conditions)
+		}
+	}
+
+	for i := 0; i < max(len(a.Messages), len(b.Messages)); i++ {
+		field := fmt.Sprintf("Messages[%d]", i)
+		switch {
+		case i >= len(a.Messages):
+			c.add(domain.QuestChange{Kind: domain.ChangeMessageAdded, NodeID: nodeID, Field: field, New: b.Messages[i].Text.EnUS})
+		case i >= len(b.Messages):
+			c.add(domain.QuestChange{Kind: domain.ChangeMessageRemoved, NodeID: nodeID, Field: field, Old: a.Messages[i].Text.EnUS})
+		default:
+			c.value(nodeID, domain.ChangeNode, field+".Speaker", a.Messages[i].Speaker, b.Messages[i].Speaker)
+			c.text(nodeID, field+".Text", a.Messages[i].Text, b.Messages[i].Text)
+		}
+	}
+
+	c.value(nodeID, domain.ChangeActions, "Actions", a.Actions, b.Actions)
+	c.value(nodeID, domain.ChangeNode, "Suppressions", a.Suppressions, b.Suppressions)
+}
+
+// edges adds the edges that were added to or removed from a node, by the
+// list they are in.
+func (c *changeCollector) edges(id int, a, b *domain.QuestNode) {
+	c.edgeList(id, "NextNodes", a.NextNodes, b.NextNodes)
+	c.edgeList(id, "NextNodesIfTrue", a.NextNodesIfTrue, b.NextNodesIfTrue)
+	c.edgeList(id, "NextNodesIfFalse", a.NextNodesIfFalse, b.NextNodesIfFalse)
+	for i := 0; i < max(len(a.Options), len(b.Options)); i++ {
+		var oldNext, newNext []int
+		if i < len(a.Options) {
+			oldNext = a.Options[i].NextNodes
+		}
+		if i < len(b.Options) {
+			newNext = b.Options[i].NextNodes
+		}
+		c.edgeList(id, fmt.Sprintf("Options[%d].NextNodes", i), oldNext, newNext)
+	}
+}
+
+func (c *changeCollector) edgeList(id int, field string, a, b []int) {
+	for _, target := range missingTargets(a, b) {
+		c.add(domain.QuestChange{Kind: domain.ChangeEdgeRemoved, NodeID: intRef(id), Field: field, Target: intRef(target)})
+	}
+	for _, target := range missingTargets(b, a) {
+		c.add(domain.QuestChange{Kind: domain.ChangeEdgeAdded, NodeID: intRef(id), Field: field, Target: intRef(target)})
+	}
+}
+
+// missingTargets returns the targets of a that are not in b, counting
+// duplicates.
+func missingTargets(a, b []int) []int {
+	count := make(map[int]int)
+	for _, target := range b {
+		count[target]++
+	}
+	var missing []int
+	for _, target := range a {
+		if count[target] > 0 {
+			count[target]--
+		} else {
+			missing = append(missing, target)
+		}
+	}
+	return missing
+}
+
+// text adds a change for each language in which a text differs.
+func (c *changeCollector) text(nodeID *int, field string, a, b domain.I18nString) {
+	if a.EnUS != b.EnUS {
+		c.add(domain.QuestChange{Kind: domain.ChangeText, NodeID: nodeID, Field: field, Language: "en-US", Old: a.EnUS, New: b.EnUS})
+	}
+	if a.DeDE != b.DeDE {
+		c.add(domain.QuestChange{Kind: domain.ChangeText, NodeID: nodeID, Field: field, Language: "de-DE", Old: a.DeDE, New: b.DeDE})
+	}
+}
+
+// value adds a change if a value differs. Empty lists count as missing.
+func (c *changeCollector) value(nodeID *int, kind domain.QuestChangeKind, field string, a, b interface{}) {
+	oldValue, newValue := formatChangeValue(a), formatChangeValue(b)
+	if oldValue != newValue {
+		c.add(domain.QuestChange{Kind: kind, NodeID: nodeID, Field: field, Old: oldValue, New: newValue})
+	}
+}
+
+// formatChangeValue formats a value for a QuestChange: strings and numbers
+// as they are, lists and mappings as JSON, and zero values as "".
+func formatChangeValue(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String()
+	case reflect.Int:
+		if rv.Int() == 0 {
+			return ""
+		}
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Slice, reflect.Map:
+		if rv.Len() == 0 {
+			return ""
+		}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,102 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+func intRefs(ids ...int) []*int {
+	refs := make([]*int, len(ids))
+	for i, id := range ids {
+		refs[i] = intRef(id)
+	}
+	return refs
+}
+
+func TestDiff_ReportsQuestModelChanges(t *testing.T) {
+	differ := NewQuestDifferService()
+
+	old := `QuestVersion: 1
+QuestID: Diffed
+DisplayName: { en-US: Diffed, de-DE: Verglichen }
+QuestNodes:
+  - NodeID: 0
+    NodeType: EntryPoint
+    NextNodes: [1]
+  - NodeID: 1
+    NodeType: PlayerDecisionDialog
+    ConversationPartner: NPC:Smith
+    Text: { en-US: Hello, de-DE: Hallo }
+    Options:
+      - Text: { en-US: Bye, de-DE: Tschüss }
+        NextNodes: [2]
+  - NodeID: 2
+    NodeType: Actions
+    Actions: [FailQuest]
+`
+	updated := `QuestVersion: 2
+QuestID: Diffed
+DisplayName: { en-US: Diffed, de-DE: Verglichen }
+QuestNodes:
+  # Reordered and commented, which is no change
+  - NodeID: 1
+    NodeType: PlayerDecisionDialog
+    ConversationPartner: NPC:Smith
+    Text: { en-US: Hello, de-DE: Guten Tag }
+    Options:
+      - Text: { en-US: Bye, de-DE: Tschüss }
+        NextNodes: [3]
+  - NodeID: 0
+    NodeType: EntryPoint
+    NextNodes: [1]
+  - NodeID: 3
+    NodeType: Actions
+    Actions: [CompleteQuest]
+`
+
+	changes, err := differ.Diff([]byte(old), []byte(updated))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := intRefs(1, 2, 3)
+	want := []domain.QuestChange{
+		{Kind: domain.ChangeQuest, Field: "QuestVersion", Old: "1", New: "2"},
+		{Kind: domain.ChangeEdgeRemoved, NodeID: ids[0], Field: "Options[0].NextNodes", Target: ids[1]},
+		{Kind: domain.ChangeEdgeAdded, NodeID: ids[0], Field: "Options[0].NextNodes", Target: ids[2]},
+		{Kind: domain.ChangeText, NodeID: ids[0], Field: "Text", Language: "de-DE", Old: "Hallo", New: "Guten Tag"},
+		{Kind: domain.ChangeNodeRemoved, NodeID: ids[1], Old: "Actions"},
+		{Kind: domain.ChangeNodeAdded, NodeID: ids[2], New: "Actions"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("unexpected changes:\n got: %+v\nwant: %+v", changes, want)
+	}
+
+	if changes, err := differ.Diff([]byte(old), []byte(old)); err != nil || len(changes) != 0 {
+		t.Errorf("expected no changes between equal quests, got %+v, %v", changes, err)
+	}
+}
+
+func TestDiff_EmptySourceIsEmptyQuest(t *testing.T) {
+	differ := NewQuestDifferService()
+	source := []byte("QuestID: Deleted\nQuestNodes:\n  - { NodeID: 0, NodeType: EntryPoint, NextNodes: [1] }\n")
+
+	changes, err := differ.Diff(source, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := intRefs(0, 1)
+	want := []domain.QuestChange{
+		{Kind: domain.ChangeQuest, Field: "QuestID", Old: "Deleted"},
+		{Kind: domain.ChangeNodeRemoved, NodeID: ids[0], Old: "EntryPoint"},
+		{Kind: domain.ChangeEdgeRemoved, NodeID: ids[0], Field: "NextNodes", Target: ids[1]},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("unexpected changes:\n got: %+v\nwant: %+v", changes, want)
+	}
+
+	if _, err := differ.Diff([]byte("QuestNodes: {"), source); err == nil {
+		t.Error("expected an error for an unparsable quest")
+	}
+}
@@ -0,0 +1,270 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+// defaultIndent is the indentation of quest files written by the editor.
+const defaultIndent = 4
+
+// QuestFixerService repairs quest issues that have an unambiguous fix.
+type QuestFixerService struct{}
+
+// NewQuestFixerService creates a new quest fixer.
+func NewQuestFixerService() *QuestFixerService {
+	return &QuestFixerService{}
+}
+
+// Fix repairs a quest file: it moves top-level NextNodes of Decision and
+// ConditionBranch nodes to the only place they can belong, drops NextNodes
+// from terminal nodes, and removes self-references, duplicate edges and
+// edges to non-existent nodes. The file is edited as a YAML tree, so
+// comments and key order are kept.
+func (f *QuestFixerService) Fix(source []byte) (*domain.FixResult, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(source, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse quest: %w", err)
+	}
+	result := &domain.FixResult{Fixes: []domain.Fix{}, Source: source}
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 {
+		return result, nil
+	}
+	quest := root.Content[0]
+	if id := yamlValue(quest, "QuestID"); id != nil {
+		result.QuestID = id.Value
+	}
+
+	nodes := yamlValue(quest, "QuestNodes")
+	if nodes == nil || nodes.Kind != yaml.SequenceNode {
+		return result, nil
+	}
+	fixer := &nodeFixer{nodeIDs: make(map[int]bool)}
+	for _, node := range nodes.Content {
+		if id, ok := yamlInt(yamlValue(node, "NodeID")); ok {
+			fixer.nodeIDs[id] = true
+		}
+	}
+	for _, node := range nodes.Content {
+		fixer.fix(node)
+	}
+	if len(fixer.fixes) == 0 {
+		return result, nil
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(indentOf(source))
+	if err := enc.Encode(&root); err != nil {
+		return nil, fmt.Errorf("failed to encode quest: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode quest: %w", err)
+	}
+	result.Fixes = fixer.fixes
+	result.Source = buf.Bytes()
+	result.Diff = unifiedDiff(result.QuestID, source, result.Source)
+	return result, nil
+}
+
+// nodeFixer applies the fixes to the nodes of one quest.
+type nodeFixer struct {
+	nodeIDs map[int]bool
+	fixes   []domain.Fix
+}
+
+func (f *nodeFixer) add(code string, nodeID int, format string, args ...interface{}) {
+	f.fixes = append(f.fixes, domain.Fix{Code: code, NodeID: nodeID, Message: fmt.Sprintf(format, args...)})
+}
+
+func (f *nodeFixer) fix(node *yaml.Node) {
+	nodeID, ok := yamlInt(yamlValue(node, "NodeID"))
+	if !ok || node.Kind != yaml.MappingNode {
+		return
+	}
+	nodeType := ""
+	if t := yamlValue(node, "NodeType"); t != nil {
+		nodeType = t.Value
+	}
+
+	if !isEmptyList(yamlValue(node, "NextNodes")) {
+		switch nodeType {
+		case "Decision":
+			f.fixDecision(node, nodeID)
+		case "ConditionBranch":
+			f.fixConditionBranch(node, nodeID)
+		case "Actions":
+			if hasTerminalAction(yamlValue(node, "Actions")) {
+				removeYAMLKey(node, "NextNodes")
+				f.add(domain.RuleTerminalWithNextNodes, nodeID, "removed NextNodes from terminal node")
+			}
+		}
+	}
+
+	for _, field := range []string{"NextNodes", "NextNodesIfTrue", "NextNodesIfFalse"} {
+		f.cleanEdges(node, field, field, nodeID)
+	}
+	if options := yamlValue(node, "Options"); options != nil && options.Kind == yaml.SequenceNode {
+		for i, option := range options.Content {
+			f.cleanEdges(option, "NextNodes", fmt.Sprintf("the NextNodes of option %d", i+1), nodeID)
+		}
+	}
+}
+
+// fixDecision moves the NextNodes of a Decision node into its option if it
+// has only one, and that option has no NextNodes of its own.
+func (f *nodeFixer) fixDecision(node *yaml.Node, nodeID int) {
+	options := yamlValue(node, "Options")
+	if options == nil || options.Kind != yaml.SequenceNode || len(options.Content) != 1 {
+		return
+	}
+	option := options.Content[0]
+	if option.Kind != yaml.MappingNode || !isEmptyList(yamlValue(option, "NextNodes")) {
+		return
+	}
+	removeYAMLKey(option, "NextNodes")
+	next := yamlValue(node, "NextNodes")
+	key := removeYAMLKey(node, "NextNodes")
+	option.Content = append(option.Content, key, next)
+	f.add(domain.RuleDecisionStructure, nodeID, "moved NextNodes into the only option")
+}
+
+// fixConditionBranch turns the NextNodes of a ConditionBranch node into the
+// branch that is missing, if exactly one is.
+func (f *nodeFixer) fixConditionBranch(node *yaml.Node, nodeID int) {
+	missingTrue := isEmptyList(yamlValue(node, "NextNodesIfTrue"))
+	missingFalse := isEmptyList(yamlValue(node, "NextNodesIfFalse"))
+	if missingTrue == missingFalse {
+		return
+	}
+	field := "NextNodesIfTrue"
+	if missingFalse {
+		field = "NextNodesIfFalse"
+	}
+	removeYAMLKey(node, field)
+	yamlKey(node, "NextNodes").Value = field
+	f.add(domain.RuleBranchStructure, nodeID, "moved top-level NextNodes to %s", field)
+}
+
+// cleanEdges removes self-references, duplicates and edges to non-existent
+// nodes from the edge list in field of owner, and the field itself if no
+// edges remain.
+func (f *nodeFixer) cleanEdges(owner *yaml.Node, field, name string, nodeID int) {
+	edges := yamlValue(owner, field)
+	if edges == nil || edges.Kind != yaml.SequenceNode || len(edges.Content) == 0 {
+		return
+	}
+	seen := make(map[int]bool)
+	var kept []*yaml.Node
+	for _, edge := range edges.Content {
+		target, ok := yamlInt(edge)
+		switch {
+		case !ok:
+			kept = append(kept, edge)
+		case target == nodeID:
+			f.add(domain.RuleSelfReference, nodeID, "removed self-reference from %s", name)
+		case seen[target]:
+			f.add(domain.RuleDuplicateEdge, nodeID, "removed duplicate edge to node %d from %s", target, name)
+		case !f.nodeIDs[target]:
+			f.add(domain.RuleUnknownNode, nodeID, "removed edge to non-existent node %d from %s", target, name)
+		default:
+			seen[target] = true
+			kept = append(kept, edge)
+		}
+	}
+	edges.Content = kept
+	if len(kept) == 0 {
+		removeYAMLKey(owner, field)
+	}
+}
+
+// hasTerminalAction reports whether an Actions list ends the quest.
+func hasTerminalAction(actions *yaml.Node) bool {
+	if actions == nil {
+		return false
+	}
+	for _, action := range actions.Content {
+		if action.Kind != yaml.ScalarNode {
+			continue
+		}
+		switch action.Value {
+		case "CompleteQuest", "FailQuest", "DeclineQuest":
+			return true
+		}
+	}
+	return false
+}
+
+// indentOf returns the indentation of the first indented line of a YAML
+// file, or defaultIndent.
+func indentOf(source []byte) int {
+	for _, line := range strings.Split(string(source), "\n") {
+		content := strings.TrimLeft(line, " ")
+		if content == "" || strings.HasPrefix(content, "#") {
+			continue
+		}
+		if n := len(line) - len(content); n > 0 {
+			return n
+		}
+	}
+	return defaultIndent
+}
+
+func isEmptyList(n *yaml.Node) bool {
+	return n == nil || len(n.Content) == 0
+}
+
+// yamlKey returns the key node of key in a mapping node, or nil.
+func yamlKey(m *yaml.Node, key string) *yaml.Node {
+	if i := yamlIndex(m, key); i >= 0 {
+		return m.Content[i]
+	}
+	return nil
+}
+
+// yamlValue returns the value node of key in a mapping node, or nil.
+func yamlValue(m *yaml.Node, key string) *yaml.Node {
+	if i := yamlIndex(m, key); i >= 0 {
+		return m.Content[i+1]
+	}
+	return nil
+}
+
+// removeYAMLKey removes key from a mapping node and returns its key node.
+func removeYAMLKey(m *yaml.Node, key string) *yaml.Node {
+	i := yamlIndex(m, key)
+	if i < 0 {
+		return nil
+	}
+	k := m.Content[i]
+	m.Content = append(m.Content[:i], m.Content[i+2:]...)
+	return k
+}
+
+// yamlIndex returns the index of the key node of key in a mapping node, or
+// -1.
+func yamlIndex(m *yaml.Node, key string) int {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return -1
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func yamlInt(n *yaml.Node) (int, bool) {
+	if n == nil || n.Kind != yaml.ScalarNode {
+		return 0, false
+	}
+	i, err := strconv.Atoi(n.Value)
+	return i, err == nil
+}
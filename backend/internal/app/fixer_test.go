@@ -0,0 +1,118 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+func TestFix_RepairsAndKeepsComments(t *testing.T) {
+	fixer := NewQuestFixerService()
+
+	source := `QuestID: Broken
+QuestNodes:
+    # The entry point
+    - NodeID: 0
+      NodeType: EntryPoint
+      NextNodes: [0, 1, 1, 42]
+    - NodeID: 1
+      NodeType: Decision
+      NextNodes: [2]
+      Options:
+        - Text: { en-US: Go, de-DE: Los }
+    - NodeID: 2
+      NodeType: ConditionBranch
+      NextNodesIfTrue: [3]
+      NextNodes: [3] # otherwise
+    - NodeID: 3
+      NodeType: Actions
+      Actions:
+        - FailQuest
+      NextNodes: [0]
+`
+	want := `QuestID: Broken
+QuestNodes:
+    # The entry point
+    - NodeID: 0
+      NodeType: EntryPoint
+      NextNodes: [1]
+    - NodeID: 1
+      NodeType: Decision
+      Options:
+        - Text: {en-US: Go, de-DE: Los}
+          NextNodes: [2]
+    - NodeID: 2
+      NodeType: ConditionBranch
+      NextNodesIfTrue: [3]
+      NextNodesIfFalse: [3] # otherwise
+    - NodeID: 3
+      NodeType: Actions
+      Actions:
+        - FailQuest
+`
+
+	result, err := fixer.Fix([]byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(result.Source) != want {
+		t.Errorf("unexpected result:\n%s", unifiedDiff("Broken", []byte(want), result.Source))
+	}
+	if result.QuestID != "Broken" {
+		t.Errorf("expected QuestID Broken, got %q", result.QuestID)
+	}
+
+	var codes []string
+	for _, fix := range result.Fixes {
+		codes = append(codes, fix.Code)
+	}
+	wantCodes := []string{
+		domain.RuleSelfReference,
+		domain.RuleDuplicateEdge,
+		domain.RuleUnknownNode,
+		domain.RuleDecisionStructure,
+		domain.RuleBranchStructure,
+		domain.RuleTerminalWithNextNodes,
+	}
+	if !reflect.DeepEqual(codes, wantCodes) {
+		t.Errorf("expected fixes %v, got %v", wantCodes, result.Fixes)
+	}
+	if !strings.HasPrefix(result.Diff, "--- Broken\n+++ Broken\n@@ -3,18 +3,17 @@\n") {
+		t.Errorf("unexpected diff:\n%s", result.Diff)
+	}
+
+	// A fixed quest needs no more fixes
+	again, err := fixer.Fix(result.Source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Fixes) != 0 || again.Diff != "" {
+		t.Errorf("expected no further fixes, got %v", again.Fixes)
+	}
+}
+
+func TestFix_LeavesAmbiguousIssues(t *testing.T) {
+	fixer := NewQuestFixerService()
+
+	source := `QuestID: Ambiguous
+QuestNodes:
+    - NodeID: 1
+      NodeType: Decision
+      NextNodes: [2]
+      Options:
+        - Text: { en-US: A, de-DE: A }
+        - Text: { en-US: B, de-DE: B }
+    - NodeID: 2
+      NodeType: ConditionBranch
+      NextNodes: [1]
+`
+	result, err := fixer.Fix([]byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Fixes) != 0 || string(result.Source) != source || result.Diff != "" {
+		t.Errorf("expected no fixes, got %v", result.Fixes)
+	}
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+	"github.com/tinx/pat-quest-editor/backend/internal/runtime"
+)
+
+// maxPaths limits path enumeration for quests with very many combinations.
+const maxPaths = 10000
+
+// QuestAnalyzerService implements static quest analysis.
+type QuestAnalyzerService struct{}
+
+// NewQuestAnalyzerService creates a new quest analyzer.
+func NewQuestAnalyzerService() *QuestAnalyzerService {
+	return &QuestAnalyzerService{}
+}
+
+// Paths enumerates every path from an EntryPoint to a terminal Actions
+// node. Every Decision option and both ConditionBranch arms are followed,
+// and parallel NextNodes are followed one at a time.
+func (a *QuestAnalyzerService) Paths(quest *domain.Quest) *domain.PathReport {
+	w := &pathWalker{
+		nodes:  make(map[int]*domain.QuestNode),
+		onPath: make(map[int]bool),
+		report: &domain.PathReport{QuestID: quest.QuestID, Paths: []domain.QuestPath{}},
+	}
+	for i := range quest.QuestNodes {
+		w.nodes[quest.QuestNodes[i].NodeID] = &quest.QuestNodes[i]
+	}
+	for _, node := range quest.QuestNodes {
+		if node.NodeType == "EntryPoint" {
+			w.walk(node.NodeID, nil)
+		}
+	}
+	return w.report
+}
+
+type pathWalker struct {
+	nodes  map[int]*domain.QuestNode
+	onPath map[int]bool
+	report *domain.PathReport
+}
+
+func (w *pathWalker) walk(nodeID int, steps []domain.PathStep) {
+	node, exists := w.nodes[nodeID]
+	// Dangling references and cycles are reported by the validator.
+	if !exists || w.onPath[nodeID] || w.report.Truncated {
+		return
+	}
+	w.onPath[nodeID] = true
+	defer delete(w.onPath, nodeID)
+
+	switch node.NodeType {
+	case "Decision":
+		for i, opt := range node.Options {
+			w.follow(opt.NextNodes, steps, domain.PathStep{NodeID: nodeID, Option: i + 1})
+		}
+	case "ConditionBranch":
+		w.follow(node.NextNodesIfTrue, steps, domain.PathStep{NodeID: nodeID, Branch: "true"})
+		w.follow(node.NextNodesIfFalse, steps, domain.PathStep{NodeID: nodeID, Branch: "false"})
+	default:
+		step := domain.PathStep{NodeID: nodeID}
+		if isTerminalNode(node) {
+			w.record(append(append([]domain.PathStep(nil), steps...), step))
+			return
+		}
+		w.follow(node.NextNodes, steps, step)
+	}
+}
+
+func (w *pathWalker) follow(next []int, steps []domain.PathStep, step domain.PathStep) {
+	extended := append(append([]domain.PathStep(nil), steps...), step)
+	for _, id := range next {
+		w.walk(id, extended)
+	}
+}
+
+// record adds a finished path with the outcome, rewards and journal
+// entries of the Actions nodes along it.
+func (w *pathWalker) record(steps []domain.PathStep) {
+	if len(w.report.Paths) >= maxPaths {
+		w.report.Truncated = true
+		return
+	}
+
+	path := domain.QuestPath{Steps: steps, Journal: []domain.I18nString{}}
+	for _, step := range steps {
+		for _, raw := range w.nodes[step.NodeID].Actions {
+			action, err := runtime.ParseAction(raw)
+			if err != nil {
+				continue
+			}
+			addToPath(&path, action)
+		}
+	}
+	w.report.Paths = append(w.report.Paths, path)
+}
+
+func addToPath(path *domain.QuestPath, action runtime.Action) {
+	rewards := &path.Rewards
+	switch action.Kind {
+	case runtime.ActionCompleteQuest:
+		path.Outcome = domain.OutcomeComplete
+	case runtime.ActionFailQuest:
+		path.Outcome = domain.OutcomeFail
+	case runtime.ActionDeclineQuest:
+		path.Outcome = domain.OutcomeDecline
+	case runtime.ActionJournalEntry:
+		path.Journal = append(path.Journal, action.Text)
+	case runtime.ActionItemsGained, runtime.ActionItemsLost:
+		if rewards.Items == nil {
+			rewards.Items = make(map[string]int)
+		}
+		sign := 1
+		if action.Kind == runtime.ActionItemsLost {
+			sign = -1
+		}
+		for _, stack := range action.Items {
+			rewards.Items[stack.Type] += sign * stack.Count
+			if rewards.Items[stack.Type] == 0 {
+				delete(rewards.Items, stack.Type)
+			}
+		}
+		if len(rewards.Items) == 0 {
+			rewards.Items = nil
+		}
+	case runtime.ActionCurrency:
+		rewards.Currency += action.Amount
+	case runtime.ActionExperience:
+		rewards.Experience += action.Amount
+	case runtime.ActionFactionStanding:
+		if rewards.Factions == nil {
+			rewards.Factions = make(map[string]int)
+		}
+		rewards.Factions[action.Faction] += action.Points
+	}
+}
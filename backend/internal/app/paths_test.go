@@ -0,0 +1,136 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+func TestPaths_DecisionsAndBranches(t *testing.T) {
+	analyzer := NewQuestAnalyzerService()
+
+	quest := &domain.Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Decision", Options: []domain.DialogOption{
+				{NextNodes: []int{2}},
+				{NextNodes: []int{5}},
+			}},
+			{NodeID: 2, NodeType: "ConditionBranch", NextNodesIfTrue: []int{3}, NextNodesIfFalse: []int{4}},
+			{NodeID: 3, NodeType: "Actions", Actions: []domain.Action{
+				map[string]interface{}{"JournalEntry": map[string]interface{}{"en-US": "Done", "de-DE": "Fertig"}},
+				map[string]interface{}{"ItemsGained": []interface{}{map[string]interface{}{"Type": "PackOfNails", "Count": 2}}},
+				map[string]interface{}{"Currency": 50},
+				map[string]interface{}{"Experience": 100},
+				map[string]interface{}{"FactionStanding": map[string]interface{}{"Faction": "Town", "Points": 3}},
+				"CompleteQuest",
+			}},
+			{NodeID: 4, NodeType: "Actions", Actions: []domain.Action{
+				map[string]interface{}{"FactionStanding": map[string]interface{}{"Faction": "Town", "Points": -1}},
+				"FailQuest",
+			}},
+			{NodeID: 5, NodeType: "Actions", Actions: []domain.Action{"DeclineQuest"}},
+		},
+	}
+
+	report := analyzer.Paths(quest)
+
+	want := []domain.QuestPath{
+		{
+			Steps:   []domain.PathStep{{NodeID: 0}, {NodeID: 1, Option: 1}, {NodeID: 2, Branch: "true"}, {NodeID: 3}},
+			Outcome: domain.OutcomeComplete,
+			Rewards: domain.PathRewards{
+				Items:      map[string]int{"PackOfNails": 2},
+				Currency:   50,
+				Experience: 100,
+				Factions:   map[string]int{"Town": 3},
+			},
+			Journal: []domain.I18nString{{EnUS: "Done", DeDE: "Fertig"}},
+		},
+		{
+			Steps:   []domain.PathStep{{NodeID: 0}, {NodeID: 1, Option: 1}, {NodeID: 2, Branch: "false"}, {NodeID: 4}},
+			Outcome: domain.OutcomeFail,
+			Rewards: domain.PathRewards{Factions: map[string]int{"Town": -1}},
+			Journal: []domain.I18nString{},
+		},
+		{
+			Steps:   []domain.PathStep{{NodeID: 0}, {NodeID: 1, Option: 2}, {NodeID: 5}},
+			Outcome: domain.OutcomeDecline,
+			Journal: []domain.I18nString{},
+		},
+	}
+	if !reflect.DeepEqual(report.Paths, want) {
+		t.Errorf("unexpected paths:\n got %+v\nwant %+v", report.Paths, want)
+	}
+	if report.Truncated {
+		t.Error("expected report not to be truncated")
+	}
+}
+
+func TestPaths_AccumulatesAlongPath(t *testing.T) {
+	analyzer := NewQuestAnalyzerService()
+
+	quest := &domain.Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Actions", NextNodes: []int{2}, Actions: []domain.Action{
+				map[string]interface{}{"JournalEntry": map[string]interface{}{"en-US": "Start", "de-DE": "Start"}},
+				map[string]interface{}{"Currency": 10},
+				map[string]interface{}{"ItemsGained": []interface{}{
+					map[string]interface{}{"Type": "PackOfNails", "Count": 2},
+					map[string]interface{}{"Type": "MineKey", "Count": 1},
+				}},
+			}},
+			{NodeID: 2, NodeType: "ConditionWatcher", NextNodes: []int{3}},
+			{NodeID: 3, NodeType: "Actions", Actions: []domain.Action{
+				map[string]interface{}{"JournalEntry": map[string]interface{}{"en-US": "End", "de-DE": "Ende"}},
+				map[string]interface{}{"Currency": -4},
+				map[string]interface{}{"ItemsLost": []interface{}{
+					map[string]interface{}{"Type": "MineKey", "Count": 1},
+					map[string]interface{}{"Type": "Rope", "Count": 1},
+				}},
+				"CompleteQuest",
+			}},
+		},
+	}
+
+	report := analyzer.Paths(quest)
+
+	if len(report.Paths) != 1 {
+		t.Fatalf("expected 1 path, got %d", len(report.Paths))
+	}
+	path := report.Paths[0]
+	if path.Rewards.Currency != 6 {
+		t.Errorf("expected currency 6, got %d", path.Rewards.Currency)
+	}
+	// The key is given back, the rope is lost
+	if want := map[string]int{"PackOfNails": 2, "Rope": -1}; !reflect.DeepEqual(path.Rewards.Items, want) {
+		t.Errorf("expected items %v, got %v", want, path.Rewards.Items)
+	}
+	if len(path.Journal) != 2 || path.Journal[1].EnUS != "End" {
+		t.Errorf("unexpected journal: %v", path.Journal)
+	}
+}
+
+func TestPaths_IgnoresCyclesAndDanglingEdges(t *testing.T) {
+	analyzer := NewQuestAnalyzerService()
+
+	quest := &domain.Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1, 9}},
+			{NodeID: 1, NodeType: "Dialog", NextNodes: []int{2}},
+			{NodeID: 2, NodeType: "ConditionWatcher", NextNodes: []int{1, 3}},
+			{NodeID: 3, NodeType: "Actions", Actions: []domain.Action{"CompleteQuest"}},
+		},
+	}
+
+	report := analyzer.Paths(quest)
+
+	if len(report.Paths) != 1 {
+		t.Errorf("expected 1 path, got %+v", report.Paths)
+	}
+}
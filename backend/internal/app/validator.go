@@ -3,6 +3,9 @@ package app
 import (
 	"fmt"
 	"log"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/tinx/pat-quest-editor/backend/internal/domain"
 	"github.com/tinx/pat-quest-editor/backend/internal/ports"
@@ -11,17 +14,20 @@ import (
 // QuestValidatorService implements quest validation logic.
 type QuestValidatorService struct {
 	refData ports.ReferenceDataRepository
+	schemas ports.SchemaValidator
 }
 
-// NewQuestValidatorService creates a new quest validator.
-func NewQuestValidatorService(refData ports.ReferenceDataRepository) *QuestValidatorService {
-	return &QuestValidatorService{refData: refData}
+// NewQuestValidatorService creates a new quest validator. If schemas is nil,
+// quests are not checked against the JSON schema.
+func NewQuestValidatorService(refData ports.ReferenceDataRepository, schemas ports.SchemaValidator) *QuestValidatorService {
+	return &QuestValidatorService{refData: refData, schemas: schemas}
 }
 
 // Validate checks a quest against all rules.
 func (v *QuestValidatorService) Validate(quest *domain.Quest) *domain.ValidationResult {
 	result := domain.NewValidationResult()
 
+	v.validateSchema(quest, result)
 	v.validateUniqueNodeIDs(quest, result)
 	v.validateNodeConnections(quest, result)
 	v.validateEntryPoints(quest, result)
@@ -33,15 +39,157 @@ func (v *QuestValidatorService) Validate(quest *domain.Quest) *domain.Validation
 	v.validateNoUnreferencedNodes(quest, result)
 	v.validateJournalAtFlowStart(quest, result)
 	v.validateJournalAtFlowEnd(quest, result)
+	v.validateMigrations(quest, result)
+	v.applySuppressions(quest, result)
+	locate(quest, result)
 
 	return result
 }
 
+// locate sets the file, line and column of each issue from the quest's
+// source map: those of the field the issue is about if known, otherwise
+// those of its node, and otherwise those of the quest itself.
+func locate(quest *domain.Quest, result *domain.ValidationResult) {
+	if quest.Source == nil {
+		return
+	}
+	nodeIndex := make(map[int]int)
+	for i, node := range quest.QuestNodes {
+		if _, ok := nodeIndex[node.NodeID]; !ok {
+			nodeIndex[node.NodeID] = i
+		}
+	}
+	for _, issues := range [][]domain.ValidationError{result.Errors, result.Suppressed} {
+		for i := range issues {
+			issue := &issues[i]
+			path := issue.Field
+			if path == "" && issue.NodeID != nil {
+				if n, ok := nodeIndex[*issue.NodeID]; ok {
+					path = fmt.Sprintf("$.QuestNodes[%d]", n)
+				}
+			}
+			if path == "" {
+				path = "$"
+			}
+			issue.File = quest.Source.File
+			if pos, ok := quest.Source.Lookup(path); ok {
+				issue.Line, issue.Column = pos.Line, pos.Column
+			}
+		}
+	}
+}
+
+// nodePath matches the JSON path of a quest node, e.g. "$.QuestNodes[3]".
+var nodePath = regexp.MustCompile(`^\$\.QuestNodes\[(\d+)\]`)
+
+func (v *QuestValidatorService) validateSchema(quest *domain.Quest, result *domain.ValidationResult) {
+	if v.schemas == nil {
+		return
+	}
+	var document interface{} = quest
+	if quest.Document != nil {
+		document = quest.Document
+	}
+	violations, err := v.schemas.Validate("quest", document)
+	if err != nil {
+		log.Printf("Warning: failed to load quest schema for validation: %v", err)
+		return
+	}
+	for _, violation := range violations {
+		if m := nodePath.FindStringSubmatch(violation.Path); m != nil {
+			if i, err := strconv.Atoi(m[1]); err == nil {
+				if n, ok := documentNodeIndex(quest, i); ok {
+					// The source map follows the order of quest.QuestNodes
+					violation.Path = fmt.Sprintf("$.QuestNodes[%d]", n) + violation.Path[len(m[0]):]
+				}
+			}
+		}
+		verr := domain.ValidationError{Code: domain.RuleSchema, Field: violation.Path, Message: violation.Error()}
+		if m := nodePath.FindStringSubmatch(violation.Path); m != nil {
+			if i, err := strconv.Atoi(m[1]); err == nil && i < len(quest.QuestNodes) {
+				verr.NodeID = &quest.QuestNodes[i].NodeID
+			}
+		}
+		result.AddError(verr)
+	}
+}
+
+// documentNodeIndex returns the index in quest.QuestNodes of the node at
+// index i of the quest's document, matched by NodeID. The two differ when
+// the nodes were reordered after parsing, e.g. by saving.
+func documentNodeIndex(quest *domain.Quest, i int) (int, bool) {
+	if quest.Document == nil {
+		return i, i < len(quest.QuestNodes)
+	}
+	document, _ := quest.Document.(map[string]interface{})
+	nodes, _ := document["QuestNodes"].([]interface{})
+	if i >= len(nodes) {
+		return 0, false
+	}
+	node, _ := nodes[i].(map[string]interface{})
+	var nodeID int
+	switch id := node["NodeID"].(type) {
+	case int:
+		nodeID = id
+	case float64:
+		nodeID = int(id)
+	default:
+		return 0, false
+	}
+	for n := range quest.QuestNodes {
+		if quest.QuestNodes[n].NodeID == nodeID {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// ValidateReferenceData checks the reference data against the item, faction,
+// resource, NPC and object schemas.
+func (v *QuestValidatorService) ValidateReferenceData() *domain.ValidationResult {
+	result := domain.NewValidationResult()
+	if v.schemas == nil {
+		return result
+	}
+
+	lists := []struct {
+		schema string
+		file   string
+		load   func() (interface{}, error)
+	}{
+		{"item", "items.yaml", func() (interface{}, error) { return v.refData.ListItems() }},
+		{"faction", "factions.yaml", func() (interface{}, error) { return v.refData.ListFactions() }},
+		{"resource", "resources.yaml", func() (interface{}, error) { return v.refData.ListResources() }},
+		{"npc", "npcs.yaml", func() (interface{}, error) { return v.refData.ListNPCs() }},
+		{"object", "objects.yaml", func() (interface{}, error) { return v.refData.ListObjects() }},
+	}
+	for _, list := range lists {
+		entries, err := list.load()
+		if err != nil {
+			result.AddGlobalError(domain.RuleSchema, fmt.Sprintf("%s: %v", list.file, err))
+			continue
+		}
+		violations, err := v.schemas.ValidateEach(list.schema, entries)
+		if err != nil {
+			result.AddGlobalError(domain.RuleSchema, fmt.Sprintf("%s: %v", list.file, err))
+			continue
+		}
+		for _, violation := range violations {
+			result.AddError(domain.ValidationError{
+				Code:    domain.RuleSchema,
+				Field:   violation.Path,
+				Message: fmt.Sprintf("%s: %s", list.file, violation.Error()),
+			})
+		}
+	}
+	return result
+}
+
 func (v *QuestValidatorService) validateUniqueNodeIDs(quest *domain.Quest, result *domain.ValidationResult) {
 	seen := make(map[int]bool)
 	for _, node := range quest.QuestNodes {
 		if seen[node.NodeID] {
-			result.AddNodeError(node.NodeID, "duplicate NodeID")
+			result.AddNodeError(domain.RuleDuplicateNodeID, node.NodeID, "duplicate NodeID")
 		}
 		seen[node.NodeID] = true
 	}
@@ -58,13 +206,13 @@ func (v *QuestValidatorService) validateNodeConnections(quest *domain.Quest, res
 		seen := make(map[int]bool)
 		for _, nextID := range list {
 			if !nodeIDs[nextID] {
-				result.AddNodeError(nodeID, fmt.Sprintf("%s references non-existent NodeID", listName))
+				result.AddNodeError(domain.RuleUnknownNode, nodeID, fmt.Sprintf("%s references non-existent NodeID", listName))
 			}
 			if nextID == nodeID {
-				result.AddNodeError(nodeID, fmt.Sprintf("node references itself in %s", listName))
+				result.AddNodeError(domain.RuleSelfReference, nodeID, fmt.Sprintf("node references itself in %s", listName))
 			}
 			if seen[nextID] {
-				result.AddNodeError(nodeID, fmt.Sprintf("duplicate edge to NodeID %d", nextID))
+				result.AddNodeError(domain.RuleDuplicateEdge, nodeID, fmt.Sprintf("duplicate edge to NodeID %d", nextID))
 			}
 			seen[nextID] = true
 		}
@@ -103,7 +251,7 @@ func (v *QuestValidatorService) validateNodeConnections(quest *domain.Quest, res
 
 	for _, node := range quest.QuestNodes {
 		if node.NodeType != "EntryPoint" && !hasIncoming[node.NodeID] {
-			result.AddNodeError(node.NodeID, "non-EntryPoint node has no incoming connections")
+			result.AddNodeError(domain.RuleUnreachableNode, node.NodeID, "non-EntryPoint node has no incoming connections")
 		}
 	}
 }
@@ -117,7 +265,7 @@ func (v *QuestValidatorService) validateEntryPoints(quest *domain.Quest, result
 		}
 	}
 	if !hasEntryPoint {
-		result.AddGlobalError("quest must have at least one EntryPoint node")
+		result.AddGlobalError(domain.RuleNoEntryPoint, "quest must have at least one EntryPoint node")
 	}
 }
 
@@ -147,17 +295,17 @@ func (v *QuestValidatorService) validateTerminalNodes(quest *domain.Quest, resul
 
 		// Check: terminal action nodes should not have NextNodes
 		if isTerminal && hasOutgoingEdges {
-			result.AddNodeError(node.NodeID, "terminal action node should not have NextNodes")
+			result.AddNodeError(domain.RuleTerminalWithNextNodes, node.NodeID, "terminal action node should not have NextNodes")
 		}
 
 		// Check: non-terminal action nodes must have NextNodes
 		if !isTerminal && !hasOutgoingEdges {
-			result.AddNodeError(node.NodeID, "non-terminal Actions node must have NextNodes (quest flow ends with unspecified behaviour)")
+			result.AddNodeError(domain.RuleNoOutgoingEdges, node.NodeID, "non-terminal Actions node must have NextNodes (quest flow ends with unspecified behaviour)")
 		}
 
 		// Check: at most one terminal action per node
 		if terminalCount > 1 {
-			result.AddNodeError(node.NodeID, "Actions node has more than one terminal action")
+			result.AddNodeError(domain.RuleMultipleTerminals, node.NodeID, "Actions node has more than one terminal action")
 		}
 	}
 }
@@ -170,13 +318,13 @@ func (v *QuestValidatorService) validateDecisions(quest *domain.Quest, result *d
 
 		// Decision must not have top-level NextNodes
 		if len(node.NextNodes) > 0 {
-			result.AddNodeError(node.NodeID, "Decision must not have top-level NextNodes; use NextNodes in each option instead")
+			result.AddNodeError(domain.RuleDecisionStructure, node.NodeID, "Decision must not have top-level NextNodes; use NextNodes in each option instead")
 		}
 
 		// Every option must have NextNodes
 		for i, opt := range node.Options {
 			if len(opt.NextNodes) == 0 {
-				result.AddNodeError(node.NodeID, fmt.Sprintf("option %d must have NextNodes", i+1))
+				result.AddNodeError(domain.RuleDecisionStructure, node.NodeID, fmt.Sprintf("option %d must have NextNodes", i+1))
 			}
 		}
 	}
@@ -190,17 +338,17 @@ func (v *QuestValidatorService) validateConditionBranches(quest *domain.Quest, r
 
 		// ConditionBranch must not have top-level NextNodes
 		if len(node.NextNodes) > 0 {
-			result.AddNodeError(node.NodeID, "ConditionBranch must not have top-level NextNodes; use NextNodesIfTrue and NextNodesIfFalse instead")
+			result.AddNodeError(domain.RuleBranchStructure, node.NodeID, "ConditionBranch must not have top-level NextNodes; use NextNodesIfTrue and NextNodesIfFalse instead")
 		}
 
 		// ConditionBranch must have at least one condition
 		if len(node.Conditions) == 0 {
-			result.AddNodeError(node.NodeID, "ConditionBranch must have at least one condition")
+			result.AddNodeError(domain.RuleBranchStructure, node.NodeID, "ConditionBranch must have at least one condition")
 		}
 
 		// At least one of NextNodesIfTrue or NextNodesIfFalse must be non-empty
 		if len(node.NextNodesIfTrue) == 0 && len(node.NextNodesIfFalse) == 0 {
-			result.AddNodeError(node.NodeID, "ConditionBranch must have at least one of NextNodesIfTrue or NextNodesIfFalse")
+			result.AddNodeError(domain.RuleBranchStructure, node.NodeID, "ConditionBranch must have at least one of NextNodesIfTrue or NextNodesIfFalse")
 		}
 	}
 }
@@ -255,7 +403,7 @@ func (v *QuestValidatorService) validateNoCycles(quest *domain.Quest, result *do
 	}
 
 	if hasCycle {
-		result.AddGlobalError("quest contains a cycle (loops are not allowed)")
+		result.AddGlobalError(domain.RuleCycle, "quest contains a cycle (loops are not allowed)")
 	}
 }
 
@@ -310,16 +458,16 @@ func (v *QuestValidatorService) validateReferences(quest *domain.Quest, result *
 	for _, node := range quest.QuestNodes {
 		// Check conversation partners and speakers
 		if node.ConversationPartner != "" && !npcIDs[node.ConversationPartner] {
-			result.AddNodeError(node.NodeID, "unknown conversation partner: "+node.ConversationPartner)
+			result.AddNodeError(domain.RuleUnknownReference, node.NodeID, "unknown conversation partner: "+node.ConversationPartner)
 		}
 		if node.Speaker != "" && !npcIDs[node.Speaker] {
-			result.AddNodeError(node.NodeID, "unknown speaker: "+node.Speaker)
+			result.AddNodeError(domain.RuleUnknownReference, node.NodeID, "unknown speaker: "+node.Speaker)
 		}
 
 		// Check message speakers (allow "Player" as a valid speaker)
 		for _, msg := range node.Messages {
 			if msg.Speaker != "Player" && !npcIDs[msg.Speaker] {
-				result.AddNodeError(node.NodeID, "unknown speaker in message: "+msg.Speaker)
+				result.AddNodeError(domain.RuleUnknownReference, node.NodeID, "unknown speaker in message: "+msg.Speaker)
 			}
 		}
 
@@ -329,7 +477,7 @@ func (v *QuestValidatorService) validateReferences(quest *domain.Quest, result *
 			if ra, ok := cond["ResourceAvailability"].(map[string]interface{}); ok {
 				if resource, ok := ra["Resource"].(string); ok && resource != "" {
 					if !resourceIDs[resource] {
-						result.AddNodeError(node.NodeID, "unknown resource in ResourceAvailability: "+resource)
+						result.AddNodeError(domain.RuleUnknownReference, node.NodeID, "unknown resource in ResourceAvailability: "+resource)
 					}
 				}
 			}
@@ -338,12 +486,12 @@ func (v *QuestValidatorService) validateReferences(quest *domain.Quest, result *
 			if iuo, ok := cond["ItemUsedOnObject"].(map[string]interface{}); ok {
 				if item, ok := iuo["Item"].(string); ok && item != "" {
 					if !itemIDs[item] {
-						result.AddNodeError(node.NodeID, "unknown item in ItemUsedOnObject: "+item)
+						result.AddNodeError(domain.RuleUnknownReference, node.NodeID, "unknown item in ItemUsedOnObject: "+item)
 					}
 				}
 				if obj, ok := iuo["Object"].(string); ok && obj != "" {
 					if !objectIDs[obj] {
-						result.AddNodeError(node.NodeID, "unknown object in ItemUsedOnObject: "+obj)
+						result.AddNodeError(domain.RuleUnknownReference, node.NodeID, "unknown object in ItemUsedOnObject: "+obj)
 					}
 				}
 			}
@@ -352,12 +500,12 @@ func (v *QuestValidatorService) validateReferences(quest *domain.Quest, result *
 			if iun, ok := cond["ItemUsedOnNPC"].(map[string]interface{}); ok {
 				if item, ok := iun["Item"].(string); ok && item != "" {
 					if !itemIDs[item] {
-						result.AddNodeError(node.NodeID, "unknown item in ItemUsedOnNPC: "+item)
+						result.AddNodeError(domain.RuleUnknownReference, node.NodeID, "unknown item in ItemUsedOnNPC: "+item)
 					}
 				}
 				if npc, ok := iun["NPC"].(string); ok && npc != "" {
 					if !npcIDs[npc] {
-						result.AddNodeError(node.NodeID, "unknown NPC in ItemUsedOnNPC: "+npc)
+						result.AddNodeError(domain.RuleUnknownReference, node.NodeID, "unknown NPC in ItemUsedOnNPC: "+npc)
 					}
 				}
 			}
@@ -391,7 +539,7 @@ func (v *QuestValidatorService) validateNoUnreferencedNodes(quest *domain.Quest,
 			continue
 		}
 		if !referenced[node.NodeID] {
-			result.AddNodeError(node.NodeID, "NodeID is never referenced by any other node")
+			result.AddNodeError(domain.RuleUnreachableNode, node.NodeID, "NodeID is never referenced by any other node")
 		}
 	}
 }
@@ -475,7 +623,7 @@ func (v *QuestValidatorService) validateJournalAtFlowStart(quest *domain.Quest,
 		}
 
 		if firstActionsNode == nil {
-			result.AddNodeError(node.NodeID, "EntryPoint flow has no Actions node")
+			result.AddNodeError(domain.RuleJournalAtStart, node.NodeID, "EntryPoint flow has no Actions node")
 			continue
 		}
 
@@ -484,10 +632,10 @@ func (v *QuestValidatorService) validateJournalAtFlowStart(quest *domain.Quest,
 		hasQuestStageDescription := nodeHasAction(firstActionsNode, "QuestStageDescription")
 
 		if !hasJournalEntry {
-			result.AddNodeError(firstActionsNode.NodeID, "first Actions node in flow must have JournalEntry action")
+			result.AddNodeError(domain.RuleJournalAtStart, firstActionsNode.NodeID, "first Actions node in flow must have JournalEntry action")
 		}
 		if !hasQuestStageDescription {
-			result.AddNodeError(firstActionsNode.NodeID, "first Actions node in flow must have QuestStageDescription action")
+			result.AddNodeError(domain.RuleJournalAtStart, firstActionsNode.NodeID, "first Actions node in flow must have QuestStageDescription action")
 		}
 	}
 }
@@ -549,7 +697,100 @@ func (v *QuestValidatorService) validateJournalAtFlowEnd(quest *domain.Quest, re
 		}
 
 		if !hasJournalEntry {
-			result.AddNodeError(node.NodeID, "terminal Actions chain must contain a JournalEntry action")
+			result.AddNodeError(domain.RuleJournalAtEnd, node.NodeID, "terminal Actions chain must contain a JournalEntry action")
+		}
+	}
+}
+
+// validateMigrations checks that each savegame migration moves from an
+// earlier QuestVersion, and that the migration into the current version
+// only targets existing nodes.
+func (v *QuestValidatorService) validateMigrations(quest *domain.Quest, result *domain.ValidationResult) {
+	nodeIDs := make(map[int]bool)
+	for _, node := range quest.QuestNodes {
+		nodeIDs[node.NodeID] = true
+	}
+
+	seen := make(map[int]bool)
+	for i, m := range quest.Migrations {
+		add := func(message string) {
+			result.AddError(domain.ValidationError{Code: domain.RuleInvalidMigration, Field: fmt.Sprintf("$.Migrations[%d]", i), Message: message})
+		}
+		if m.FromVersion < 1 || m.FromVersion >= quest.QuestVersion {
+			add(fmt.Sprintf("migration from version %d must start between version 1 and %d", m.FromVersion, quest.QuestVersion-1))
+		}
+		if seen[m.FromVersion] {
+			add(fmt.Sprintf("duplicate migration from version %d", m.FromVersion))
+		}
+		seen[m.FromVersion] = true
+
+		if m.FromVersion != quest.QuestVersion-1 {
+			continue
+		}
+		for _, nm := range m.Nodes {
+			for _, to := range nm.To {
+				if !nodeIDs[to] {
+					add(fmt.Sprintf("migration from version %d maps node %d to non-existent NodeID %d", m.FromVersion, nm.From, to))
+				}
+			}
+		}
+	}
+}
+
+// suppression is a Suppression together with where it was declared.
+type suppression struct {
+	domain.Suppression
+	nodeID *int   // nil for quest-wide suppressions
+	field  string // JSON path of the suppression
+	used   bool
+}
+
+// applySuppressions silences the issues named by the quest's and nodes'
+// Suppressions. Quest-wide suppressions match all issues of a rule, node
+// suppressions only those of their node. Suppressions without a reason are
+// not applied, and problems with suppressions themselves cannot be
+// suppressed.
+func (v *QuestValidatorService) applySuppressions(quest *domain.Quest, result *domain.ValidationResult) {
+	var suppressions []*suppression
+	for i, s := range quest.Suppressions {
+		suppressions = append(suppressions, &suppression{Suppression: s, field: fmt.Sprintf("$.Suppressions[%d]", i)})
+	}
+	for n, node := range quest.QuestNodes {
+		for i, s := range node.Suppressions {
+			field := fmt.Sprintf("$.QuestNodes[%d].Suppressions[%d]", n, i)
+			suppressions = append(suppressions, &suppression{Suppression: s, nodeID: &node.NodeID, field: field})
+		}
+	}
+	if len(suppressions) == 0 {
+		return
+	}
+
+	result.Suppress(func(err domain.ValidationError) bool {
+		matched := false
+		for _, s := range suppressions {
+			if s.Rule != err.Code || strings.TrimSpace(s.Reason) == "" {
+				continue
+			}
+			if s.nodeID != nil && (err.NodeID == nil || *err.NodeID != *s.nodeID) {
+				continue
+			}
+			s.used = true
+			matched = true
+		}
+		return matched
+	})
+
+	for _, s := range suppressions {
+		add := func(code, message string) {
+			result.AddError(domain.ValidationError{Code: code, NodeID: s.nodeID, Field: s.field, Message: message})
+		}
+		switch {
+		case strings.TrimSpace(s.Reason) == "":
+			add(domain.RuleUnjustifiedSuppression, fmt.Sprintf("suppression of %s must give a Reason", s.Rule))
+		case domain.Rules[s.Rule].Code == "":
+			add(domain.RuleUnknownSuppression, fmt.Sprintf("suppression of unknown rule %s", s.Rule))
+		case !s.used && !domain.FuzzRules[s.Rule]:
+			add(domain.RuleUnusedSuppression, fmt.Sprintf("suppression of %s matches no issue", s.Rule))
 		}
 	}
 }
@@ -1,9 +1,13 @@
 package app
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+	"github.com/tinx/pat-quest-editor/backend/internal/schema"
 )
 
 // mockReferenceData implements ports.ReferenceDataRepository for testing.
@@ -48,7 +52,7 @@ func (m *mockReferenceData) ListObjects() ([]domain.Object, error) {
 func (m *mockReferenceData) GetObject(objectID string) (*domain.Object, error) { return nil, nil }
 
 func TestValidate_ValidQuest(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -70,7 +74,7 @@ func TestValidate_ValidQuest(t *testing.T) {
 }
 
 func TestValidate_DuplicateNodeIDs(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -99,7 +103,7 @@ func TestValidate_DuplicateNodeIDs(t *testing.T) {
 }
 
 func TestValidate_NoEntryPoint(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -116,7 +120,7 @@ func TestValidate_NoEntryPoint(t *testing.T) {
 }
 
 func TestValidate_CycleDetection(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -146,7 +150,7 @@ func TestValidate_CycleDetection(t *testing.T) {
 }
 
 func TestValidate_TerminalNodeWithNextNodes(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -165,7 +169,7 @@ func TestValidate_TerminalNodeWithNextNodes(t *testing.T) {
 }
 
 func TestValidate_UnknownNPC(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -184,7 +188,7 @@ func TestValidate_UnknownNPC(t *testing.T) {
 }
 
 func TestValidate_PlayerSpeakerIsValid(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -210,7 +214,7 @@ func TestValidate_PlayerSpeakerIsValid(t *testing.T) {
 }
 
 func TestValidate_ConditionBranch_Valid(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -242,7 +246,7 @@ func TestValidate_ConditionBranch_Valid(t *testing.T) {
 }
 
 func TestValidate_ConditionBranch_OnlyTrueBranch(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -268,7 +272,7 @@ func TestValidate_ConditionBranch_OnlyTrueBranch(t *testing.T) {
 }
 
 func TestValidate_ConditionBranch_OnlyFalseBranch(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -294,7 +298,7 @@ func TestValidate_ConditionBranch_OnlyFalseBranch(t *testing.T) {
 }
 
 func TestValidate_ConditionBranch_NoBranches(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -325,7 +329,7 @@ func TestValidate_ConditionBranch_NoBranches(t *testing.T) {
 }
 
 func TestValidate_ConditionBranch_NoConditions(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -357,7 +361,7 @@ func TestValidate_ConditionBranch_NoConditions(t *testing.T) {
 }
 
 func TestValidate_ConditionBranch_NoTopLevelNextNodes(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -391,7 +395,7 @@ func TestValidate_ConditionBranch_NoTopLevelNextNodes(t *testing.T) {
 }
 
 func TestValidate_ConditionBranch_InvalidReference(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -423,7 +427,7 @@ func TestValidate_ConditionBranch_InvalidReference(t *testing.T) {
 }
 
 func TestValidate_ConditionBranch_CycleDetection(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -466,7 +470,7 @@ func validTerminalActions(actions ...domain.Action) []domain.Action {
 // ============ Tests for new validation rules ============
 
 func TestValidate_DuplicateEdges(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -495,7 +499,7 @@ func TestValidate_DuplicateEdges(t *testing.T) {
 }
 
 func TestValidate_SelfReference(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -524,7 +528,7 @@ func TestValidate_SelfReference(t *testing.T) {
 }
 
 func TestValidate_SelfReferenceInConditionBranch(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -556,7 +560,7 @@ func TestValidate_SelfReferenceInConditionBranch(t *testing.T) {
 }
 
 func TestValidate_NonTerminalActionsWithoutNextNodes(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -587,7 +591,7 @@ func TestValidate_NonTerminalActionsWithoutNextNodes(t *testing.T) {
 }
 
 func TestValidate_UnreferencedNodeID(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -617,7 +621,7 @@ func TestValidate_UnreferencedNodeID(t *testing.T) {
 }
 
 func TestValidate_EntryPointNodeID_NotRequireReference(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -635,7 +639,7 @@ func TestValidate_EntryPointNodeID_NotRequireReference(t *testing.T) {
 }
 
 func TestValidate_JournalAtFlowStart_Missing(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -667,7 +671,7 @@ func TestValidate_JournalAtFlowStart_Missing(t *testing.T) {
 }
 
 func TestValidate_JournalAtFlowStart_MissingJournalEntry(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -699,7 +703,7 @@ func TestValidate_JournalAtFlowStart_MissingJournalEntry(t *testing.T) {
 }
 
 func TestValidate_JournalAtFlowEnd_Missing(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -735,7 +739,7 @@ func TestValidate_JournalAtFlowEnd_Missing(t *testing.T) {
 }
 
 func TestValidate_JournalAtFlowEnd_InChain(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -760,7 +764,7 @@ func TestValidate_JournalAtFlowEnd_InChain(t *testing.T) {
 }
 
 func TestValidate_MultipleEntryPoints_EachNeedsJournal(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -797,7 +801,7 @@ func TestValidate_MultipleEntryPoints_EachNeedsJournal(t *testing.T) {
 }
 
 func TestValidate_DuplicateEdgesInDecisionOptions(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -824,7 +828,7 @@ func TestValidate_DuplicateEdgesInDecisionOptions(t *testing.T) {
 }
 
 func TestValidate_DuplicateEdgesWithinSameOption(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -859,7 +863,7 @@ func TestValidate_DuplicateEdgesWithinSameOption(t *testing.T) {
 }
 
 func TestValidate_SelfReferenceInDialogOption(t *testing.T) {
-	validator := NewQuestValidatorService(&mockReferenceData{})
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
 
 	quest := &domain.Quest{
 		QuestID: "TestQuest",
@@ -891,3 +895,352 @@ func TestValidate_SelfReferenceInDialogOption(t *testing.T) {
 		t.Errorf("expected self-reference error, got: %v", result.Errors)
 	}
 }
+
+func TestValidate_Migrations(t *testing.T) {
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
+
+	quest := &domain.Quest{
+		QuestID:      "TestQuest",
+		QuestVersion: 3,
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Actions", Actions: []domain.Action{
+				map[string]interface{}{"JournalEntry": map[string]interface{}{}},
+				map[string]interface{}{"QuestStageDescription": map[string]interface{}{}},
+				"CompleteQuest",
+			}},
+		},
+		Migrations: []domain.QuestMigration{
+			{FromVersion: 1, Nodes: []domain.NodeMigration{{From: 4, To: []int{5}}}},
+			{FromVersion: 2, Nodes: []domain.NodeMigration{{From: 2, To: []int{1, 6}}}},
+			{FromVersion: 2},
+			{FromVersion: 3},
+		},
+	}
+
+	result := validator.Validate(quest)
+
+	expected := []string{
+		"migration from version 2 maps node 2 to non-existent NodeID 6",
+		"duplicate migration from version 2",
+		"migration from version 3 must start between version 1 and 2",
+	}
+	if len(result.Errors) != len(expected) {
+		t.Fatalf("expected %d errors, got: %v", len(expected), result.Errors)
+	}
+	for i, msg := range expected {
+		if result.Errors[i].Message != msg {
+			t.Errorf("error %d: expected %q, got %q", i, msg, result.Errors[i].Message)
+		}
+	}
+}
+
+// mockSchemas implements ports.SchemaValidator for testing.
+type mockSchemas map[string]string
+
+func (m mockSchemas) compile(name string) (*schema.Schema, error) {
+	source, ok := m[name]
+	if !ok {
+		return nil, fmt.Errorf("schema %q: %w", name, domain.ErrNotFound)
+	}
+	return schema.Compile([]byte(source))
+}
+
+func (m mockSchemas) Validate(name string, document interface{}) ([]domain.SchemaViolation, error) {
+	s, err := m.compile(name)
+	if err != nil {
+		return nil, err
+	}
+	return mockViolations(s.Validate(document)), nil
+}
+
+func (m mockSchemas) ValidateEach(name string, list interface{}) ([]domain.SchemaViolation, error) {
+	s, err := m.compile(name)
+	if err != nil {
+		return nil, err
+	}
+	return mockViolations(s.ValidateEach(list)), nil
+}
+
+func mockViolations(violations []schema.Violation) []domain.SchemaViolation {
+	result := make([]domain.SchemaViolation, len(violations))
+	for i, v := range violations {
+		result[i] = domain.SchemaViolation{Path: v.Path, Message: v.Message}
+	}
+	return result
+}
+
+func TestValidate_Schema(t *testing.T) {
+	schemas := mockSchemas{"quest": `{
+		"type": "object",
+		"properties": {
+			"QuestType": { "enum": [ "SideQuest", "MainQuest" ] },
+			"QuestNodes": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"properties": {
+						"ConditionsRequired": { "type": "string", "pattern": "^(all|[1-9][0-9]*)$" }
+					}
+				}
+			}
+		}
+	}`}
+	validator := NewQuestValidatorService(&mockReferenceData{}, schemas)
+
+	quest := &domain.Quest{
+		QuestID:   "TestQuest",
+		QuestType: "SecretQuest",
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{7}},
+			{NodeID: 7, NodeType: "Actions", ConditionsRequired: "0", Actions: []domain.Action{
+				map[string]interface{}{"JournalEntry": map[string]interface{}{}},
+				map[string]interface{}{"QuestStageDescription": map[string]interface{}{}},
+				"CompleteQuest",
+			}},
+		},
+	}
+
+	result := validator.Validate(quest)
+
+	if result.Valid || len(result.Errors) != 2 {
+		t.Fatalf("expected 2 schema errors, got: %v", result.Errors)
+	}
+	if e := result.Errors[1]; e.NodeID != nil || e.Field != "$.QuestType" {
+		t.Errorf("unexpected second error: %+v", e)
+	}
+	e := result.Errors[0]
+	if e.NodeID == nil || *e.NodeID != 7 || e.Field != "$.QuestNodes[1].ConditionsRequired" {
+		t.Errorf("expected an error on node 7's ConditionsRequired, got %+v", e)
+	}
+	if e.Message != `$.QuestNodes[1].ConditionsRequired: "0" does not match pattern ^(all|[1-9][0-9]*)$` {
+		t.Errorf("unexpected message: %q", e.Message)
+	}
+}
+
+func TestValidate_SchemaChecksDocument(t *testing.T) {
+	schemas := mockSchemas{"quest": `{
+		"type": "object",
+		"properties": {
+			"DisplayName": { "type": "object", "required": [ "en-US", "de-DE" ] },
+			"QuestNodes": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"properties": {
+						"Options": {
+							"type": "array",
+							"items": { "type": "object", "required": [ "Text" ] }
+						}
+					}
+				}
+			}
+		}
+	}`}
+	validator := NewQuestValidatorService(&mockReferenceData{}, schemas)
+
+	// The document lists node 1 first; the decoded quest is sorted, as after
+	// saving. Decoding fills in the missing translation and option text, so
+	// only the document shows them.
+	var document interface{}
+	if err := json.Unmarshal([]byte(`{
+		"QuestID": "TestQuest",
+		"DisplayName": { "en-US": "Test" },
+		"QuestNodes": [
+			{ "NodeID": 1, "NodeType": "Decision", "Options": [ { "NextNodes": [] } ] },
+			{ "NodeID": 0, "NodeType": "EntryPoint", "NextNodes": [1] }
+		]
+	}`), &document); err != nil {
+		t.Fatal(err)
+	}
+	quest := &domain.Quest{
+		QuestID:     "TestQuest",
+		DisplayName: domain.I18nString{EnUS: "Test"},
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Decision", Options: []domain.DialogOption{{}}},
+		},
+		Document: document,
+	}
+
+	var schemaErrors []domain.ValidationError
+	for _, e := range validator.Validate(quest).Errors {
+		if e.Code == domain.RuleSchema {
+			schemaErrors = append(schemaErrors, e)
+		}
+	}
+
+	if len(schemaErrors) != 2 {
+		t.Fatalf("expected 2 schema errors, got: %v", schemaErrors)
+	}
+	if e := schemaErrors[0]; e.NodeID != nil || e.Field != "$.DisplayName" || !strings.Contains(e.Message, "de-DE") {
+		t.Errorf("expected an error on the missing translation, got %+v", e)
+	}
+	e := schemaErrors[1]
+	if e.NodeID == nil || *e.NodeID != 1 || e.Field != "$.QuestNodes[1].Options[0]" {
+		t.Errorf("expected an error on node 1's option, got %+v", e)
+	}
+}
+
+func TestValidateReferenceData(t *testing.T) {
+	schemas := mockSchemas{
+		"item":     `{ "properties": { "Category": { "enum": [ "Material", "Tool" ] } } }`,
+		"faction":  `{}`,
+		"resource": `{}`,
+		"npc":      `{}`,
+	}
+	validator := NewQuestValidatorService(&mockReferenceData{}, schemas)
+
+	result := validator.ValidateReferenceData()
+
+	expected := []string{
+		`items.yaml: $[0].Category: must be one of "Material", "Tool"`,
+		`objects.yaml: schema "object": not found`,
+	}
+	if len(result.Errors) != len(expected) {
+		t.Fatalf("expected %d errors, got: %v", len(expected), result.Errors)
+	}
+	for i, msg := range expected {
+		if result.Errors[i].Message != msg {
+			t.Errorf("error %d: expected %q, got %q", i, msg, result.Errors[i].Message)
+		}
+	}
+}
+
+func TestValidate_RuleCodes(t *testing.T) {
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
+
+	quest := &domain.Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1, 1}},
+			{NodeID: 1, NodeType: "Actions", Actions: validTerminalActions("CompleteQuest")},
+		},
+	}
+
+	result := validator.Validate(quest)
+
+	if len(result.Errors) != 1 {
+		t.Fatalf("expected one error, got: %v", result.Errors)
+	}
+	if e := result.Errors[0]; e.Code != "PAT001" || e.Severity != domain.SeverityError {
+		t.Errorf("expected a PAT001 error, got %+v", e)
+	}
+}
+
+func TestValidate_Suppressions(t *testing.T) {
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
+
+	quest := &domain.Quest{
+		QuestID: "TestQuest",
+		Suppressions: []domain.Suppression{
+			{Rule: "PAT013", Reason: "the NPC is added in the next content update"},
+			{Rule: "PAT099", Reason: "typo"},
+		},
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Dialog", ConversationPartner: "NPC:Stranger", NextNodes: []int{2}},
+			{NodeID: 2, NodeType: "Actions", Actions: []domain.Action{"CompleteQuest"}, Suppressions: []domain.Suppression{
+				{Rule: "PAT014", Reason: "the dialog introduces the quest"},
+				{Rule: "PAT015", Reason: "covered by the dialog"},
+				{Rule: "PAT001", Reason: "left over"},
+				// Only the checker's fuzzer reports PAT042, so it is never unused here
+				{Rule: "PAT042", Reason: "races the delivery against losing the item"},
+			}},
+		},
+	}
+
+	result := validator.Validate(quest)
+
+	if !result.Valid {
+		t.Errorf("expected suppressed errors not to invalidate the quest, got: %v", result.Errors)
+	}
+	if len(result.Suppressed) != 4 {
+		t.Errorf("expected 4 suppressed issues, got: %v", result.Suppressed)
+	}
+
+	node2 := 2
+	want := []struct {
+		code     string
+		severity domain.Severity
+		nodeID   *int
+	}{
+		{"PAT031", domain.SeverityWarning, nil},
+		{"PAT032", domain.SeverityInfo, &node2},
+	}
+	if len(result.Errors) != len(want) {
+		t.Fatalf("expected %d issues, got: %v", len(want), result.Errors)
+	}
+	for i, w := range want {
+		e := result.Errors[i]
+		if e.Code != w.code || e.Severity != w.severity || (e.NodeID == nil) != (w.nodeID == nil) ||
+			(e.NodeID != nil && *e.NodeID != *w.nodeID) {
+			t.Errorf("issue %d: expected %s %s, got %+v", i, w.severity, w.code, e)
+		}
+	}
+}
+
+func TestValidate_SuppressionWithoutReason(t *testing.T) {
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
+
+	quest := &domain.Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1, 1}, Suppressions: []domain.Suppression{
+				{Rule: "PAT001"},
+			}},
+			{NodeID: 1, NodeType: "Actions", Actions: validTerminalActions("CompleteQuest")},
+		},
+	}
+
+	result := validator.Validate(quest)
+
+	if result.Valid || len(result.Suppressed) != 0 {
+		t.Fatalf("expected the suppression not to apply, got: %+v", result)
+	}
+	if len(result.Errors) != 2 || result.Errors[0].Code != "PAT001" || result.Errors[1].Code != "PAT030" {
+		t.Errorf("expected PAT001 and PAT030 errors, got: %v", result.Errors)
+	}
+}
+
+func TestValidate_SourcePositions(t *testing.T) {
+	validator := NewQuestValidatorService(&mockReferenceData{}, nil)
+
+	quest := &domain.Quest{
+		QuestID:      "TestQuest",
+		QuestVersion: 1,
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Actions", Actions: validTerminalActions("CompleteQuest"), NextNodes: []int{0}},
+		},
+		Migrations: []domain.QuestMigration{{FromVersion: 1}},
+		Source: &domain.SourceMap{
+			File: "test_quest.yaml",
+			Positions: map[string]domain.Position{
+				"$":               {Line: 1, Column: 1},
+				"$.QuestNodes[1]": {Line: 9, Column: 5},
+				"$.Migrations":    {Line: 14, Column: 1},
+			},
+		},
+	}
+
+	result := validator.Validate(quest)
+
+	located := map[string]bool{}
+	for _, e := range result.Errors {
+		if e.File != "test_quest.yaml" {
+			t.Errorf("expected every issue in test_quest.yaml, got %+v", e)
+		}
+		switch e.Code {
+		case domain.RuleTerminalWithNextNodes:
+			located[e.Code] = e.Line == 9 && e.Column == 5
+		case domain.RuleInvalidMigration:
+			located[e.Code] = e.Field == "$.Migrations[0]" && e.Line == 14 && e.Column == 1
+		}
+	}
+	for _, code := range []string{domain.RuleTerminalWithNextNodes, domain.RuleInvalidMigration} {
+		if !located[code] {
+			t.Errorf("expected %s to be located, got: %+v", code, result.Errors)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package domain
+
+import "time"
+
+// QuestBackup is a previous version of a quest file.
+type QuestBackup struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	Size    int64     `json:"size"`
+}
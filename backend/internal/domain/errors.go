@@ -9,4 +9,8 @@ var (
 
 	// ErrInvalidInput is returned when input validation fails.
 	ErrInvalidInput = errors.New("invalid input")
+
+	// ErrDuplicateQuestID is returned when several quest files have the
+	// QuestID of a requested quest.
+	ErrDuplicateQuestID = errors.New("duplicate quest ID")
 )
@@ -0,0 +1,22 @@
+package domain
+
+// Fix is a repair the fixer applied to a quest.
+type Fix struct {
+	Code    string `json:"code"`
+	NodeID  int    `json:"nodeId"`
+	Message string `json:"message"`
+}
+
+// FixResult is the outcome of fixing a quest file.
+type FixResult struct {
+	QuestID string `json:"questId"`
+	Fixes   []Fix  `json:"fixes"`
+	// Diff shows the changes to the quest file as a unified diff.
+	Diff string `json:"diff"`
+	// Applied reports whether the fixed file was saved.
+	Applied bool `json:"applied"`
+	// Validation is the validation result of the saved quest.
+	Validation *ValidationResult `json:"validation,omitempty"`
+	// Source is the fixed quest file.
+	Source []byte `json:"-"`
+}
@@ -0,0 +1,23 @@
+package domain
+
+// QuestMigration describes how a savegame moves from FromVersion of a
+// quest to FromVersion+1. Active nodes and variables that are not listed
+// keep their IDs and names.
+type QuestMigration struct {
+	FromVersion int                 `yaml:"FromVersion" json:"FromVersion"`
+	Nodes       []NodeMigration     `yaml:"Nodes,omitempty" json:"Nodes,omitempty"`
+	Variables   []VariableMigration `yaml:"Variables,omitempty" json:"Variables,omitempty"`
+}
+
+// NodeMigration maps an old NodeID to the nodes that replace it. An empty
+// To list drops the node from the savegame.
+type NodeMigration struct {
+	From int   `yaml:"From" json:"From"`
+	To   []int `yaml:"To" json:"To"`
+}
+
+// VariableMigration renames a quest variable. An empty To drops it.
+type VariableMigration struct {
+	From string `yaml:"From" json:"From"`
+	To   string `yaml:"To,omitempty" json:"To,omitempty"`
+}
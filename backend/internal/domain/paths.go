@@ -0,0 +1,41 @@
+package domain
+
+// PathReport lists the distinct paths through a quest.
+type PathReport struct {
+	QuestID string      `json:"questId"`
+	Paths   []QuestPath `json:"paths"`
+	// Truncated is set when the quest has more paths than were enumerated.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// QuestPath is one way from an EntryPoint to a terminal Actions node.
+type QuestPath struct {
+	Steps   []PathStep   `json:"steps"`
+	Outcome string       `json:"outcome"`
+	Rewards PathRewards  `json:"rewards"`
+	Journal []I18nString `json:"journal"`
+}
+
+// Path outcomes, named after the terminal action that ends the path.
+const (
+	OutcomeComplete = "Complete"
+	OutcomeFail     = "Fail"
+	OutcomeDecline  = "Decline"
+)
+
+// PathStep is a node on a path, with the Decision option or
+// ConditionBranch arm that was taken to leave it.
+type PathStep struct {
+	NodeID int    `json:"nodeId"`
+	Option int    `json:"option,omitempty"`
+	Branch string `json:"branch,omitempty"`
+}
+
+// PathRewards accumulates the rewards and punishments along a path. Items
+// are the items gained minus the items lost, negative for lost items.
+type PathRewards struct {
+	Items      map[string]int `json:"items,omitempty"`
+	Currency   int            `json:"currency,omitempty"`
+	Experience int            `json:"experience,omitempty"`
+	Factions   map[string]int `json:"factions,omitempty"`
+}
@@ -8,13 +8,21 @@ type I18nString struct {
 
 // Quest represents a complete quest definition.
 type Quest struct {
-	QuestTypeVersion int          `yaml:"QuestTypeVersion" json:"QuestTypeVersion"`
-	QuestVersion     int          `yaml:"QuestVersion" json:"QuestVersion"`
-	QuestID          string       `yaml:"QuestID" json:"QuestID"`
-	QuestType        string       `yaml:"QuestType" json:"QuestType"`
-	DisplayName      I18nString   `yaml:"DisplayName" json:"DisplayName"`
-	Repeatable       string       `yaml:"Repeatable" json:"Repeatable"`
-	QuestNodes       []QuestNode  `yaml:"QuestNodes" json:"QuestNodes"`
+	QuestTypeVersion int              `yaml:"QuestTypeVersion" json:"QuestTypeVersion"`
+	QuestVersion     int              `yaml:"QuestVersion" json:"QuestVersion"`
+	QuestID          string           `yaml:"QuestID" json:"QuestID"`
+	QuestType        string           `yaml:"QuestType" json:"QuestType"`
+	DisplayName      I18nString       `yaml:"DisplayName" json:"DisplayName"`
+	Repeatable       string           `yaml:"Repeatable,omitempty" json:"Repeatable,omitempty"`
+	QuestNodes       []QuestNode      `yaml:"QuestNodes" json:"QuestNodes"`
+	Migrations       []QuestMigration `yaml:"Migrations,omitempty" json:"Migrations,omitempty"`
+	Suppressions     []Suppression    `yaml:"Suppressions,omitempty" json:"Suppressions,omitempty"`
+	// Source is where the quest was read from, if it was read from a file.
+	Source *SourceMap `yaml:"-" json:"-"`
+	// Document is the quest as parsed from its file or request, before it
+	// was decoded into the fields above. Decoding drops unknown keys and
+	// fills in missing values, so the schema is checked against Document.
+	Document interface{} `yaml:"-" json:"-"`
 }
 
 // QuestNode represents a node in the quest state machine.
@@ -32,6 +40,14 @@ type QuestNode struct {
 	Options             []DialogOption       `yaml:"Options,omitempty" json:"Options,omitempty"`
 	Messages            []DialogMessage      `yaml:"Messages,omitempty" json:"Messages,omitempty"`
 	Actions             []Action             `yaml:"Actions,omitempty" json:"Actions,omitempty"`
+	Suppressions        []Suppression        `yaml:"Suppressions,omitempty" json:"Suppressions,omitempty"`
+}
+
+// Suppression silences a validation rule for a whole quest or a single
+// node. Reason documents why the rule does not apply.
+type Suppression struct {
+	Rule   string `yaml:"Rule" json:"Rule"`
+	Reason string `yaml:"Reason" json:"Reason"`
 }
 
 // Condition represents a condition that can be checked.
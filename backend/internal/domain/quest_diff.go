@@ -0,0 +1,44 @@
+package domain
+
+// QuestChangeKind classifies the changes between two versions of a quest.
+type QuestChangeKind string
+
+const (
+	ChangeQuest          QuestChangeKind = "quest-changed"
+	ChangeNodeAdded      QuestChangeKind = "node-added"
+	ChangeNodeRemoved    QuestChangeKind = "node-removed"
+	ChangeNode           QuestChangeKind = "node-changed"
+	ChangeEdgeAdded      QuestChangeKind = "edge-added"
+	ChangeEdgeRemoved    QuestChangeKind = "edge-removed"
+	ChangeOptionAdded    QuestChangeKind = "option-added"
+	ChangeOptionRemoved  QuestChangeKind = "option-removed"
+	ChangeMessageAdded   QuestChangeKind = "message-added"
+	ChangeMessageRemoved QuestChangeKind = "message-removed"
+	ChangeConditions     QuestChangeKind = "conditions-changed"
+	ChangeActions        QuestChangeKind = "actions-changed"
+	ChangeText           QuestChangeKind = "text-changed"
+)
+
+// QuestChange is a difference between two versions of a quest in terms of
+// the quest model. NodeID is nil for changes of the quest itself; Field
+// names the changed value, like "NextNodes" or "Options[1].Text". Target is
+// the node an added or removed edge leads to. Old and New hold changed
+// values, with lists and mappings as JSON.
+type QuestChange struct {
+	Kind     QuestChangeKind `json:"kind"`
+	NodeID   *int            `json:"nodeId,omitempty"`
+	Field    string          `json:"field,omitempty"`
+	Language string          `json:"language,omitempty"`
+	Target   *int            `json:"target,omitempty"`
+	Old      string          `json:"old,omitempty"`
+	New      string          `json:"new,omitempty"`
+}
+
+// QuestDiff is the outcome of comparing an earlier version of a quest with
+// the current one.
+type QuestDiff struct {
+	QuestID string `json:"questId"`
+	// From names the earlier version, like "revision 12" or "commit 1a2b3c".
+	From    string        `json:"from"`
+	Changes []QuestChange `json:"changes"`
+}
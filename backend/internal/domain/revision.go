@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// QuestRevision is a version of a quest as saved from the editor: its file
+// and its editor metadata. Lists of revisions leave out Source and Metadata.
+type QuestRevision struct {
+	ID       int64          `json:"id"`
+	QuestID  string         `json:"questId"`
+	Created  time.Time      `json:"created"`
+	Author   string         `json:"author,omitempty"`
+	Size     int64          `json:"size"`
+	Source   string         `json:"source,omitempty"`
+	Metadata *QuestMetadata `json:"metadata,omitempty"`
+}
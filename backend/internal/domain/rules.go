@@ -0,0 +1,81 @@
+package domain
+
+// Rule describes a validation rule. Codes are stable, so tools and
+// suppressions can refer to a rule without matching on message text.
+type Rule struct {
+	Code     string   `json:"code"`
+	Severity Severity `json:"severity"`
+	Summary  string   `json:"summary"`
+}
+
+// Validation rule codes. The quest checker uses the same codes.
+const (
+	RuleDuplicateEdge          = "PAT001"
+	RuleUnknownNode            = "PAT002"
+	RuleSelfReference          = "PAT003"
+	RuleDuplicateNodeID        = "PAT004"
+	RuleUnreachableNode        = "PAT005"
+	RuleNoEntryPoint           = "PAT006"
+	RuleCycle                  = "PAT007"
+	RuleTerminalWithNextNodes  = "PAT008"
+	RuleMultipleTerminals      = "PAT009"
+	RuleNoOutgoingEdges        = "PAT010"
+	RuleDecisionStructure      = "PAT011"
+	RuleBranchStructure        = "PAT012"
+	RuleUnknownReference       = "PAT013"
+	RuleJournalAtStart         = "PAT014"
+	RuleJournalAtEnd           = "PAT015"
+	RuleInvalidMigration       = "PAT016"
+	RuleSchema                 = "PAT017"
+	RuleDuplicateQuestID       = "PAT020"
+	RuleDuplicateDisplayName   = "PAT021"
+	RuleDuplicateStage         = "PAT022"
+	RuleUnknownQuest           = "PAT023"
+	RuleVersionDecreased       = "PAT024"
+	RuleMissingMigration       = "PAT025"
+	RuleVariableMigration      = "PAT026"
+	RuleUnjustifiedSuppression = "PAT030"
+	RuleUnknownSuppression     = "PAT031"
+	RuleUnusedSuppression      = "PAT032"
+	RuleFuzzStuck              = "PAT040"
+	RuleFuzzNotAccepted        = "PAT041"
+	RuleFuzzActiveAtEnd        = "PAT042"
+)
+
+// Rules lists all validation rules by code.
+var Rules = map[string]Rule{
+	RuleDuplicateEdge:          {RuleDuplicateEdge, SeverityError, "duplicate edge"},
+	RuleUnknownNode:            {RuleUnknownNode, SeverityError, "edge to a non-existent node"},
+	RuleSelfReference:          {RuleSelfReference, SeverityError, "node references itself"},
+	RuleDuplicateNodeID:        {RuleDuplicateNodeID, SeverityError, "duplicate NodeID"},
+	RuleUnreachableNode:        {RuleUnreachableNode, SeverityError, "node has no incoming connections"},
+	RuleNoEntryPoint:           {RuleNoEntryPoint, SeverityError, "quest has no EntryPoint"},
+	RuleCycle:                  {RuleCycle, SeverityError, "quest contains a cycle"},
+	RuleTerminalWithNextNodes:  {RuleTerminalWithNextNodes, SeverityError, "terminal Actions node has NextNodes"},
+	RuleMultipleTerminals:      {RuleMultipleTerminals, SeverityError, "Actions node has more than one terminal action"},
+	RuleNoOutgoingEdges:        {RuleNoOutgoingEdges, SeverityError, "non-terminal node has no outgoing edges"},
+	RuleDecisionStructure:      {RuleDecisionStructure, SeverityError, "malformed Decision node"},
+	RuleBranchStructure:        {RuleBranchStructure, SeverityError, "malformed ConditionBranch node"},
+	RuleUnknownReference:       {RuleUnknownReference, SeverityError, "unknown NPC, item, faction, resource or object"},
+	RuleJournalAtStart:         {RuleJournalAtStart, SeverityError, "quest flow starts without a journal entry"},
+	RuleJournalAtEnd:           {RuleJournalAtEnd, SeverityError, "quest flow ends without a journal entry"},
+	RuleInvalidMigration:       {RuleInvalidMigration, SeverityError, "invalid savegame migration"},
+	RuleSchema:                 {RuleSchema, SeverityError, "JSON schema violation"},
+	RuleDuplicateQuestID:       {RuleDuplicateQuestID, SeverityError, "duplicate QuestID"},
+	RuleDuplicateDisplayName:   {RuleDuplicateDisplayName, SeverityWarning, "duplicate DisplayName"},
+	RuleDuplicateStage:         {RuleDuplicateStage, SeverityWarning, "duplicate QuestStageDescription"},
+	RuleUnknownQuest:           {RuleUnknownQuest, SeverityError, "QuestCompleted references an unknown quest"},
+	RuleVersionDecreased:       {RuleVersionDecreased, SeverityError, "QuestVersion decreased"},
+	RuleMissingMigration:       {RuleMissingMigration, SeverityError, "released node removed or replaced without a version bump or migration"},
+	RuleVariableMigration:      {RuleVariableMigration, SeverityError, "released variable removed without a version bump or migration"},
+	RuleUnjustifiedSuppression: {RuleUnjustifiedSuppression, SeverityError, "suppression without a reason"},
+	RuleUnknownSuppression:     {RuleUnknownSuppression, SeverityWarning, "suppression of an unknown rule"},
+	RuleUnusedSuppression:      {RuleUnusedSuppression, SeverityInfo, "suppression that matches no issue"},
+	RuleFuzzStuck:              {RuleFuzzStuck, SeverityError, "playthrough gets stuck or does not end"},
+	RuleFuzzNotAccepted:        {RuleFuzzNotAccepted, SeverityError, "CompleteQuest before AcceptQuest"},
+	RuleFuzzActiveAtEnd:        {RuleFuzzActiveAtEnd, SeverityError, "quest ends while other nodes are still active"},
+}
+
+// FuzzRules are the rules that only the checker's fuzzer reports. The
+// validation never matches their suppressions.
+var FuzzRules = map[string]bool{RuleFuzzStuck: true, RuleFuzzNotAccepted: true, RuleFuzzActiveAtEnd: true}
@@ -0,0 +1,49 @@
+package domain
+
+import "strings"
+
+// Position is a line and column in a quest file, both starting at 1.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// SourceMap records where the values of a quest were read from. Positions
+// are keyed by JSON path, written like the paths of schema violations
+// (e.g. "$.QuestNodes[2].NextNodes"); mapping entries start at their key.
+type SourceMap struct {
+	File      string
+	Positions map[string]Position
+}
+
+// Lookup returns the position of path, or of the closest value enclosing
+// it if path itself was not read from the file.
+func (s *SourceMap) Lookup(path string) (Position, bool) {
+	if s == nil {
+		return Position{}, false
+	}
+	for {
+		if pos, ok := s.Positions[path]; ok {
+			return pos, true
+		}
+		parent, ok := parentPath(path)
+		if !ok {
+			return Position{}, false
+		}
+		path = parent
+	}
+}
+
+// parentPath strips the last property or index from a JSON path.
+func parentPath(path string) (string, bool) {
+	if strings.HasSuffix(path, "]") {
+		if i := strings.LastIndex(path, "["); i > 0 {
+			return path[:i], true
+		}
+		return "", false
+	}
+	if i := strings.LastIndex(path, "."); i > 0 {
+		return path[:i], true
+	}
+	return "", false
+}
@@ -0,0 +1,25 @@
+package domain
+
+// QuestState is the savegame representation of a running quest. It is
+// keyed by QuestID and QuestVersion, so a state can only be restored into
+// the quest version it was saved from. Active nodes are ordered by NodeID
+// and maps are encoded with sorted keys, which keeps the YAML and JSON
+// output stable.
+type QuestState struct {
+	QuestID          string         `yaml:"QuestID" json:"QuestID"`
+	QuestVersion     int            `yaml:"QuestVersion" json:"QuestVersion"`
+	Status           string         `yaml:"Status" json:"Status"`
+	Accepted         bool           `yaml:"Accepted" json:"Accepted"`
+	ActiveNodes      []NodeState    `yaml:"ActiveNodes" json:"ActiveNodes"`
+	Variables        map[string]int `yaml:"Variables,omitempty" json:"Variables,omitempty"`
+	Journal          []I18nString   `yaml:"Journal,omitempty" json:"Journal,omitempty"`
+	StageDescription *I18nString    `yaml:"StageDescription,omitempty" json:"StageDescription,omitempty"`
+}
+
+// NodeState records an active node and what happened since it became
+// active, such as the hours passed and EventTriggered counts.
+type NodeState struct {
+	NodeID int            `yaml:"NodeID" json:"NodeID"`
+	Hours  int            `yaml:"Hours,omitempty" json:"Hours,omitempty"`
+	Events map[string]int `yaml:"Events,omitempty" json:"Events,omitempty"`
+}
@@ -2,11 +2,26 @@ package domain
 
 import "fmt"
 
+// Severity classifies a validation issue. Only errors make a quest invalid.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
 // ValidationError represents a single validation issue.
 type ValidationError struct {
-	NodeID  *int   `json:"nodeId,omitempty"`
-	Field   string `json:"field,omitempty"`
-	Message string `json:"message"`
+	Code     string   `json:"code,omitempty"`
+	Severity Severity `json:"severity"`
+	NodeID   *int     `json:"nodeId,omitempty"`
+	Field    string   `json:"field,omitempty"`
+	Message  string   `json:"message"`
+	// File, Line and Column locate the issue in the quest file, if known.
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
 }
 
 func (e ValidationError) Error() string {
@@ -16,10 +31,25 @@ func (e ValidationError) Error() string {
 	return e.Message
 }
 
-// ValidationResult contains all validation errors for a quest.
+// SchemaViolation is a single place where a document does not match a
+// JSON schema.
+type SchemaViolation struct {
+	// Path is a JSON path into the document, such as
+	// "$.QuestNodes[3].Conditions[0]".
+	Path    string
+	Message string
+}
+
+func (v SchemaViolation) Error() string {
+	return fmt.Sprintf("%s: %s", v.Path, v.Message)
+}
+
+// ValidationResult contains all validation issues for a quest.
 type ValidationResult struct {
 	Valid  bool              `json:"valid"`
 	Errors []ValidationError `json:"errors,omitempty"`
+	// Suppressed lists issues silenced by the quest's Suppressions.
+	Suppressed []ValidationError `json:"suppressed,omitempty"`
 }
 
 // NewValidationResult creates an empty valid result.
@@ -27,18 +57,46 @@ func NewValidationResult() *ValidationResult {
 	return &ValidationResult{Valid: true, Errors: []ValidationError{}}
 }
 
-// AddError adds an error and marks result as invalid.
+// AddError adds an issue. Without a severity, the severity of its rule is
+// used, and issues of unknown rules are errors. Errors mark the result as
+// invalid.
 func (r *ValidationResult) AddError(err ValidationError) {
-	r.Valid = false
+	if err.Severity == "" {
+		err.Severity = SeverityError
+		if rule, ok := Rules[err.Code]; ok {
+			err.Severity = rule.Severity
+		}
+	}
+	if err.Severity == SeverityError {
+		r.Valid = false
+	}
 	r.Errors = append(r.Errors, err)
 }
 
-// AddNodeError adds an error associated with a specific node.
-func (r *ValidationResult) AddNodeError(nodeID int, message string) {
-	r.AddError(ValidationError{NodeID: &nodeID, Message: message})
+// AddNodeError adds an issue of the given rule associated with a specific node.
+func (r *ValidationResult) AddNodeError(code string, nodeID int, message string) {
+	r.AddError(ValidationError{Code: code, NodeID: &nodeID, Message: message})
 }
 
-// AddGlobalError adds an error not associated with a specific node.
-func (r *ValidationResult) AddGlobalError(message string) {
-	r.AddError(ValidationError{Message: message})
+// AddGlobalError adds an issue of the given rule not associated with a specific node.
+func (r *ValidationResult) AddGlobalError(code string, message string) {
+	r.AddError(ValidationError{Code: code, Message: message})
+}
+
+// Suppress moves the issues for which match returns true to Suppressed,
+// and re-evaluates whether the result is valid.
+func (r *ValidationResult) Suppress(match func(ValidationError) bool) {
+	kept := r.Errors[:0]
+	r.Valid = true
+	for _, err := range r.Errors {
+		if match(err) {
+			r.Suppressed = append(r.Suppressed, err)
+			continue
+		}
+		if err.Severity == SeverityError {
+			r.Valid = false
+		}
+		kept = append(kept, err)
+	}
+	r.Errors = kept
 }
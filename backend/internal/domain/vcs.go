@@ -0,0 +1,51 @@
+package domain
+
+import "time"
+
+// VCSStatus is the state of a quest file in the working tree of its version
+// control repository.
+type VCSStatus string
+
+const (
+	VCSModified   VCSStatus = "modified"
+	VCSAdded      VCSStatus = "added"
+	VCSDeleted    VCSStatus = "deleted"
+	VCSRenamed    VCSStatus = "renamed"
+	VCSUntracked  VCSStatus = "untracked"
+	VCSConflicted VCSStatus = "conflicted"
+)
+
+// QuestVCSStatus is the state of a quest file that differs from the last
+// commit. QuestID is empty if the file has none.
+type QuestVCSStatus struct {
+	QuestID string    `json:"questId,omitempty"`
+	Path    string    `json:"path"`
+	Status  VCSStatus `json:"status"`
+}
+
+// Commit is a commit of the version control repository.
+type Commit struct {
+	Hash    string    `json:"hash"`
+	Author  string    `json:"author"`
+	Email   string    `json:"email"`
+	Date    time.Time `json:"date"`
+	Subject string    `json:"subject"`
+	// Path is the path of the quest file as of the commit, in the history
+	// of a quest, since quest files can be renamed.
+	Path string `json:"path,omitempty"`
+}
+
+// Blame holds the lines of a quest file together with the commits that
+// last changed them.
+type Blame struct {
+	Lines   []BlameLine       `json:"lines"`
+	Commits map[string]Commit `json:"commits"`
+}
+
+// BlameLine is a line of a quest file and the hash of the commit that last
+// changed it, which is empty if the line hasn't been committed yet.
+type BlameLine struct {
+	Line int    `json:"line"`
+	Hash string `json:"hash,omitempty"`
+	Text string `json:"text"`
+}
@@ -18,6 +18,25 @@ type QuestRepository interface {
 	
 	// Exists checks if a quest with the given ID exists.
 	Exists(questID string) (bool, error)
+
+	// GetSource retrieves the stored file of a quest.
+	GetSource(questID string) ([]byte, error)
+
+	// GetWithSource retrieves a quest together with the stored file it was
+	// parsed from.
+	GetWithSource(questID string) (*domain.Quest, []byte, error)
+
+	// SaveSource replaces the stored file of a quest, or creates it.
+	SaveSource(questID string, source []byte) error
+
+	// ListBackups returns the backups of a quest, newest first.
+	ListBackups(questID string) ([]domain.QuestBackup, error)
+
+	// RestoreBackup replaces a quest with one of its backups.
+	RestoreBackup(questID, backupID string) error
+
+	// Path returns the path of the file of a quest.
+	Path(questID string) (string, error)
 }
 
 // ReferenceDataRepository defines operations for reference data (items, factions, etc.).
@@ -64,3 +83,34 @@ type MetadataRepository interface {
 	// DeleteQuestMetadata removes editor metadata for a quest.
 	DeleteQuestMetadata(questID string) error
 }
+
+// RevisionRepository defines operations for the revision history of quest saves.
+type RevisionRepository interface {
+	// SaveRevision records a version of a quest and assigns its ID and creation time.
+	SaveRevision(revision *domain.QuestRevision) error
+
+	// ListRevisions returns the revisions of a quest, newest first, without their content.
+	ListRevisions(questID string) ([]domain.QuestRevision, error)
+
+	// GetRevision retrieves a revision of a quest.
+	GetRevision(questID string, revisionID int64) (*domain.QuestRevision, error)
+}
+
+// VersionControl provides the version control repository of the quest files.
+type VersionControl interface {
+	// Status returns the quest files that differ from the last commit.
+	Status() ([]domain.QuestVCSStatus, error)
+
+	// History returns the commits that changed the file of a quest, newest first.
+	History(questID string) ([]domain.Commit, error)
+
+	// Show retrieves the file of a quest as of a revision.
+	Show(questID, revision string) ([]byte, error)
+
+	// Blame returns the lines of the file of a quest with the commits that last changed them.
+	Blame(questID string) (*domain.Blame, error)
+
+	// Commit commits the changes to the files of the given quests. If
+	// author ("Name <email>") is empty, the default identity is used.
+	Commit(questIDs []string, message, author string) (*domain.Commit, error)
+}
@@ -7,3 +7,33 @@ type QuestValidator interface {
 	// Validate checks a quest against all rules and returns validation results.
 	Validate(quest *domain.Quest) *domain.ValidationResult
 }
+
+// QuestAnalyzer defines the interface for static quest analysis.
+type QuestAnalyzer interface {
+	// Paths enumerates every path from an EntryPoint to a terminal Actions node.
+	Paths(quest *domain.Quest) *domain.PathReport
+}
+
+// QuestFixer defines the interface for repairing quest files.
+type QuestFixer interface {
+	// Fix repairs the issues of a quest file that have an unambiguous fix.
+	Fix(source []byte) (*domain.FixResult, error)
+}
+
+// QuestDiffer defines the interface for comparing versions of a quest.
+type QuestDiffer interface {
+	// Diff returns the changes from one version of a quest file to another.
+	Diff(from, to []byte) ([]domain.QuestChange, error)
+}
+
+// SchemaValidator checks documents against the JSON schemas that quests and
+// reference data must follow. Schemas are named "quest", "item",
+// "faction", "resource", "npc" or "object".
+type SchemaValidator interface {
+	// Validate checks a document against a schema.
+	Validate(name string, document interface{}) ([]domain.SchemaViolation, error)
+
+	// ValidateEach checks every element of a list against a schema. Paths
+	// start at the element, e.g. "$[2].Category".
+	ValidateEach(name string, list interface{}) ([]domain.SchemaViolation, error)
+}
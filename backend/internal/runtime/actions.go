@@ -0,0 +1,147 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+// ActionKind names one of the action types defined in schemas/quest.json.
+type ActionKind string
+
+// Action kinds supported by the engine.
+const (
+	ActionAcceptQuest           ActionKind = "AcceptQuest"
+	ActionDeclineQuest          ActionKind = "DeclineQuest"
+	ActionFailQuest             ActionKind = "FailQuest"
+	ActionCompleteQuest         ActionKind = "CompleteQuest"
+	ActionItemsGained           ActionKind = "ItemsGained"
+	ActionItemsLost             ActionKind = "ItemsLost"
+	ActionFactionStanding       ActionKind = "FactionStanding"
+	ActionCurrency              ActionKind = "Currency"
+	ActionExperience            ActionKind = "Experience"
+	ActionJournalEntry          ActionKind = "JournalEntry"
+	ActionSetVariable           ActionKind = "SetVariable"
+	ActionQuestStageDescription ActionKind = "QuestStageDescription"
+)
+
+// ItemStack is an amount of one item type, as used by ItemsGained and ItemsLost.
+type ItemStack struct {
+	Type      string `json:"Type"`
+	Count     int    `json:"Count"`
+	QuestItem bool   `json:"QuestItem,omitempty"`
+}
+
+// Action is the typed form of a domain.Action. Only the fields relevant
+// for the given Kind are set.
+type Action struct {
+	Kind ActionKind
+
+	// Items is set for ItemsGained and ItemsLost.
+	Items []ItemStack
+	// Faction and Points are set for FactionStanding.
+	Faction string
+	Points  int
+	// Amount is set for Currency and Experience.
+	Amount int
+	// Text is set for JournalEntry and QuestStageDescription.
+	Text domain.I18nString
+	// Variable, Operation and Value are set for SetVariable.
+	Variable  string
+	Operation string
+	Value     int
+}
+
+// IsTerminal reports whether the action ends the quest.
+func (a Action) IsTerminal() bool {
+	switch a.Kind {
+	case ActionCompleteQuest, ActionFailQuest, ActionDeclineQuest:
+		return true
+	}
+	return false
+}
+
+// ParseAction converts an untyped quest action into an Action.
+func ParseAction(raw domain.Action) (Action, error) {
+	if name, ok := raw.(string); ok {
+		switch kind := ActionKind(name); kind {
+		case ActionAcceptQuest, ActionDeclineQuest, ActionFailQuest, ActionCompleteQuest:
+			return Action{Kind: kind}, nil
+		}
+		return Action{}, fmt.Errorf("unknown action %q", name)
+	}
+
+	var typed struct {
+		ItemsGained     []ItemStack `json:"ItemsGained"`
+		ItemsLost       []ItemStack `json:"ItemsLost"`
+		FactionStanding *struct {
+			Faction string `json:"Faction"`
+			Points  int    `json:"Points"`
+		} `json:"FactionStanding"`
+		Currency              *int               `json:"Currency"`
+		Experience            *int               `json:"Experience"`
+		JournalEntry          *domain.I18nString `json:"JournalEntry"`
+		QuestStageDescription *domain.I18nString `json:"QuestStageDescription"`
+		SetVariable           *struct {
+			VariableName string `json:"VariableName"`
+			Operation    string `json:"Operation"`
+			Value        int    `json:"Value"`
+		} `json:"SetVariable"`
+	}
+	if err := remarshal(raw, &typed); err != nil {
+		return Action{}, fmt.Errorf("malformed action: %w", err)
+	}
+
+	switch {
+	case typed.ItemsGained != nil:
+		return Action{Kind: ActionItemsGained, Items: typed.ItemsGained}, nil
+	case typed.ItemsLost != nil:
+		return Action{Kind: ActionItemsLost, Items: typed.ItemsLost}, nil
+	case typed.FactionStanding != nil:
+		return Action{Kind: ActionFactionStanding, Faction: typed.FactionStanding.Faction, Points: typed.FactionStanding.Points}, nil
+	case typed.Currency != nil:
+		return Action{Kind: ActionCurrency, Amount: *typed.Currency}, nil
+	case typed.Experience != nil:
+		return Action{Kind: ActionExperience, Amount: *typed.Experience}, nil
+	case typed.JournalEntry != nil:
+		return Action{Kind: ActionJournalEntry, Text: *typed.JournalEntry}, nil
+	case typed.QuestStageDescription != nil:
+		return Action{Kind: ActionQuestStageDescription, Text: *typed.QuestStageDescription}, nil
+	case typed.SetVariable != nil:
+		return Action{
+			Kind:      ActionSetVariable,
+			Variable:  typed.SetVariable.VariableName,
+			Operation: typed.SetVariable.Operation,
+			Value:     typed.SetVariable.Value,
+		}, nil
+	}
+	return Action{}, fmt.Errorf("unknown action %v", raw)
+}
+
+// applySetVariable applies a SetVariable action to a variable map.
+func applySetVariable(vars map[string]int, action Action) error {
+	switch action.Operation {
+	case "set to":
+		vars[action.Variable] = action.Value
+	case "unset":
+		delete(vars, action.Variable)
+	case "increase by":
+		vars[action.Variable] += action.Value
+	case "decrease by":
+		vars[action.Variable] -= action.Value
+	default:
+		return fmt.Errorf("unknown SetVariable operation %q", action.Operation)
+	}
+	return nil
+}
+
+// remarshal converts loosely typed YAML/JSON data into a typed value by
+// round-tripping it through encoding/json.
+func remarshal(in interface{}, out interface{}) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,263 @@
+package runtime
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+// ConditionKind names one of the condition types defined in schemas/quest.json.
+type ConditionKind string
+
+// Condition kinds supported by the evaluator.
+const (
+	ConditionQuestCompleted       ConditionKind = "QuestCompleted"
+	ConditionResourceAvailability ConditionKind = "ResourceAvailability"
+	ConditionFactionStanding      ConditionKind = "FactionStanding"
+	ConditionTimePassed           ConditionKind = "TimePassed"
+	ConditionItemLost             ConditionKind = "ItemLost"
+	ConditionInventory            ConditionKind = "Inventory"
+	ConditionVariable             ConditionKind = "Variable"
+	ConditionEventTriggered       ConditionKind = "EventTriggered"
+	ConditionItemUsedOnObject     ConditionKind = "ItemUsedOnObject"
+	ConditionItemUsedOnNPC        ConditionKind = "ItemUsedOnNPC"
+)
+
+// InventoryEntry is one requirement of an Inventory condition.
+type InventoryEntry struct {
+	Type      string `json:"Type"`
+	MinCount  int    `json:"MinCount"`
+	QuestItem bool   `json:"QuestItem"`
+}
+
+// Condition is the typed form of a domain.Condition. Only the fields
+// relevant for the given Kind are set.
+type Condition struct {
+	Kind ConditionKind
+
+	// Quest is set for QuestCompleted.
+	Quest string
+	// Resource and Available are set for ResourceAvailability.
+	Resource  string
+	Available bool
+	// Faction, MinimumLevel and MaximumLevel are set for FactionStanding.
+	// A nil bound is not checked.
+	Faction      string
+	MinimumLevel *int
+	MaximumLevel *int
+	// Hours is set for TimePassed.
+	Hours int
+	// Inventory is set for Inventory.
+	Inventory []InventoryEntry
+	// Variable, Comparison and Value are set for Variable.
+	Variable   string
+	Comparison string
+	Value      int
+	// Happening and Count are set for ItemLost, EventTriggered,
+	// ItemUsedOnObject and ItemUsedOnNPC.
+	Happening Happening
+	Count     int
+}
+
+// ParseCondition converts an untyped quest condition into a Condition.
+func ParseCondition(raw domain.Condition) (Condition, error) {
+	var typed struct {
+		QuestCompleted       *string `json:"QuestCompleted"`
+		ResourceAvailability *struct {
+			Resource  string `json:"Resource"`
+			Available bool   `json:"Available"`
+		} `json:"ResourceAvailability"`
+		FactionStanding *struct {
+			Faction      string `json:"Faction"`
+			MinimumLevel *int   `json:"MinimumLevel"`
+			MaximumLevel *int   `json:"MaximumLevel"`
+		} `json:"FactionStanding"`
+		TimePassed *string          `json:"TimePassed"`
+		ItemLost   *string          `json:"ItemLost"`
+		Inventory  []InventoryEntry `json:"Inventory"`
+		Variable   *struct {
+			VariableName string `json:"VariableName"`
+			Comparison   string `json:"Comparison"`
+			Value        int    `json:"Value"`
+		} `json:"Variable"`
+		EventTriggered *struct {
+			Event string `json:"Event"`
+			Count int    `json:"Count"`
+		} `json:"EventTriggered"`
+		ItemUsedOnObject *struct {
+			Item   string `json:"Item"`
+			Object string `json:"Object"`
+		} `json:"ItemUsedOnObject"`
+		ItemUsedOnNPC *struct {
+			Item string `json:"Item"`
+			NPC  string `json:"NPC"`
+		} `json:"ItemUsedOnNPC"`
+	}
+	if err := remarshal(raw, &typed); err != nil {
+		return Condition{}, fmt.Errorf("malformed condition: %w", err)
+	}
+
+	switch {
+	case typed.QuestCompleted != nil:
+		return Condition{Kind: ConditionQuestCompleted, Quest: *typed.QuestCompleted}, nil
+	case typed.ResourceAvailability != nil:
+		ra := typed.ResourceAvailability
+		return Condition{Kind: ConditionResourceAvailability, Resource: ra.Resource, Available: ra.Available}, nil
+	case typed.FactionStanding != nil:
+		fs := typed.FactionStanding
+		return Condition{Kind: ConditionFactionStanding, Faction: fs.Faction, MinimumLevel: fs.MinimumLevel, MaximumLevel: fs.MaximumLevel}, nil
+	case typed.TimePassed != nil:
+		hours, err := ParseTimePassed(*typed.TimePassed)
+		if err != nil {
+			return Condition{}, err
+		}
+		return Condition{Kind: ConditionTimePassed, Hours: hours}, nil
+	case typed.ItemLost != nil:
+		return Condition{Kind: ConditionItemLost, Happening: ItemLost(*typed.ItemLost), Count: 1}, nil
+	case typed.Inventory != nil:
+		for i := range typed.Inventory {
+			if typed.Inventory[i].MinCount < 1 {
+				typed.Inventory[i].MinCount = 1
+			}
+		}
+		return Condition{Kind: ConditionInventory, Inventory: typed.Inventory}, nil
+	case typed.Variable != nil:
+		v := typed.Variable
+		return Condition{Kind: ConditionVariable, Variable: v.VariableName, Comparison: v.Comparison, Value: v.Value}, nil
+	case typed.EventTriggered != nil:
+		et := typed.EventTriggered
+		return Condition{Kind: ConditionEventTriggered, Happening: EventTriggered(et.Event), Count: et.Count}, nil
+	case typed.ItemUsedOnObject != nil:
+		iu := typed.ItemUsedOnObject
+		return Condition{Kind: ConditionItemUsedOnObject, Happening: ItemUsedOnObject(iu.Item, iu.Object), Count: 1}, nil
+	case typed.ItemUsedOnNPC != nil:
+		iu := typed.ItemUsedOnNPC
+		return Condition{Kind: ConditionItemUsedOnNPC, Happening: ItemUsedOnNPC(iu.Item, iu.NPC), Count: 1}, nil
+	}
+	return Condition{}, fmt.Errorf("unknown condition %v", raw)
+}
+
+// hoursPerUnit maps the units of a TimePassed condition to game hours.
+// Months and years are counted as 30 and 365 days.
+var hoursPerUnit = map[byte]int{
+	'h': 1,
+	'd': 24,
+	'w': 7 * 24,
+	'M': 30 * 24,
+	'y': 365 * 24,
+}
+
+// ParseTimePassed converts a TimePassed value such as "36h" or "2w" into hours.
+func ParseTimePassed(s string) (int, error) {
+	if len(s) < 2 {
+		return 0, fmt.Errorf("invalid TimePassed value %q", s)
+	}
+	perUnit, ok := hoursPerUnit[s[len(s)-1]]
+	if !ok {
+		return 0, fmt.Errorf("invalid TimePassed unit in %q", s)
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid TimePassed amount in %q", s)
+	}
+	return n * perUnit, nil
+}
+
+// RequiredCount returns how many of total conditions must hold for a
+// ConditionsRequired value, which is "all", a positive number, or empty.
+func RequiredCount(required string, total int) (int, error) {
+	if required == "" || required == "all" {
+		return total, nil
+	}
+	n, err := strconv.Atoi(required)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid ConditionsRequired value %q", required)
+	}
+	return n, nil
+}
+
+// WorldEvaluator evaluates conditions against a WorldState and the
+// progress of the node being evaluated. Variables set by the quest take
+// precedence over variables of the world.
+type WorldEvaluator struct {
+	world WorldState
+}
+
+// NewWorldEvaluator creates an evaluator for the given world.
+func NewWorldEvaluator(world WorldState) *WorldEvaluator {
+	return &WorldEvaluator{world: world}
+}
+
+// Evaluate reports whether at least the required number of conditions
+// hold. Malformed conditions never hold.
+func (e *WorldEvaluator) Evaluate(ctx Context, conditions []domain.Condition, required string) bool {
+	need, err := RequiredCount(required, len(conditions))
+	if err != nil {
+		return false
+	}
+	met := 0
+	for _, raw := range conditions {
+		cond, err := ParseCondition(raw)
+		if err == nil && e.holds(ctx, cond) {
+			met++
+		}
+	}
+	return met >= need
+}
+
+func (e *WorldEvaluator) holds(ctx Context, cond Condition) bool {
+	switch cond.Kind {
+	case ConditionQuestCompleted:
+		return e.world.QuestCompleted(cond.Quest)
+
+	case ConditionResourceAvailability:
+		return e.world.ResourceAvailable(cond.Resource) == cond.Available
+
+	case ConditionFactionStanding:
+		level := e.world.FactionStanding(cond.Faction)
+		if cond.MinimumLevel != nil && level < *cond.MinimumLevel {
+			return false
+		}
+		if cond.MaximumLevel != nil && level > *cond.MaximumLevel {
+			return false
+		}
+		return true
+
+	case ConditionTimePassed:
+		return ctx.Progress != nil && ctx.Progress.Hours >= cond.Hours
+
+	case ConditionInventory:
+		for _, entry := range cond.Inventory {
+			if e.world.ItemCount(entry.Type, entry.QuestItem) < entry.MinCount {
+				return false
+			}
+		}
+		return true
+
+	case ConditionVariable:
+		value, ok := ctx.Variables[cond.Variable]
+		if !ok {
+			value, _ = e.world.Variable(cond.Variable)
+		}
+		return compare(value, cond.Comparison, cond.Value)
+
+	case ConditionItemLost, ConditionEventTriggered, ConditionItemUsedOnObject, ConditionItemUsedOnNPC:
+		return ctx.Progress != nil && ctx.Progress.Events[cond.Happening.Key()] >= cond.Count
+	}
+	return false
+}
+
+func compare(have int, comparison string, want int) bool {
+	switch comparison {
+	case "equal":
+		return have == want
+	case "not equal":
+		return have != want
+	case "greater than":
+		return have > want
+	case "smaller than":
+		return have < want
+	}
+	return false
+}
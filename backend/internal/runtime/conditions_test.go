@@ -0,0 +1,192 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+func TestWorldEvaluator_StateConditions(t *testing.T) {
+	world := NewMemoryWorld()
+	world.CompletedQuests["CourierQuest"] = true
+	world.Resources["Forge"] = true
+	world.Factions["Town"] = 5
+	world.Items["Horseshoes"] = 4
+	world.QuestItems["PackOfNails"] = 1
+	world.Variables["Visits"] = 3
+	eval := NewWorldEvaluator(world)
+
+	tests := []struct {
+		name string
+		cond domain.Condition
+		want bool
+	}{
+		{"quest completed", domain.Condition{"QuestCompleted": "CourierQuest"}, true},
+		{"quest not completed", domain.Condition{"QuestCompleted": "Other"}, false},
+		{"resource available", domain.Condition{"ResourceAvailability": map[string]interface{}{"Resource": "Forge", "Available": true}}, true},
+		{"resource unavailable", domain.Condition{"ResourceAvailability": map[string]interface{}{"Resource": "Mine", "Available": false}}, true},
+		{"faction in range", domain.Condition{"FactionStanding": map[string]interface{}{"Faction": "Town", "MinimumLevel": 5, "MaximumLevel": 10}}, true},
+		{"faction too low", domain.Condition{"FactionStanding": map[string]interface{}{"Faction": "Town", "MinimumLevel": 6}}, false},
+		{"faction too high", domain.Condition{"FactionStanding": map[string]interface{}{"Faction": "Town", "MaximumLevel": 4}}, false},
+		{"inventory", domain.Condition{"Inventory": []interface{}{map[string]interface{}{"Type": "Horseshoes", "MinCount": 4}}}, true},
+		{"inventory too few", domain.Condition{"Inventory": []interface{}{map[string]interface{}{"Type": "Horseshoes", "MinCount": 5}}}, false},
+		{"quest item", domain.Condition{"Inventory": []interface{}{map[string]interface{}{"Type": "PackOfNails", "QuestItem": true}}}, true},
+		{"not a quest item", domain.Condition{"Inventory": []interface{}{map[string]interface{}{"Type": "Horseshoes", "QuestItem": true}}}, false},
+		{"variable greater", domain.Condition{"Variable": map[string]interface{}{"VariableName": "Visits", "Comparison": "greater than", "Value": 2}}, true},
+		{"variable unset", domain.Condition{"Variable": map[string]interface{}{"VariableName": "Other", "Comparison": "equal", "Value": 0}}, true},
+		{"unknown condition", domain.Condition{"Weather": "rain"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := eval.Evaluate(Context{}, []domain.Condition{tt.cond}, "all"); got != tt.want {
+				t.Errorf("Evaluate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorldEvaluator_QuestVariablesTakePrecedence(t *testing.T) {
+	world := NewMemoryWorld()
+	world.Variables["Visits"] = 1
+	eval := NewWorldEvaluator(world)
+	cond := []domain.Condition{{"Variable": map[string]interface{}{"VariableName": "Visits", "Comparison": "equal", "Value": 2}}}
+
+	if eval.Evaluate(Context{}, cond, "all") {
+		t.Error("expected world variable to be used without quest variables")
+	}
+	if !eval.Evaluate(Context{Variables: map[string]int{"Visits": 2}}, cond, "all") {
+		t.Error("expected quest variable to take precedence")
+	}
+}
+
+func TestWorldEvaluator_ConditionsRequired(t *testing.T) {
+	eval := NewWorldEvaluator(NewMemoryWorld())
+	conds := []domain.Condition{
+		{"QuestCompleted": "Other"},
+		{"ResourceAvailability": map[string]interface{}{"Resource": "Mine", "Available": false}},
+		{"Variable": map[string]interface{}{"VariableName": "X", "Comparison": "equal", "Value": 0}},
+	}
+
+	for required, want := range map[string]bool{"all": false, "": false, "1": true, "2": true, "3": false, "x": false} {
+		if got := eval.Evaluate(Context{}, conds, required); got != want {
+			t.Errorf("Evaluate with required %q = %v, want %v", required, got, want)
+		}
+	}
+}
+
+func TestEngine_WatcherWaitsForHappenings(t *testing.T) {
+	quest := &domain.Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "ConditionWatcher", NextNodes: []int{2}, Conditions: []domain.Condition{
+				{"TimePassed": "1d"},
+				{"EventTriggered": map[string]interface{}{"Event": "Storm", "Count": 2}},
+				{"ItemUsedOnNPC": map[string]interface{}{"Item": "Horseshoes", "NPC": "NPC:Smith"}},
+			}},
+			{NodeID: 2, NodeType: "Actions", Actions: []domain.Action{"CompleteQuest"}},
+		},
+	}
+	engine := startEngine(t, quest, NewWorldEvaluator(NewMemoryWorld()))
+
+	steps := []func() error{
+		func() error { return engine.PassTime(23) },
+		func() error { return engine.Notify(EventTriggered("Storm")) },
+		func() error { return engine.Notify(ItemUsedOnNPC("Horseshoes", "NPC:Smith")) },
+		func() error { return engine.Notify(EventTriggered("Storm")) },
+		func() error { return engine.Notify(ItemUsedOnObject("Horseshoes", "Anvil")) },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %d failed: %v", i, err)
+		}
+		if engine.Status() != StatusActive {
+			t.Fatalf("quest ended early at step %d", i)
+		}
+	}
+	progress, ok := engine.Progress(1)
+	if !ok || progress.Hours != 23 || progress.Events[EventTriggered("Storm").Key()] != 2 {
+		t.Errorf("unexpected progress %+v", progress)
+	}
+
+	if err := engine.PassTime(1); err != nil {
+		t.Fatalf("PassTime failed: %v", err)
+	}
+	if engine.Status() != StatusCompleted {
+		t.Errorf("expected status Completed, got %s", engine.Status())
+	}
+}
+
+func TestEngine_HappeningsBeforeActivationDoNotCount(t *testing.T) {
+	quest := &domain.Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Dialog", NextNodes: []int{2}},
+			{NodeID: 2, NodeType: "ConditionWatcher", NextNodes: []int{3}, Conditions: []domain.Condition{{"ItemLost": "Horseshoes"}}},
+			{NodeID: 3, NodeType: "Actions", Actions: []domain.Action{"FailQuest"}},
+		},
+	}
+	engine := startEngine(t, quest, NewWorldEvaluator(NewMemoryWorld()))
+
+	if err := engine.Notify(ItemLost("Horseshoes")); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if err := engine.Talk(1); err != nil {
+		t.Fatalf("Talk failed: %v", err)
+	}
+	if engine.Status() != StatusActive {
+		t.Fatalf("expected earlier happening to be ignored, got %s", engine.Status())
+	}
+	if err := engine.Notify(ItemLost("Horseshoes")); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if engine.Status() != StatusFailed {
+		t.Errorf("expected status Failed, got %s", engine.Status())
+	}
+}
+
+func TestMemoryWorld_HandleAction(t *testing.T) {
+	quest := &domain.Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Actions", NextNodes: []int{2}, Actions: []domain.Action{
+				map[string]interface{}{"ItemsGained": []interface{}{map[string]interface{}{"Type": "PackOfNails", "Count": 2, "QuestItem": true}}},
+				map[string]interface{}{"FactionStanding": map[string]interface{}{"Faction": "Town", "Points": 3}},
+			}},
+			{NodeID: 2, NodeType: "ConditionBranch", NextNodesIfTrue: []int{3}, NextNodesIfFalse: []int{4}, Conditions: []domain.Condition{
+				{"Inventory": []interface{}{map[string]interface{}{"Type": "PackOfNails", "MinCount": 2, "QuestItem": true}}},
+				{"FactionStanding": map[string]interface{}{"Faction": "Town", "MinimumLevel": 3}},
+			}},
+			{NodeID: 3, NodeType: "Actions", Actions: []domain.Action{"CompleteQuest"}},
+			{NodeID: 4, NodeType: "Actions", Actions: []domain.Action{"FailQuest"}},
+		},
+	}
+	world := NewMemoryWorld()
+	engine, err := NewEngine(quest, NewWorldEvaluator(world), world)
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+	if err := engine.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if engine.Status() != StatusCompleted {
+		t.Errorf("expected status Completed, got %s", engine.Status())
+	}
+}
+
+func TestParseTimePassed(t *testing.T) {
+	tests := map[string]int{"36h": 36, "1d": 24, "2w": 336, "1M": 720, "1y": 8760}
+	for input, want := range tests {
+		got, err := ParseTimePassed(input)
+		if err != nil || got != want {
+			t.Errorf("ParseTimePassed(%q) = %d, %v; want %d", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"", "h", "0d", "3x", "-1h"} {
+		if _, err := ParseTimePassed(input); err == nil {
+			t.Errorf("expected ParseTimePassed(%q) to fail", input)
+		}
+	}
+}
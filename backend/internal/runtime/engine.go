@@ -0,0 +1,449 @@
+// Package runtime executes quests the way the game does. It serves as the
+// reference implementation of quest semantics: multiple active nodes,
+// waiting ConditionWatchers, branching, player decisions and actions.
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+// Sentinel errors returned by Engine operations.
+var (
+	// ErrNotActive is returned when interacting with a node that is not active.
+	ErrNotActive = errors.New("node is not active")
+
+	// ErrUnavailable is returned when a node or option cannot be used right now.
+	ErrUnavailable = errors.New("not available")
+
+	// ErrQuestEnded is returned when interacting with a quest that has terminated.
+	ErrQuestEnded = errors.New("quest has ended")
+
+	// ErrNoProgress is returned when pass-through nodes keep activating each
+	// other in a cycle, so that the quest never waits for the player or the
+	// world.
+	ErrNoProgress = errors.New("quest cycles without waiting")
+)
+
+// Status describes where a quest stands in its lifecycle.
+type Status string
+
+// Quest statuses.
+const (
+	StatusInactive  Status = "Inactive"
+	StatusActive    Status = "Active"
+	StatusCompleted Status = "Completed"
+	StatusFailed    Status = "Failed"
+	StatusDeclined  Status = "Declined"
+)
+
+// Context describes the node whose conditions are being evaluated.
+type Context struct {
+	QuestID string
+	NodeID  int
+	// Variables holds the quest variables set via SetVariable.
+	Variables map[string]int
+	// Progress records time and happenings since the node became active.
+	// It must not be modified.
+	Progress *Progress
+}
+
+// ConditionEvaluator decides whether a list of conditions holds.
+// required is either "all", a number of conditions that must hold,
+// or empty (meaning "all").
+type ConditionEvaluator interface {
+	Evaluate(ctx Context, conditions []domain.Condition, required string) bool
+}
+
+// EvaluatorFunc adapts a plain function to the ConditionEvaluator interface.
+type EvaluatorFunc func(ctx Context, conditions []domain.Condition, required string) bool
+
+// Evaluate calls f.
+func (f EvaluatorFunc) Evaluate(ctx Context, conditions []domain.Condition, required string) bool {
+	return f(ctx, conditions, required)
+}
+
+// ActionHandler receives every action the engine executes, so the game
+// can apply rewards, punishments and other effects to the world.
+type ActionHandler interface {
+	HandleAction(nodeID int, action Action)
+}
+
+// Step records one node the engine has left, and how it was left.
+type Step struct {
+	NodeID int
+	// Option is the 1-based Decision option chosen, or 0.
+	Option int
+	// Branch is "true" or "false" for ConditionBranch nodes, otherwise empty.
+	Branch string
+}
+
+// Engine drives a single quest as a state machine with multiple active nodes.
+//
+// EntryPoint, Actions and ConditionBranch nodes are passed through
+// immediately. ConditionWatcher nodes wait until their conditions hold.
+// Dialog and Decision nodes wait for the player (see Talk and Choose).
+// A terminal action ends the quest and deactivates all other nodes.
+type Engine struct {
+	quest     *domain.Quest
+	nodes     map[int]*domain.QuestNode
+	evaluator ConditionEvaluator
+	handler   ActionHandler
+
+	status    Status
+	accepted  bool
+	active    map[int]bool
+	progress  map[int]*Progress
+	variables map[string]int
+	journal   []domain.I18nString
+	stage     *domain.I18nString
+	history   []Step
+}
+
+// NewEngine creates an engine for the given quest. handler may be nil.
+func NewEngine(quest *domain.Quest, evaluator ConditionEvaluator, handler ActionHandler) (*Engine, error) {
+	nodes := make(map[int]*domain.QuestNode, len(quest.QuestNodes))
+	for i := range quest.QuestNodes {
+		node := &quest.QuestNodes[i]
+		if _, exists := nodes[node.NodeID]; exists {
+			return nil, fmt.Errorf("%w: duplicate NodeID %d", domain.ErrInvalidInput, node.NodeID)
+		}
+		nodes[node.NodeID] = node
+	}
+
+	return &Engine{
+		quest:     quest,
+		nodes:     nodes,
+		evaluator: evaluator,
+		handler:   handler,
+		status:    StatusInactive,
+		active:    make(map[int]bool),
+		progress:  make(map[int]*Progress),
+		variables: make(map[string]int),
+	}, nil
+}
+
+// Start activates every EntryPoint node and runs the quest until it waits
+// for the player or the world.
+func (e *Engine) Start() error {
+	if e.status != StatusInactive {
+		return fmt.Errorf("quest %s already started", e.quest.QuestID)
+	}
+	e.status = StatusActive
+	for _, node := range e.quest.QuestNodes {
+		if node.NodeType == "EntryPoint" {
+			e.activate(node.NodeID)
+		}
+	}
+	return e.settle()
+}
+
+// Update re-evaluates all waiting nodes. Call it whenever the world changed.
+func (e *Engine) Update() error {
+	if e.status != StatusActive {
+		return nil
+	}
+	return e.settle()
+}
+
+// PassTime advances the game clock of every active node by the given
+// number of hours and re-evaluates the quest.
+func (e *Engine) PassTime(hours int) error {
+	if e.status != StatusActive {
+		return nil
+	}
+	for id := range e.active {
+		e.progress[id].Hours += hours
+	}
+	return e.settle()
+}
+
+// Notify records a happening for every active node and re-evaluates the quest.
+func (e *Engine) Notify(h Happening) error {
+	if e.status != StatusActive {
+		return nil
+	}
+	key := h.Key()
+	for id := range e.active {
+		e.progress[id].Events[key]++
+	}
+	return e.settle()
+}
+
+// Status returns the current quest status.
+func (e *Engine) Status() Status {
+	return e.status
+}
+
+// Accepted reports whether an AcceptQuest action has been executed.
+func (e *Engine) Accepted() bool {
+	return e.accepted
+}
+
+// Active returns the IDs of all active nodes in ascending order.
+func (e *Engine) Active() []int {
+	ids := make([]int, 0, len(e.active))
+	for id := range e.active {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// IsActive reports whether the given node is active.
+func (e *Engine) IsActive(nodeID int) bool {
+	return e.active[nodeID]
+}
+
+// Node returns the quest node with the given ID, or nil.
+func (e *Engine) Node(nodeID int) *domain.QuestNode {
+	return e.nodes[nodeID]
+}
+
+// Progress returns a copy of the progress of an active node.
+func (e *Engine) Progress(nodeID int) (Progress, bool) {
+	p, ok := e.progress[nodeID]
+	if !ok {
+		return Progress{}, false
+	}
+	events := make(map[string]int, len(p.Events))
+	for key, count := range p.Events {
+		events[key] = count
+	}
+	return Progress{Hours: p.Hours, Events: events}, true
+}
+
+// Variables returns a copy of the quest variables.
+func (e *Engine) Variables() map[string]int {
+	vars := make(map[string]int, len(e.variables))
+	for name, value := range e.variables {
+		vars[name] = value
+	}
+	return vars
+}
+
+// Journal returns the journal entries written so far, oldest first.
+func (e *Engine) Journal() []domain.I18nString {
+	return append([]domain.I18nString(nil), e.journal...)
+}
+
+// StageDescription returns the most recent quest stage description, or nil.
+func (e *Engine) StageDescription() *domain.I18nString {
+	return e.stage
+}
+
+// History returns the nodes the engine has left so far, in order.
+func (e *Engine) History() []Step {
+	return append([]Step(nil), e.history...)
+}
+
+// AvailableOptions returns the 1-based indices of the options of an active
+// Decision node whose conditions currently hold.
+func (e *Engine) AvailableOptions(nodeID int) ([]int, error) {
+	node, err := e.interactiveNode(nodeID, "Decision")
+	if err != nil {
+		return nil, err
+	}
+	var options []int
+	for i, opt := range node.Options {
+		if len(opt.Conditions) == 0 || e.evaluate(nodeID, opt.Conditions, "all") {
+			options = append(options, i+1)
+		}
+	}
+	return options, nil
+}
+
+// Choose selects a 1-based option of an active Decision node.
+func (e *Engine) Choose(nodeID, option int) error {
+	available, err := e.AvailableOptions(nodeID)
+	if err != nil {
+		return err
+	}
+	for _, o := range available {
+		if o == option {
+			node := e.nodes[nodeID]
+			e.leave(Step{NodeID: nodeID, Option: option}, node.Options[option-1].NextNodes)
+			return e.settle()
+		}
+	}
+	return fmt.Errorf("%w: option %d of node %d", ErrUnavailable, option, nodeID)
+}
+
+// CanTalk reports whether the conversation of an active Dialog node can
+// take place, i.e. whether the node's conditions hold.
+func (e *Engine) CanTalk(nodeID int) bool {
+	node, err := e.interactiveNode(nodeID, "Dialog")
+	if err != nil {
+		return false
+	}
+	return len(node.Conditions) == 0 || e.evaluate(nodeID, node.Conditions, node.ConditionsRequired)
+}
+
+// Talk conducts the conversation of an active Dialog node and continues
+// with its NextNodes.
+func (e *Engine) Talk(nodeID int) error {
+	if _, err := e.interactiveNode(nodeID, "Dialog"); err != nil {
+		return err
+	}
+	if !e.CanTalk(nodeID) {
+		return fmt.Errorf("%w: conditions of node %d do not hold", ErrUnavailable, nodeID)
+	}
+	e.leave(Step{NodeID: nodeID}, e.nodes[nodeID].NextNodes)
+	return e.settle()
+}
+
+func (e *Engine) interactiveNode(nodeID int, nodeType string) (*domain.QuestNode, error) {
+	if e.status != StatusActive {
+		return nil, ErrQuestEnded
+	}
+	if !e.active[nodeID] {
+		return nil, fmt.Errorf("%w: %d", ErrNotActive, nodeID)
+	}
+	node := e.nodes[nodeID]
+	if node.NodeType != nodeType {
+		return nil, fmt.Errorf("%w: node %d is a %s node, not %s", ErrUnavailable, nodeID, node.NodeType, nodeType)
+	}
+	return node, nil
+}
+
+// settle advances all nodes that do not need to wait, until nothing changes.
+// Each round advances every active node by one step, so a quest without
+// cycles settles within len(nodes) rounds. The rounds are capped at
+// len(nodes)² in case a cycle of pass-through nodes never waits.
+func (e *Engine) settle() error {
+	maxRounds := len(e.nodes)*len(e.nodes) + 1
+	for round := 0; e.status == StatusActive; round++ {
+		if round == maxRounds {
+			return fmt.Errorf("%w: nodes %v are still advancing after %d rounds", ErrNoProgress, e.Active(), maxRounds)
+		}
+		progressed := false
+		for _, id := range e.Active() {
+			if !e.active[id] {
+				continue
+			}
+			advanced, err := e.advance(e.nodes[id])
+			if err != nil {
+				return err
+			}
+			if e.status != StatusActive {
+				return nil
+			}
+			progressed = progressed || advanced
+		}
+		if !progressed {
+			return nil
+		}
+	}
+	return nil
+}
+
+// advance moves past a single active node if it does not need to wait.
+func (e *Engine) advance(node *domain.QuestNode) (bool, error) {
+	switch node.NodeType {
+	case "EntryPoint":
+		e.leave(Step{NodeID: node.NodeID}, node.NextNodes)
+		return true, nil
+
+	case "Actions":
+		return true, e.executeActions(node)
+
+	case "ConditionBranch":
+		if e.evaluate(node.NodeID, node.Conditions, node.ConditionsRequired) {
+			e.leave(Step{NodeID: node.NodeID, Branch: "true"}, node.NextNodesIfTrue)
+		} else {
+			e.leave(Step{NodeID: node.NodeID, Branch: "false"}, node.NextNodesIfFalse)
+		}
+		return true, nil
+
+	case "ConditionWatcher":
+		if !e.evaluate(node.NodeID, node.Conditions, node.ConditionsRequired) {
+			return false, nil
+		}
+		e.leave(Step{NodeID: node.NodeID}, node.NextNodes)
+		return true, nil
+	}
+
+	// Dialog and Decision nodes wait for the player.
+	return false, nil
+}
+
+func (e *Engine) executeActions(node *domain.QuestNode) error {
+	var terminal ActionKind
+	for _, raw := range node.Actions {
+		action, err := ParseAction(raw)
+		if err != nil {
+			return fmt.Errorf("node %d: %w", node.NodeID, err)
+		}
+
+		switch action.Kind {
+		case ActionAcceptQuest:
+			e.accepted = true
+		case ActionJournalEntry:
+			e.journal = append(e.journal, action.Text)
+		case ActionQuestStageDescription:
+			text := action.Text
+			e.stage = &text
+		case ActionSetVariable:
+			if err := applySetVariable(e.variables, action); err != nil {
+				return fmt.Errorf("node %d: %w", node.NodeID, err)
+			}
+		}
+		if action.IsTerminal() {
+			terminal = action.Kind
+		}
+
+		if e.handler != nil {
+			e.handler.HandleAction(node.NodeID, action)
+		}
+	}
+
+	if terminal == "" {
+		e.leave(Step{NodeID: node.NodeID}, node.NextNodes)
+		return nil
+	}
+
+	e.history = append(e.history, Step{NodeID: node.NodeID})
+	e.active = make(map[int]bool)
+	e.progress = make(map[int]*Progress)
+	switch terminal {
+	case ActionCompleteQuest:
+		e.status = StatusCompleted
+	case ActionFailQuest:
+		e.status = StatusFailed
+	case ActionDeclineQuest:
+		e.status = StatusDeclined
+	}
+	return nil
+}
+
+// leave deactivates a node and activates its successors.
+func (e *Engine) leave(step Step, next []int) {
+	delete(e.active, step.NodeID)
+	delete(e.progress, step.NodeID)
+	e.history = append(e.history, step)
+	for _, id := range next {
+		e.activate(id)
+	}
+}
+
+// activate makes a node active. Progress of an already active node is kept.
+func (e *Engine) activate(nodeID int) {
+	if _, exists := e.nodes[nodeID]; !exists || e.active[nodeID] {
+		return
+	}
+	e.active[nodeID] = true
+	e.progress[nodeID] = newProgress()
+}
+
+func (e *Engine) evaluate(nodeID int, conditions []domain.Condition, required string) bool {
+	ctx := Context{
+		QuestID:   e.quest.QuestID,
+		NodeID:    nodeID,
+		Variables: e.Variables(),
+		Progress:  e.progress[nodeID],
+	}
+	return e.evaluator.Evaluate(ctx, conditions, required)
+}
@@ -0,0 +1,381 @@
+package runtime
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+// flagEvaluator treats every condition of the form {"Flag": name} as true
+// if the flag is set. All conditions must hold.
+type flagEvaluator map[string]bool
+
+func (f flagEvaluator) Evaluate(ctx Context, conditions []domain.Condition, required string) bool {
+	for _, cond := range conditions {
+		name, _ := cond["Flag"].(string)
+		if !f[name] {
+			return false
+		}
+	}
+	return true
+}
+
+func flag(name string) []domain.Condition {
+	return []domain.Condition{{"Flag": name}}
+}
+
+// recordingHandler collects all actions passed to it.
+type recordingHandler struct {
+	actions []Action
+}
+
+func (h *recordingHandler) HandleAction(nodeID int, action Action) {
+	h.actions = append(h.actions, action)
+}
+
+func journal(text string) domain.Action {
+	return map[string]interface{}{"JournalEntry": map[string]interface{}{"en-US": text, "de-DE": text}}
+}
+
+func startEngine(t *testing.T, quest *domain.Quest, eval ConditionEvaluator) *Engine {
+	t.Helper()
+	engine, err := NewEngine(quest, eval, nil)
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+	if err := engine.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	return engine
+}
+
+func TestEngine_StartWaitsAtConditionWatcher(t *testing.T) {
+	quest := &domain.Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "ConditionWatcher", NextNodes: []int{2}, Conditions: flag("ready")},
+			{NodeID: 2, NodeType: "Actions", Actions: []domain.Action{"CompleteQuest"}},
+		},
+	}
+	flags := flagEvaluator{}
+	engine := startEngine(t, quest, flags)
+
+	if got := engine.Active(); !reflect.DeepEqual(got, []int{1}) {
+		t.Fatalf("expected node 1 to be active, got %v", got)
+	}
+	if engine.Status() != StatusActive {
+		t.Fatalf("expected status Active, got %s", engine.Status())
+	}
+
+	flags["ready"] = true
+	if err := engine.Update(); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if engine.Status() != StatusCompleted {
+		t.Errorf("expected status Completed, got %s", engine.Status())
+	}
+	if len(engine.Active()) != 0 {
+		t.Errorf("expected no active nodes, got %v", engine.Active())
+	}
+}
+
+func TestEngine_MultipleEntryPointsAreActiveInParallel(t *testing.T) {
+	quest := &domain.Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "ConditionWatcher", NextNodes: []int{4}, Conditions: flag("a")},
+			{NodeID: 2, NodeType: "EntryPoint", NextNodes: []int{3}},
+			{NodeID: 3, NodeType: "ConditionWatcher", NextNodes: []int{4}, Conditions: flag("b")},
+			{NodeID: 4, NodeType: "Actions", Actions: []domain.Action{"CompleteQuest"}},
+		},
+	}
+	engine := startEngine(t, quest, flagEvaluator{})
+
+	if got := engine.Active(); !reflect.DeepEqual(got, []int{1, 3}) {
+		t.Errorf("expected nodes 1 and 3 to be active, got %v", got)
+	}
+}
+
+func TestEngine_ConditionBranchRouting(t *testing.T) {
+	quest := func() *domain.Quest {
+		return &domain.Quest{
+			QuestID: "TestQuest",
+			QuestNodes: []domain.QuestNode{
+				{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+				{NodeID: 1, NodeType: "ConditionBranch", Conditions: flag("rich"), NextNodesIfTrue: []int{2}, NextNodesIfFalse: []int{3}},
+				{NodeID: 2, NodeType: "Actions", Actions: []domain.Action{"CompleteQuest"}},
+				{NodeID: 3, NodeType: "Actions", Actions: []domain.Action{"FailQuest"}},
+			},
+		}
+	}
+
+	engine := startEngine(t, quest(), flagEvaluator{"rich": true})
+	if engine.Status() != StatusCompleted {
+		t.Errorf("expected true branch to complete the quest, got %s", engine.Status())
+	}
+
+	engine = startEngine(t, quest(), flagEvaluator{})
+	if engine.Status() != StatusFailed {
+		t.Errorf("expected false branch to fail the quest, got %s", engine.Status())
+	}
+
+	history := engine.History()
+	if history[1].NodeID != 1 || history[1].Branch != "false" {
+		t.Errorf("expected history to record the false branch, got %+v", history)
+	}
+}
+
+func TestEngine_DecisionOptionsRespectConditions(t *testing.T) {
+	quest := &domain.Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Decision", Options: []domain.DialogOption{
+				{NextNodes: []int{2}},
+				{NextNodes: []int{3}, Conditions: flag("guild")},
+			}},
+			{NodeID: 2, NodeType: "Actions", Actions: []domain.Action{"AcceptQuest", "CompleteQuest"}},
+			{NodeID: 3, NodeType: "Actions", Actions: []domain.Action{"DeclineQuest"}},
+		},
+	}
+	engine := startEngine(t, quest, flagEvaluator{})
+
+	options, err := engine.AvailableOptions(1)
+	if err != nil {
+		t.Fatalf("AvailableOptions failed: %v", err)
+	}
+	if !reflect.DeepEqual(options, []int{1}) {
+		t.Fatalf("expected only option 1 to be available, got %v", options)
+	}
+
+	if err := engine.Choose(1, 2); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable for hidden option, got %v", err)
+	}
+	if err := engine.Choose(1, 1); err != nil {
+		t.Fatalf("Choose failed: %v", err)
+	}
+	if engine.Status() != StatusCompleted || !engine.Accepted() {
+		t.Errorf("expected accepted and completed quest, got %s (accepted=%v)", engine.Status(), engine.Accepted())
+	}
+	if err := engine.Choose(1, 1); !errors.Is(err, ErrQuestEnded) {
+		t.Errorf("expected ErrQuestEnded after termination, got %v", err)
+	}
+}
+
+func TestEngine_DialogWaitsForConditionsAndPlayer(t *testing.T) {
+	quest := &domain.Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Dialog", Conditions: flag("has_nails"), NextNodes: []int{2}},
+			{NodeID: 2, NodeType: "Actions", Actions: []domain.Action{"CompleteQuest"}},
+		},
+	}
+	flags := flagEvaluator{}
+	engine := startEngine(t, quest, flags)
+
+	if engine.CanTalk(1) {
+		t.Error("expected dialog to be unavailable while conditions fail")
+	}
+	if err := engine.Talk(1); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable, got %v", err)
+	}
+
+	flags["has_nails"] = true
+	if err := engine.Update(); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if !engine.IsActive(1) {
+		t.Fatal("expected dialog to keep waiting for the player")
+	}
+	if err := engine.Talk(1); err != nil {
+		t.Fatalf("Talk failed: %v", err)
+	}
+	if engine.Status() != StatusCompleted {
+		t.Errorf("expected status Completed, got %s", engine.Status())
+	}
+}
+
+func TestEngine_InteractingWithInactiveNode(t *testing.T) {
+	quest := &domain.Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Dialog", NextNodes: []int{2}},
+			{NodeID: 2, NodeType: "Dialog", NextNodes: []int{3}},
+			{NodeID: 3, NodeType: "Actions", Actions: []domain.Action{"CompleteQuest"}},
+		},
+	}
+	engine := startEngine(t, quest, flagEvaluator{})
+
+	if err := engine.Talk(2); !errors.Is(err, ErrNotActive) {
+		t.Errorf("expected ErrNotActive, got %v", err)
+	}
+	if _, err := engine.AvailableOptions(1); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable for a Dialog treated as Decision, got %v", err)
+	}
+}
+
+func TestEngine_TerminalActionEndsParallelFlows(t *testing.T) {
+	quest := &domain.Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Actions", NextNodes: []int{2, 3}, Actions: []domain.Action{"AcceptQuest"}},
+			{NodeID: 2, NodeType: "ConditionWatcher", NextNodes: []int{4}, Conditions: flag("lost")},
+			{NodeID: 3, NodeType: "Dialog", NextNodes: []int{5}},
+			{NodeID: 4, NodeType: "Actions", Actions: []domain.Action{"FailQuest"}},
+			{NodeID: 5, NodeType: "Actions", Actions: []domain.Action{"CompleteQuest"}},
+		},
+	}
+	flags := flagEvaluator{}
+	engine := startEngine(t, quest, flags)
+
+	if got := engine.Active(); !reflect.DeepEqual(got, []int{2, 3}) {
+		t.Fatalf("expected nodes 2 and 3 to be active, got %v", got)
+	}
+
+	flags["lost"] = true
+	if err := engine.Update(); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if engine.Status() != StatusFailed {
+		t.Errorf("expected status Failed, got %s", engine.Status())
+	}
+	if engine.IsActive(3) {
+		t.Error("expected parallel dialog to be deactivated by FailQuest")
+	}
+}
+
+func TestEngine_ExecutesActions(t *testing.T) {
+	quest := &domain.Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Actions", NextNodes: []int{2}, Actions: []domain.Action{
+				"AcceptQuest",
+				journal("You accepted."),
+				map[string]interface{}{"QuestStageDescription": map[string]interface{}{"en-US": "Do it", "de-DE": "Mach es"}},
+				map[string]interface{}{"SetVariable": map[string]interface{}{"VariableName": "Count", "Operation": "set to", "Value": 2}},
+			}},
+			{NodeID: 2, NodeType: "Actions", Actions: []domain.Action{
+				map[string]interface{}{"SetVariable": map[string]interface{}{"VariableName": "Count", "Operation": "increase by", "Value": 3}},
+				map[string]interface{}{"Currency": 5},
+				journal("You finished."),
+				"CompleteQuest",
+			}},
+		},
+	}
+	handler := &recordingHandler{}
+	engine, err := NewEngine(quest, flagEvaluator{}, handler)
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+	if err := engine.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if !engine.Accepted() {
+		t.Error("expected quest to be accepted")
+	}
+	if got := engine.Variables()["Count"]; got != 5 {
+		t.Errorf("expected Count = 5, got %d", got)
+	}
+	if got := engine.Journal(); len(got) != 2 || got[1].EnUS != "You finished." {
+		t.Errorf("unexpected journal: %+v", got)
+	}
+	if stage := engine.StageDescription(); stage == nil || stage.EnUS != "Do it" {
+		t.Errorf("unexpected stage description: %+v", stage)
+	}
+	if len(handler.actions) != 8 {
+		t.Fatalf("expected 8 handled actions, got %d", len(handler.actions))
+	}
+	if handler.actions[5].Kind != ActionCurrency || handler.actions[5].Amount != 5 {
+		t.Errorf("unexpected currency action: %+v", handler.actions[5])
+	}
+}
+
+func TestEngine_ConvergingFlowsActivateNodeOnce(t *testing.T) {
+	quest := &domain.Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1, 2}},
+			{NodeID: 1, NodeType: "Actions", NextNodes: []int{3}, Actions: []domain.Action{"AcceptQuest"}},
+			{NodeID: 2, NodeType: "Actions", NextNodes: []int{3}, Actions: []domain.Action{journal("x")}},
+			{NodeID: 3, NodeType: "Dialog", NextNodes: []int{4}},
+			{NodeID: 4, NodeType: "Actions", Actions: []domain.Action{"CompleteQuest"}},
+		},
+	}
+	engine := startEngine(t, quest, flagEvaluator{})
+
+	if got := engine.Active(); !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("expected only node 3 to be active, got %v", got)
+	}
+}
+
+func TestNewEngine_DuplicateNodeIDs(t *testing.T) {
+	quest := &domain.Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 0, NodeType: "Actions", Actions: []domain.Action{"CompleteQuest"}},
+		},
+	}
+	if _, err := NewEngine(quest, flagEvaluator{}, nil); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput, got %v", err)
+	}
+}
+
+func TestParseAction(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  domain.Action
+		want Action
+	}{
+		{"terminal", "FailQuest", Action{Kind: ActionFailQuest}},
+		{"items gained", map[string]interface{}{"ItemsGained": []interface{}{
+			map[string]interface{}{"Type": "Horseshoes", "Count": 1, "QuestItem": true},
+		}}, Action{Kind: ActionItemsGained, Items: []ItemStack{{Type: "Horseshoes", Count: 1, QuestItem: true}}}},
+		{"faction standing", map[string]interface{}{"FactionStanding": map[string]interface{}{"Faction": "Town", "Points": -1}},
+			Action{Kind: ActionFactionStanding, Faction: "Town", Points: -1}},
+		{"experience from JSON", map[string]interface{}{"Experience": float64(10)}, Action{Kind: ActionExperience, Amount: 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAction(tt.raw)
+			if err != nil {
+				t.Fatalf("ParseAction failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseAction("Teleport"); err == nil {
+		t.Error("expected error for unknown action")
+	}
+}
+
+func TestEngine_PassThroughCycleReturnsError(t *testing.T) {
+	quest := &domain.Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Actions", NextNodes: []int{2}, Actions: []domain.Action{journal("x")}},
+			{NodeID: 2, NodeType: "Actions", NextNodes: []int{1}, Actions: []domain.Action{journal("y")}},
+		},
+	}
+	engine, err := NewEngine(quest, flagEvaluator{}, nil)
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+	if err := engine.Start(); !errors.Is(err, ErrNoProgress) {
+		t.Errorf("expected ErrNoProgress, got %v", err)
+	}
+}
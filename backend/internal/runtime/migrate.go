@@ -0,0 +1,114 @@
+package runtime
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+// MigrateState upgrades a quest state saved from an older QuestVersion to
+// the version of quest, applying the quest's Migrations one version at a
+// time. Versions without a migration keep all node IDs and variables. The
+// given state is not modified.
+func MigrateState(state domain.QuestState, quest *domain.Quest) (domain.QuestState, error) {
+	if state.QuestID != quest.QuestID || state.QuestVersion > quest.QuestVersion {
+		return domain.QuestState{}, fmt.Errorf("%w: cannot migrate %s version %d to %s version %d",
+			ErrVersionMismatch, state.QuestID, state.QuestVersion, quest.QuestID, quest.QuestVersion)
+	}
+
+	migrations := make(map[int]domain.QuestMigration, len(quest.Migrations))
+	for _, m := range quest.Migrations {
+		migrations[m.FromVersion] = m
+	}
+
+	migrated := copyState(state)
+	for version := state.QuestVersion; version < quest.QuestVersion; version++ {
+		if m, ok := migrations[version]; ok {
+			migrated = applyMigration(migrated, m)
+		}
+		migrated.QuestVersion = version + 1
+	}
+
+	nodes := make(map[int]bool, len(quest.QuestNodes))
+	for _, node := range quest.QuestNodes {
+		nodes[node.NodeID] = true
+	}
+	for _, active := range migrated.ActiveNodes {
+		if !nodes[active.NodeID] {
+			return domain.QuestState{}, fmt.Errorf("%w: active node %d does not exist in version %d and has no migration",
+				ErrVersionMismatch, active.NodeID, quest.QuestVersion)
+		}
+	}
+	return migrated, nil
+}
+
+// applyMigration moves a state by a single version. Replacement nodes
+// inherit the progress of the node they replace.
+func applyMigration(state domain.QuestState, m domain.QuestMigration) domain.QuestState {
+	nodeMap := make(map[int][]int, len(m.Nodes))
+	for _, nm := range m.Nodes {
+		nodeMap[nm.From] = nm.To
+	}
+	seen := make(map[int]bool)
+	var active []domain.NodeState
+	for _, node := range state.ActiveNodes {
+		targets, mapped := nodeMap[node.NodeID]
+		if !mapped {
+			targets = []int{node.NodeID}
+		}
+		for _, id := range targets {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			moved := copyNodeState(node)
+			moved.NodeID = id
+			active = append(active, moved)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].NodeID < active[j].NodeID })
+	state.ActiveNodes = append([]domain.NodeState{}, active...)
+
+	for _, vm := range m.Variables {
+		value, ok := state.Variables[vm.From]
+		if !ok {
+			continue
+		}
+		delete(state.Variables, vm.From)
+		if vm.To != "" {
+			state.Variables[vm.To] = value
+		}
+	}
+	if len(state.Variables) == 0 {
+		state.Variables = nil
+	}
+	return state
+}
+
+func copyState(state domain.QuestState) domain.QuestState {
+	out := state
+	out.ActiveNodes = make([]domain.NodeState, 0, len(state.ActiveNodes))
+	for _, node := range state.ActiveNodes {
+		out.ActiveNodes = append(out.ActiveNodes, copyNodeState(node))
+	}
+	if state.Variables != nil {
+		out.Variables = make(map[string]int, len(state.Variables))
+		for name, value := range state.Variables {
+			out.Variables[name] = value
+		}
+	}
+	out.Journal = append([]domain.I18nString(nil), state.Journal...)
+	return out
+}
+
+func copyNodeState(node domain.NodeState) domain.NodeState {
+	out := domain.NodeState{NodeID: node.NodeID, Hours: node.Hours}
+	if node.Events != nil {
+		out.Events = make(map[string]int, len(node.Events))
+		for key, count := range node.Events {
+			out.Events[key] = count
+		}
+	}
+	return out
+}
@@ -0,0 +1,95 @@
+package runtime
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+func migrationQuest() *domain.Quest {
+	return &domain.Quest{
+		QuestID:      "TestQuest",
+		QuestVersion: 4,
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{7, 9}},
+			{NodeID: 7, NodeType: "Dialog", NextNodes: []int{10}},
+			{NodeID: 8, NodeType: "ConditionWatcher", NextNodes: []int{10}},
+			{NodeID: 9, NodeType: "ConditionWatcher", NextNodes: []int{10}},
+			{NodeID: 10, NodeType: "Actions", Actions: []domain.Action{"CompleteQuest"}},
+		},
+		Migrations: []domain.QuestMigration{
+			{
+				FromVersion: 1,
+				Nodes:       []domain.NodeMigration{{From: 2, To: []int{7}}, {From: 3, To: []int{}}},
+				Variables:   []domain.VariableMigration{{From: "Visits", To: "TimesVisited"}, {From: "Obsolete"}},
+			},
+			// Version 2 to 3 changed nothing a savegame can observe.
+			{
+				FromVersion: 3,
+				Nodes:       []domain.NodeMigration{{From: 4, To: []int{8, 9}}},
+			},
+		},
+	}
+}
+
+func TestMigrateState(t *testing.T) {
+	storm := map[string]int{"EventTriggered Storm": 2}
+	old := domain.QuestState{
+		QuestID:      "TestQuest",
+		QuestVersion: 1,
+		Status:       "Active",
+		ActiveNodes: []domain.NodeState{
+			{NodeID: 2, Hours: 5},
+			{NodeID: 3},
+			{NodeID: 4, Hours: 12, Events: storm},
+		},
+		Variables: map[string]int{"Visits": 3, "Obsolete": 1, "Kept": 2},
+	}
+
+	migrated, err := MigrateState(old, migrationQuest())
+	if err != nil {
+		t.Fatalf("MigrateState failed: %v", err)
+	}
+
+	want := domain.QuestState{
+		QuestID:      "TestQuest",
+		QuestVersion: 4,
+		Status:       "Active",
+		ActiveNodes: []domain.NodeState{
+			{NodeID: 7, Hours: 5},
+			{NodeID: 8, Hours: 12, Events: storm},
+			{NodeID: 9, Hours: 12, Events: storm},
+		},
+		Variables: map[string]int{"TimesVisited": 3, "Kept": 2},
+	}
+	if !reflect.DeepEqual(migrated, want) {
+		t.Errorf("unexpected migrated state:\n got %+v\nwant %+v", migrated, want)
+	}
+	if old.QuestVersion != 1 || len(old.ActiveNodes) != 3 || old.Variables["Visits"] != 3 {
+		t.Errorf("MigrateState modified its input: %+v", old)
+	}
+
+	if _, err := RestoreEngine(migrationQuest(), migrated, NewWorldEvaluator(NewMemoryWorld()), nil); err != nil {
+		t.Errorf("migrated state cannot be restored: %v", err)
+	}
+}
+
+func TestMigrateState_Rejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		state domain.QuestState
+	}{
+		{"newer version", domain.QuestState{QuestID: "TestQuest", QuestVersion: 5}},
+		{"other quest", domain.QuestState{QuestID: "OtherQuest", QuestVersion: 1}},
+		{"unmigrated node", domain.QuestState{QuestID: "TestQuest", QuestVersion: 2, ActiveNodes: []domain.NodeState{{NodeID: 5}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := MigrateState(tt.state, migrationQuest()); !errors.Is(err, ErrVersionMismatch) {
+				t.Errorf("expected ErrVersionMismatch, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+// ErrVersionMismatch is returned when restoring a state saved from a
+// different quest or quest version.
+var ErrVersionMismatch = errors.New("quest state does not match quest version")
+
+// State returns a snapshot of the engine suitable for savegames.
+func (e *Engine) State() domain.QuestState {
+	state := domain.QuestState{
+		QuestID:      e.quest.QuestID,
+		QuestVersion: e.quest.QuestVersion,
+		Status:       string(e.status),
+		Accepted:     e.accepted,
+		ActiveNodes:  []domain.NodeState{},
+		Journal:      e.Journal(),
+	}
+	if len(e.variables) > 0 {
+		state.Variables = e.Variables()
+	}
+	if e.stage != nil {
+		stage := *e.stage
+		state.StageDescription = &stage
+	}
+	for _, id := range e.Active() {
+		progress, _ := e.Progress(id)
+		node := domain.NodeState{NodeID: id, Hours: progress.Hours}
+		if len(progress.Events) > 0 {
+			node.Events = progress.Events
+		}
+		state.ActiveNodes = append(state.ActiveNodes, node)
+	}
+	return state
+}
+
+// RestoreEngine creates an engine for quest and puts it into a previously
+// saved state. The state must have been saved from the same QuestID and
+// QuestVersion. The restored engine does not advance until the next call
+// to Update, PassTime, Notify, Talk or Choose.
+func RestoreEngine(quest *domain.Quest, state domain.QuestState, evaluator ConditionEvaluator, handler ActionHandler) (*Engine, error) {
+	if state.QuestID != quest.QuestID || state.QuestVersion != quest.QuestVersion {
+		return nil, fmt.Errorf("%w: state is for %s version %d, quest is %s version %d",
+			ErrVersionMismatch, state.QuestID, state.QuestVersion, quest.QuestID, quest.QuestVersion)
+	}
+
+	e, err := NewEngine(quest, evaluator, handler)
+	if err != nil {
+		return nil, err
+	}
+
+	switch status := Status(state.Status); status {
+	case StatusInactive, StatusActive, StatusCompleted, StatusFailed, StatusDeclined:
+		e.status = status
+	default:
+		return nil, fmt.Errorf("%w: unknown quest status %q", domain.ErrInvalidInput, state.Status)
+	}
+	e.accepted = state.Accepted
+	e.journal = append(e.journal, state.Journal...)
+	if state.StageDescription != nil {
+		stage := *state.StageDescription
+		e.stage = &stage
+	}
+	for name, value := range state.Variables {
+		e.variables[name] = value
+	}
+
+	for _, saved := range state.ActiveNodes {
+		if _, exists := e.nodes[saved.NodeID]; !exists {
+			return nil, fmt.Errorf("%w: active node %d does not exist", domain.ErrInvalidInput, saved.NodeID)
+		}
+		if e.status != StatusActive {
+			return nil, fmt.Errorf("%w: %s quest has active nodes", domain.ErrInvalidInput, e.status)
+		}
+		e.activate(saved.NodeID)
+		progress := e.progress[saved.NodeID]
+		progress.Hours = saved.Hours
+		for key, count := range saved.Events {
+			progress.Events[key] = count
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,158 @@
+package runtime
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tinx/pat-quest-editor/backend/internal/domain"
+)
+
+func stateQuest() *domain.Quest {
+	return &domain.Quest{
+		QuestID:      "TestQuest",
+		QuestVersion: 3,
+		QuestNodes: []domain.QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Actions", NextNodes: []int{2, 3}, Actions: []domain.Action{
+				"AcceptQuest",
+				journal("Accepted"),
+				map[string]interface{}{"SetVariable": map[string]interface{}{"VariableName": "Visits", "Operation": "set to", "Value": 2}},
+				map[string]interface{}{"QuestStageDescription": map[string]interface{}{"en-US": "Wait", "de-DE": "Warte"}},
+			}},
+			{NodeID: 2, NodeType: "ConditionWatcher", NextNodes: []int{4}, Conditions: []domain.Condition{
+				{"TimePassed": "2d"},
+				{"EventTriggered": map[string]interface{}{"Event": "Storm", "Count": 1}},
+			}},
+			{NodeID: 3, NodeType: "Dialog", NextNodes: []int{5}},
+			{NodeID: 4, NodeType: "Actions", Actions: []domain.Action{"CompleteQuest"}},
+			{NodeID: 5, NodeType: "Actions", Actions: []domain.Action{"FailQuest"}},
+		},
+	}
+}
+
+// savedEngine returns an engine in the middle of stateQuest with progress
+// recorded on the watcher.
+func savedEngine(t *testing.T) *Engine {
+	t.Helper()
+	engine := startEngine(t, stateQuest(), NewWorldEvaluator(NewMemoryWorld()))
+	if err := engine.PassTime(30); err != nil {
+		t.Fatalf("PassTime failed: %v", err)
+	}
+	if err := engine.Notify(EventTriggered("Storm")); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	return engine
+}
+
+func TestEngine_State(t *testing.T) {
+	state := savedEngine(t).State()
+
+	want := domain.QuestState{
+		QuestID:      "TestQuest",
+		QuestVersion: 3,
+		Status:       "Active",
+		Accepted:     true,
+		ActiveNodes: []domain.NodeState{
+			{NodeID: 2, Hours: 30, Events: map[string]int{"EventTriggered Storm": 1}},
+			{NodeID: 3, Hours: 30, Events: map[string]int{"EventTriggered Storm": 1}},
+		},
+		Variables:        map[string]int{"Visits": 2},
+		Journal:          []domain.I18nString{{EnUS: "Accepted", DeDE: "Accepted"}},
+		StageDescription: &domain.I18nString{EnUS: "Wait", DeDE: "Warte"},
+	}
+	if !reflect.DeepEqual(state, want) {
+		t.Errorf("unexpected state:\n got %+v\nwant %+v", state, want)
+	}
+}
+
+func TestQuestState_RoundTrip(t *testing.T) {
+	state := savedEngine(t).State()
+
+	formats := map[string]struct {
+		marshal   func(interface{}) ([]byte, error)
+		unmarshal func([]byte, interface{}) error
+	}{
+		"yaml": {yaml.Marshal, yaml.Unmarshal},
+		"json": {json.Marshal, json.Unmarshal},
+	}
+	for name, format := range formats {
+		t.Run(name, func(t *testing.T) {
+			data, err := format.marshal(state)
+			if err != nil {
+				t.Fatalf("marshal failed: %v", err)
+			}
+			var decoded domain.QuestState
+			if err := format.unmarshal(data, &decoded); err != nil {
+				t.Fatalf("unmarshal failed: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, state) {
+				t.Errorf("round trip changed state:\n got %+v\nwant %+v", decoded, state)
+			}
+
+			again, err := format.marshal(decoded)
+			if err != nil {
+				t.Fatalf("marshal failed: %v", err)
+			}
+			if string(again) != string(data) {
+				t.Errorf("encoding is not stable:\n%s\n---\n%s", data, again)
+			}
+		})
+	}
+}
+
+func TestRestoreEngine_ContinuesWhereSaved(t *testing.T) {
+	data, err := yaml.Marshal(savedEngine(t).State())
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	var state domain.QuestState
+	if err := yaml.Unmarshal(data, &state); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	engine, err := RestoreEngine(stateQuest(), state, NewWorldEvaluator(NewMemoryWorld()), nil)
+	if err != nil {
+		t.Fatalf("RestoreEngine failed: %v", err)
+	}
+	if !reflect.DeepEqual(engine.State(), state) {
+		t.Errorf("restored engine reports a different state:\n got %+v\nwant %+v", engine.State(), state)
+	}
+
+	// 48 hours and one Storm satisfy both of the watcher's conditions.
+	if err := engine.PassTime(18); err != nil {
+		t.Fatalf("PassTime failed: %v", err)
+	}
+	if engine.Status() != StatusCompleted {
+		t.Errorf("expected status Completed, got %s", engine.Status())
+	}
+}
+
+func TestRestoreEngine_Rejects(t *testing.T) {
+	valid := savedEngine(t).State()
+	tests := []struct {
+		name    string
+		modify  func(*domain.QuestState)
+		wantErr error
+	}{
+		{"other version", func(s *domain.QuestState) { s.QuestVersion = 2 }, ErrVersionMismatch},
+		{"other quest", func(s *domain.QuestState) { s.QuestID = "OtherQuest" }, ErrVersionMismatch},
+		{"unknown status", func(s *domain.QuestState) { s.Status = "Paused" }, domain.ErrInvalidInput},
+		{"unknown node", func(s *domain.QuestState) { s.ActiveNodes = append(s.ActiveNodes, domain.NodeState{NodeID: 42}) }, domain.ErrInvalidInput},
+		{"ended with active nodes", func(s *domain.QuestState) { s.Status = "Completed" }, domain.ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := valid
+			state.ActiveNodes = append([]domain.NodeState(nil), valid.ActiveNodes...)
+			tt.modify(&state)
+			_, err := RestoreEngine(stateQuest(), state, NewWorldEvaluator(NewMemoryWorld()), nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
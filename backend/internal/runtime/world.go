@@ -0,0 +1,155 @@
+package runtime
+
+import "strings"
+
+// WorldState answers questions about the game world that quest conditions
+// depend on. The game provides its own implementation; MemoryWorld is a
+// simple one for simulations and tests.
+type WorldState interface {
+	// QuestCompleted reports whether the quest has been completed.
+	QuestCompleted(questID string) bool
+
+	// ResourceAvailable reports whether the resource is currently available.
+	ResourceAvailable(resourceID string) bool
+
+	// FactionStanding returns the player's standing level with a faction.
+	FactionStanding(factionID string) int
+
+	// ItemCount returns how many items of a type the player carries.
+	// If questItems is true, only quest items are counted.
+	ItemCount(itemType string, questItems bool) int
+
+	// Variable returns the value of a game variable, if it is set.
+	Variable(name string) (int, bool)
+}
+
+// Happening kinds that ConditionWatchers can wait for.
+const (
+	HappeningEventTriggered   = "EventTriggered"
+	HappeningItemLost         = "ItemLost"
+	HappeningItemUsedOnObject = "ItemUsedOnObject"
+	HappeningItemUsedOnNPC    = "ItemUsedOnNPC"
+)
+
+// Happening is an event-like occurrence in the world. Unlike world state,
+// happenings only count towards nodes that are active when they occur.
+type Happening struct {
+	Kind    string
+	Subject string
+	Target  string
+}
+
+// EventTriggered creates the happening of a named game event.
+func EventTriggered(event string) Happening {
+	return Happening{Kind: HappeningEventTriggered, Subject: event}
+}
+
+// ItemLost creates the happening of the player losing an item.
+func ItemLost(item string) Happening {
+	return Happening{Kind: HappeningItemLost, Subject: item}
+}
+
+// ItemUsedOnObject creates the happening of an item being used on a world object.
+func ItemUsedOnObject(item, object string) Happening {
+	return Happening{Kind: HappeningItemUsedOnObject, Subject: item, Target: object}
+}
+
+// ItemUsedOnNPC creates the happening of an item being used on an NPC.
+func ItemUsedOnNPC(item, npc string) Happening {
+	return Happening{Kind: HappeningItemUsedOnNPC, Subject: item, Target: npc}
+}
+
+// Key returns a stable string identifying the happening.
+func (h Happening) Key() string {
+	parts := []string{h.Kind, h.Subject}
+	if h.Target != "" {
+		parts = append(parts, h.Target)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Progress records what happened since a node became active.
+type Progress struct {
+	// Hours is the game time passed since activation.
+	Hours int
+	// Events counts happenings by their Key.
+	Events map[string]int
+}
+
+func newProgress() *Progress {
+	return &Progress{Events: make(map[string]int)}
+}
+
+// MemoryWorld is a WorldState backed by plain maps.
+type MemoryWorld struct {
+	CompletedQuests map[string]bool
+	Resources       map[string]bool
+	Factions        map[string]int
+	Items           map[string]int
+	QuestItems      map[string]int
+	Variables       map[string]int
+}
+
+// NewMemoryWorld creates an empty MemoryWorld.
+func NewMemoryWorld() *MemoryWorld {
+	return &MemoryWorld{
+		CompletedQuests: make(map[string]bool),
+		Resources:       make(map[string]bool),
+		Factions:        make(map[string]int),
+		Items:           make(map[string]int),
+		QuestItems:      make(map[string]int),
+		Variables:       make(map[string]int),
+	}
+}
+
+// QuestCompleted reports whether the quest has been completed.
+func (w *MemoryWorld) QuestCompleted(questID string) bool {
+	return w.CompletedQuests[questID]
+}
+
+// ResourceAvailable reports whether the resource is available.
+func (w *MemoryWorld) ResourceAvailable(resourceID string) bool {
+	return w.Resources[resourceID]
+}
+
+// FactionStanding returns the standing level with a faction.
+func (w *MemoryWorld) FactionStanding(factionID string) int {
+	return w.Factions[factionID]
+}
+
+// ItemCount returns how many items of a type the player carries.
+func (w *MemoryWorld) ItemCount(itemType string, questItems bool) int {
+	if questItems {
+		return w.QuestItems[itemType]
+	}
+	return w.Items[itemType]
+}
+
+// Variable returns the value of a game variable.
+func (w *MemoryWorld) Variable(name string) (int, bool) {
+	value, ok := w.Variables[name]
+	return value, ok
+}
+
+// HandleAction applies the world effects of quest actions, so a
+// MemoryWorld can be used as the engine's ActionHandler.
+func (w *MemoryWorld) HandleAction(nodeID int, action Action) {
+	switch action.Kind {
+	case ActionItemsGained, ActionItemsLost:
+		sign := 1
+		if action.Kind == ActionItemsLost {
+			sign = -1
+		}
+		for _, stack := range action.Items {
+			w.Items[stack.Type] += sign * stack.Count
+			if stack.QuestItem {
+				w.QuestItems[stack.Type] += sign * stack.Count
+			}
+		}
+	case ActionFactionStanding:
+		w.Factions[action.Faction] += action.Points
+	case ActionSetVariable:
+		// Errors are reported by the engine, which applies the same action.
+		_ = applySetVariable(w.Variables, action)
+	}
+}
@@ -0,0 +1,438 @@
+// Package schema validates documents against the project's JSON schemas.
+//
+// Only the subset of JSON Schema draft 2020-12 used by the files in
+// schemas/ is supported: type, properties, items, required, $ref (to
+// "#/..." within the same schema), oneOf, const, enum, pattern, minimum,
+// minItems and uniqueItems. Other keywords, such as description and
+// default, are ignored.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schema is a compiled JSON schema.
+type Schema struct {
+	root     interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+// Violation is a single place where a document does not match a schema.
+type Violation struct {
+	// Path is a JSON path into the document, such as
+	// "$.QuestNodes[3].Conditions[0]".
+	Path string
+	// Keyword is the schema keyword that failed, such as "required".
+	Keyword string
+	Message string
+}
+
+func (v Violation) Error() string {
+	return fmt.Sprintf("%s: %s", v.Path, v.Message)
+}
+
+// Compile parses a JSON schema. It fails if the schema contains invalid
+// patterns or references that cannot be resolved.
+func Compile(data []byte) (*Schema, error) {
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	s := &Schema{root: root, patterns: make(map[string]*regexp.Regexp)}
+	if err := s.compile(root); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Schema) compile(node interface{}) error {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if pattern, ok := n["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+			s.patterns[pattern] = re
+		}
+		if ref, ok := n["$ref"].(string); ok {
+			if _, err := s.resolve(ref); err != nil {
+				return err
+			}
+		}
+		for _, child := range n {
+			if err := s.compile(child); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range n {
+			if err := s.compile(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolve looks up a reference of the form "#/$defs/Name".
+func (s *Schema) resolve(ref string) (interface{}, error) {
+	pointer, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil, fmt.Errorf("unsupported $ref %q: only references within the schema are supported", ref)
+	}
+	node := s.root
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if token == "" {
+			continue
+		}
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+		if node, ok = m[token]; !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+	}
+	return node, nil
+}
+
+// Validate checks a document against the schema. The document can be
+// decoded from JSON or YAML into interface{} values, or be any value
+// that encoding/json can marshal.
+func (s *Schema) Validate(document interface{}) []Violation {
+	instance, err := normalize(document)
+	if err != nil {
+		return []Violation{{Path: "$", Keyword: "type", Message: err.Error()}}
+	}
+	return s.validate(s.root, instance, "$")
+}
+
+// ValidateEach checks every element of a list against the schema, as for
+// reference data files holding a list of records. Paths start at the
+// element, e.g. "$[2].Category".
+func (s *Schema) ValidateEach(list interface{}) []Violation {
+	instance, err := normalize(list)
+	if err != nil {
+		return []Violation{{Path: "$", Keyword: "type", Message: err.Error()}}
+	}
+	elements, ok := instance.([]interface{})
+	if !ok {
+		return []Violation{{Path: "$", Keyword: "type", Message: fmt.Sprintf("expected array, got %s", typeOf(instance))}}
+	}
+	var violations []Violation
+	for i, element := range elements {
+		violations = append(violations, s.validate(s.root, element, fmt.Sprintf("$[%d]", i))...)
+	}
+	return violations
+}
+
+// normalize turns a document into the values encoding/json decodes to,
+// so numbers are float64 and objects are map[string]interface{}.
+func normalize(document interface{}) (interface{}, error) {
+	switch d := document.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(d))
+		for k, v := range d {
+			n, err := normalize(v)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = n
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(d))
+		for i, v := range d {
+			n, err := normalize(v)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = n
+		}
+		return out, nil
+	case nil, string, bool, float64:
+		return d, nil
+	case int:
+		return float64(d), nil
+	case int64:
+		return float64(d), nil
+	case uint64:
+		return float64(d), nil
+	}
+
+	data, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("cannot validate document: %w", err)
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("cannot validate document: %w", err)
+	}
+	return out, nil
+}
+
+func (s *Schema) validate(node interface{}, instance interface{}, path string) []Violation {
+	if allowed, ok := node.(bool); ok {
+		if !allowed {
+			return []Violation{{Path: path, Keyword: "false", Message: "is not allowed"}}
+		}
+		return nil
+	}
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var violations []Violation
+	fail := func(keyword, format string, args ...interface{}) {
+		violations = append(violations, Violation{Path: path, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		target, _ := s.resolve(ref)
+		violations = append(violations, s.validate(target, instance, path)...)
+	}
+
+	if t, ok := schema["type"]; ok && !hasType(instance, t) {
+		fail("type", "expected %s, got %s", describeType(t), typeOf(instance))
+		return violations
+	}
+	if c, ok := schema["const"]; ok && !reflect.DeepEqual(instance, c) {
+		fail("const", "must be %s", encode(c))
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && !contains(enum, instance) {
+		values := make([]string, len(enum))
+		for i, v := range enum {
+			values[i] = encode(v)
+		}
+		fail("enum", "must be one of %s", strings.Join(values, ", "))
+	}
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		violations = append(violations, s.validateOneOf(oneOf, instance, path)...)
+	}
+
+	switch v := instance.(type) {
+	case string:
+		if pattern, ok := schema["pattern"].(string); ok && !s.patterns[pattern].MatchString(v) {
+			fail("pattern", "%s does not match pattern %s", encode(v), pattern)
+		}
+	case float64:
+		if minimum, ok := schema["minimum"].(float64); ok && v < minimum {
+			fail("minimum", "must be at least %s", strconv.FormatFloat(minimum, 'f', -1, 64))
+		}
+	case []interface{}:
+		if minItems, ok := schema["minItems"].(float64); ok && float64(len(v)) < minItems {
+			fail("minItems", "must have at least %s items", strconv.FormatFloat(minItems, 'f', -1, 64))
+		}
+		if unique, _ := schema["uniqueItems"].(bool); unique {
+			if i, j, found := duplicate(v); found {
+				fail("uniqueItems", "items %d and %d are equal", i, j)
+			}
+		}
+		if items, ok := schema["items"]; ok {
+			for i, item := range v {
+				violations = append(violations, s.validate(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, r := range required {
+				name, _ := r.(string)
+				if _, present := v[name]; !present {
+					fail("required", "missing required property %s", encode(name))
+				}
+			}
+		}
+		if properties, ok := schema["properties"].(map[string]interface{}); ok {
+			for _, name := range sortedKeys(v) {
+				if prop, ok := properties[name]; ok {
+					violations = append(violations, s.validate(prop, v[name], PropertyPath(path, name))...)
+				}
+			}
+		}
+	}
+
+	return violations
+}
+
+// validateOneOf checks that exactly one subschema matches. If none
+// matches, the violations of the subschema the instance was most likely
+// meant for are reported, so authors see what to fix rather than a list
+// of every alternative.
+func (s *Schema) validateOneOf(oneOf []interface{}, instance interface{}, path string) []Violation {
+	results := make([][]Violation, len(oneOf))
+	matches := 0
+	for i, sub := range oneOf {
+		results[i] = s.validate(sub, instance, path)
+		if len(results[i]) == 0 {
+			matches++
+		}
+	}
+	if matches == 1 {
+		return nil
+	}
+	if matches > 1 {
+		return []Violation{{Path: path, Keyword: "oneOf", Message: "matches more than one of the allowed forms"}}
+	}
+
+	// A subschema is a candidate unless the instance has the wrong type
+	// or a discriminating constant, such as NodeType, does not match.
+	best, bestShallow, bestTotal, tie := -1, 0, 0, false
+	for i, violations := range results {
+		shallow, excluded := 0, false
+		for _, v := range violations {
+			if v.Keyword == "type" && v.Path == path {
+				excluded = true
+			}
+			if v.Keyword == "const" && (v.Path == path || isChildPath(path, v.Path)) {
+				excluded = true
+			}
+			if v.Path == path {
+				shallow++
+			}
+		}
+		if excluded {
+			continue
+		}
+		switch {
+		case best < 0 || shallow < bestShallow || (shallow == bestShallow && len(violations) < bestTotal):
+			best, bestShallow, bestTotal, tie = i, shallow, len(violations), false
+		case shallow == bestShallow && len(violations) == bestTotal:
+			tie = true
+		}
+	}
+	if best < 0 || tie {
+		return []Violation{{Path: path, Keyword: "oneOf", Message: "does not match any of the allowed forms"}}
+	}
+	return results[best]
+}
+
+func isChildPath(parent, path string) bool {
+	rest, ok := strings.CutPrefix(path, parent)
+	if !ok || rest == "" {
+		return false
+	}
+	if rest[0] == '.' {
+		return !strings.ContainsAny(rest[1:], ".[")
+	}
+	if rest[0] == '[' {
+		return strings.Index(rest, "]") == len(rest)-1
+	}
+	return false
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// PropertyPath returns the path of the property name of the object at path,
+// as used in Violation paths.
+func PropertyPath(path, name string) string {
+	if identifier.MatchString(name) {
+		return path + "." + name
+	}
+	return fmt.Sprintf("%s[%s]", path, strconv.Quote(name))
+}
+
+func hasType(instance interface{}, t interface{}) bool {
+	switch t := t.(type) {
+	case string:
+		return isType(instance, t)
+	case []interface{}:
+		for _, name := range t {
+			if name, ok := name.(string); ok && isType(instance, name) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func isType(instance interface{}, name string) bool {
+	switch name {
+	case "integer":
+		f, ok := instance.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := instance.(float64)
+		return ok
+	}
+	return typeOf(instance) == name
+}
+
+func typeOf(instance interface{}) string {
+	switch v := instance.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", instance)
+}
+
+func describeType(t interface{}) string {
+	if names, ok := t.([]interface{}); ok {
+		parts := make([]string, len(names))
+		for i, name := range names {
+			parts[i] = fmt.Sprint(name)
+		}
+		return strings.Join(parts, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func contains(values []interface{}, instance interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, instance) {
+			return true
+		}
+	}
+	return false
+}
+
+func duplicate(items []interface{}) (int, int, bool) {
+	for i := range items {
+		for j := i + 1; j < len(items); j++ {
+			if reflect.DeepEqual(items[i], items[j]) {
+				return i, j, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+func encode(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
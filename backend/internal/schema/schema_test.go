@@ -0,0 +1,213 @@
+package schema
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const testSchema = `{
+	"type": "object",
+	"properties": {
+		"ID": { "type": "string", "pattern": "^[A-Z][a-z]*$" },
+		"Kind": { "type": "string", "enum": [ "Small", "Large" ] },
+		"Count": { "type": "integer", "minimum": 1 },
+		"Tags": {
+			"type": "array",
+			"minItems": 1,
+			"uniqueItems": true,
+			"items": { "type": "string" }
+		},
+		"Name": { "$ref": "#/$defs/i18n" },
+		"Shape": {
+			"oneOf": [
+				{ "$ref": "#/$defs/Circle" },
+				{ "$ref": "#/$defs/Square" }
+			]
+		}
+	},
+	"required": [ "ID", "Kind" ],
+	"$defs": {
+		"i18n": {
+			"type": "object",
+			"properties": { "en-US": { "type": "string" } },
+			"required": [ "en-US" ]
+		},
+		"Circle": {
+			"type": "object",
+			"properties": {
+				"Type": { "const": "Circle" },
+				"Radius": { "type": "integer", "minimum": 1 }
+			},
+			"required": [ "Type", "Radius" ]
+		},
+		"Square": {
+			"type": "object",
+			"properties": {
+				"Type": { "const": "Square" },
+				"Side": { "type": "integer" }
+			},
+			"required": [ "Type", "Side" ]
+		}
+	}
+}`
+
+func compileTestSchema(t *testing.T) *Schema {
+	t.Helper()
+	s, err := Compile([]byte(testSchema))
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	return s
+}
+
+func validateYAML(t *testing.T, s *Schema, document string) []string {
+	t.Helper()
+	var doc interface{}
+	if err := yaml.Unmarshal([]byte(document), &doc); err != nil {
+		t.Fatal(err)
+	}
+	var errors []string
+	for _, v := range s.Validate(doc) {
+		errors = append(errors, v.Error())
+	}
+	return errors
+}
+
+func TestValidate_Valid(t *testing.T) {
+	s := compileTestSchema(t)
+
+	errors := validateYAML(t, s, `
+ID: Anvil
+Kind: Large
+Count: 3
+Tags: [iron, heavy]
+Name: { en-US: Anvil }
+Shape: { Type: Square, Side: 2 }
+Unknown: ignored
+`)
+
+	if len(errors) != 0 {
+		t.Errorf("expected no violations, got %v", errors)
+	}
+}
+
+func TestValidate_Keywords(t *testing.T) {
+	s := compileTestSchema(t)
+
+	tests := []struct {
+		name     string
+		document string
+		want     []string
+	}{
+		{"required", `{ID: Anvil}`, []string{`$: missing required property "Kind"`}},
+		{"type", `{ID: Anvil, Kind: Small, Count: many}`, []string{"$.Count: expected integer, got string"}},
+		{"integer", `{ID: Anvil, Kind: Small, Count: 1.5}`, []string{"$.Count: expected integer, got number"}},
+		{"enum", `{ID: Anvil, Kind: Huge}`, []string{`$.Kind: must be one of "Small", "Large"`}},
+		{"pattern", `{ID: anvil, Kind: Small}`, []string{`$.ID: "anvil" does not match pattern ^[A-Z][a-z]*$`}},
+		{"minimum", `{ID: Anvil, Kind: Small, Count: 0}`, []string{"$.Count: must be at least 1"}},
+		{"minItems", `{ID: Anvil, Kind: Small, Tags: []}`, []string{"$.Tags: must have at least 1 items"}},
+		{"uniqueItems", `{ID: Anvil, Kind: Small, Tags: [a, b, a]}`, []string{"$.Tags: items 0 and 2 are equal"}},
+		{"items", `{ID: Anvil, Kind: Small, Tags: [a, 2]}`, []string{"$.Tags[1]: expected string, got integer"}},
+		{"$ref", `{ID: Anvil, Kind: Small, Name: {de-DE: Amboss}}`, []string{`$.Name: missing required property "en-US"`}},
+		{"property path", `{ID: Anvil, Kind: Small, Name: {en-US: 1}}`, []string{`$.Name["en-US"]: expected string, got integer`}},
+		{"oneOf picks the intended form", `{ID: Anvil, Kind: Small, Shape: {Type: Circle, Radius: 0}}`, []string{"$.Shape.Radius: must be at least 1"}},
+		{"oneOf without a matching form", `{ID: Anvil, Kind: Small, Shape: {Type: Triangle}}`, []string{"$.Shape: does not match any of the allowed forms"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := validateYAML(t, s, tt.document)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_Structs(t *testing.T) {
+	s := compileTestSchema(t)
+	doc := struct {
+		ID   string `json:"ID"`
+		Kind string `json:"Kind"`
+	}{ID: "Anvil", Kind: "Tiny"}
+
+	violations := s.Validate(doc)
+
+	if len(violations) != 1 || violations[0].Path != "$.Kind" || violations[0].Keyword != "enum" {
+		t.Errorf("unexpected violations: %v", violations)
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	for _, schema := range []string{
+		`{`,
+		`{"pattern": "("}`,
+		`{"$ref": "#/$defs/Missing"}`,
+		`{"$ref": "other.json"}`,
+	} {
+		if _, err := Compile([]byte(schema)); err == nil {
+			t.Errorf("expected Compile(%s) to fail", schema)
+		}
+	}
+}
+
+func TestQuestSchema(t *testing.T) {
+	data, err := os.ReadFile("../../../schemas/quest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Compile(data)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	errors := validateYAML(t, s, `
+QuestTypeVersion: 1
+QuestVersion: 1
+QuestID: TestQuest
+QuestType: SideQuest
+DisplayName: { en-US: Test, de-DE: Test }
+Repeatable: sometimes
+QuestNodes:
+  - NodeID: 0
+    NodeType: EntryPoint
+    NextNodes: [1]
+  - NodeID: 1
+    NodeType: ConditionBranch
+    Conditions:
+      - TimePassed: 1d
+    NextNodesIfTrue: [2]
+  - NodeID: 2
+    NodeType: ConditionWatcher
+    Conditions:
+      - TimePassed: 3x
+    NextNodes: [3]
+  - NodeID: 3
+    NodeType: Decision
+    ConversationPartner: NPC:Smith
+    Text: { en-US: Well, de-DE: Nun }
+    Options:
+      - Text: { en-US: Yes, de-DE: Ja }
+        NextNodes: [4]
+      - Text: { en-US: No, de-DE: Nein }
+        NextNodes: [4]
+  - NodeID: 4
+    NodeType: Actions
+    Actions:
+      - Variable: { VariableName: X, Comparison: equal, Value: 1 }
+`)
+
+	want := []string{
+		`$.QuestNodes[1].Conditions[0]: does not match any of the allowed forms`,
+		`$.QuestNodes[2].Conditions[0].TimePassed: "3x" does not match pattern ^[1-9][0-9]*[hdwMy]$`,
+		`$.QuestNodes[3]: missing required property "Speaker"`,
+		`$.QuestNodes[4].Actions[0]: does not match any of the allowed forms`,
+		`$.Repeatable: "sometimes" does not match pattern ^(never|daily|weekly|always)$`,
+	}
+	if !reflect.DeepEqual(errors, want) {
+		t.Errorf("unexpected violations:\n got %q\nwant %q", errors, want)
+	}
+}
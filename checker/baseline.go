@@ -0,0 +1,153 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// baselineVersion is the version of the baseline file format.
+const baselineVersion = 1
+
+// Baseline records known findings, so that a stricter rule can be adopted
+// without fixing all existing quests first. Findings are matched by a
+// fingerprint of their quest, node, rule and message, which leaves out the
+// line and column so that entries survive unrelated edits.
+type Baseline struct {
+	Version  int             `json:"version"`
+	Findings []BaselineEntry `json:"findings"`
+}
+
+// BaselineEntry is a finding recorded in a baseline.
+type BaselineEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	File        string `json:"file,omitempty"`
+	QuestID     string `json:"questId,omitempty"`
+	NodeID      *int   `json:"nodeId,omitempty"`
+	Rule        string `json:"rule"`
+	Message     string `json:"message"`
+}
+
+func (e BaselineEntry) String() string {
+	return formatError(ValidationError{
+		Code:     e.Rule,
+		Severity: ruleSeverity(e.Rule),
+		File:     e.File,
+		QuestID:  e.QuestID,
+		NodeID:   e.NodeID,
+		Message:  e.Message,
+	})
+}
+
+// fingerprint identifies an issue independently of where in its file it
+// was found.
+func fingerprint(issue ValidationError) string {
+	node := ""
+	if issue.NodeID != nil {
+		node = strconv.Itoa(*issue.NodeID)
+	}
+	h := sha256.New()
+	for _, part := range []string{issue.Code, issue.File, issue.QuestID, node, issue.Message} {
+		fmt.Fprintf(h, "%d:%s\n", len(part), part)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// NewBaseline records the given issues.
+func NewBaseline(issues []ValidationError) *Baseline {
+	b := &Baseline{Version: baselineVersion, Findings: []BaselineEntry{}}
+	for _, issue := range issues {
+		b.Findings = append(b.Findings, BaselineEntry{
+			Fingerprint: fingerprint(issue),
+			File:        issue.File,
+			QuestID:     issue.QuestID,
+			NodeID:      issue.NodeID,
+			Rule:        issue.Code,
+			Message:     issue.Message,
+		})
+	}
+	sort.SliceStable(b.Findings, func(i, j int) bool {
+		a, c := b.Findings[i], b.Findings[j]
+		if a.QuestID != c.QuestID {
+			return a.QuestID < c.QuestID
+		}
+		if a.File != c.File {
+			return a.File < c.File
+		}
+		if (a.NodeID == nil) != (c.NodeID == nil) {
+			return a.NodeID == nil
+		}
+		if a.NodeID != nil && *a.NodeID != *c.NodeID {
+			return *a.NodeID < *c.NodeID
+		}
+		if a.Rule != c.Rule {
+			return a.Rule < c.Rule
+		}
+		return a.Message < c.Message
+	})
+	return b
+}
+
+// LoadBaseline reads a baseline file.
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	if b.Version != baselineVersion {
+		return nil, fmt.Errorf("baseline %s has unsupported version %d", path, b.Version)
+	}
+	return &b, nil
+}
+
+// Write writes the baseline to a file.
+func (b *Baseline) Write(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode baseline: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
+
+// Apply removes the issues recorded in the baseline. Each entry matches one
+// issue, so a finding that occurs more often than recorded is still
+// reported. It returns the remaining issues, the number of removed ones,
+// and the entries that matched no issue because they have been fixed.
+func (b *Baseline) Apply(issues []ValidationError) ([]ValidationError, int, []BaselineEntry) {
+	known := make(map[string]int)
+	for _, entry := range b.Findings {
+		known[entry.Fingerprint]++
+	}
+
+	var kept []ValidationError
+	baselined := 0
+	for _, issue := range issues {
+		fp := fingerprint(issue)
+		if known[fp] > 0 {
+			known[fp]--
+			baselined++
+			continue
+		}
+		kept = append(kept, issue)
+	}
+
+	var fixed []BaselineEntry
+	for _, entry := range b.Findings {
+		if known[entry.Fingerprint] > 0 {
+			known[entry.Fingerprint]--
+			fixed = append(fixed, entry)
+		}
+	}
+	return kept, baselined, fixed
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBaseline_Apply(t *testing.T) {
+	known := []ValidationError{
+		{Code: "PAT013", QuestID: "QuestA", NodeID: intPtr(3), Message: "unknown NPC \"NPC:Ghost\""},
+		{Code: "PAT013", QuestID: "QuestA", NodeID: intPtr(3), Message: "unknown NPC \"NPC:Ghost\""},
+		{Code: "PAT021", Message: "duplicate DisplayName \"Test\" (en-US) in quests: QuestA, QuestB"},
+		{Code: "PAT017", File: "items.yaml", Message: "$[0]: missing required property \"ItemID\""},
+	}
+	baseline := NewBaseline(known)
+
+	// The same findings at other positions are still known
+	current := []ValidationError{
+		{Code: "PAT013", QuestID: "QuestA", NodeID: intPtr(3), Line: 40, Column: 5, Message: "unknown NPC \"NPC:Ghost\""},
+		{Code: "PAT013", QuestID: "QuestA", NodeID: intPtr(3), Line: 52, Column: 5, Message: "unknown NPC \"NPC:Ghost\""},
+		{Code: "PAT013", QuestID: "QuestA", NodeID: intPtr(3), Line: 60, Column: 5, Message: "unknown NPC \"NPC:Ghost\""},
+		{Code: "PAT013", QuestID: "QuestA", NodeID: intPtr(4), Message: "unknown NPC \"NPC:Ghost\""},
+		{Code: "PAT017", File: "items.yaml", Line: 2, Column: 3, Message: "$[0]: missing required property \"ItemID\""},
+	}
+	kept, baselined, fixed := baseline.Apply(current)
+
+	if baselined != 3 {
+		t.Errorf("expected 3 baselined issues, got %d", baselined)
+	}
+	if want := []ValidationError{current[2], current[3]}; !reflect.DeepEqual(kept, want) {
+		t.Errorf("expected the third occurrence and the other node to remain, got %v", kept)
+	}
+	if len(fixed) != 1 || fixed[0].Rule != "PAT021" {
+		t.Errorf("expected the DisplayName entry to be fixed, got %v", fixed)
+	}
+}
+
+func TestBaseline_WriteAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	baseline := NewBaseline([]ValidationError{
+		{Code: "PAT021", Message: "duplicate DisplayName"},
+		{Code: "PAT013", QuestID: "QuestB", NodeID: intPtr(1), Message: "unknown NPC"},
+		{Code: "PAT013", QuestID: "QuestA", NodeID: intPtr(2), Message: "unknown NPC"},
+		{Code: "PAT006", QuestID: "QuestA", Message: "no EntryPoint"},
+	})
+	if err := baseline.Write(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, baseline) {
+		t.Errorf("expected %+v, got %+v", baseline, loaded)
+	}
+	var order []string
+	for _, entry := range loaded.Findings {
+		order = append(order, entry.QuestID+"/"+entry.Rule)
+	}
+	if want := []string{"/PAT021", "QuestA/PAT006", "QuestA/PAT013", "QuestB/PAT013"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("expected entries sorted by quest and node, got %v", order)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"strconv"
+)
+
+// hoursPerUnit maps the units of a TimePassed condition to hours.
+// Months and years are counted as 30 and 365 days.
+var hoursPerUnit = map[byte]int{
+	'h': 1,
+	'd': 24,
+	'w': 7 * 24,
+	'M': 30 * 24,
+	'y': 365 * 24,
+}
+
+// parseTimePassed converts a TimePassed value such as "36h" into hours.
+func parseTimePassed(s string) (int, bool) {
+	if len(s) < 2 {
+		return 0, false
+	}
+	perUnit, ok := hoursPerUnit[s[len(s)-1]]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n < 1 {
+		return 0, false
+	}
+	return n * perUnit, true
+}
+
+// requiredCount returns how many of total conditions must hold for the
+// given ConditionsRequired value ("all", a number, or empty).
+func requiredCount(required string, total int) int {
+	if required == "" || required == "all" {
+		return total
+	}
+	n, err := strconv.Atoi(required)
+	if err != nil {
+		return total
+	}
+	return n
+}
+
+func (in *Interpreter) conditionsHold(nodeID int, conditions []map[string]interface{}, required string) bool {
+	if in.Decide != nil && len(conditions) > 0 {
+		return in.Decide(in.nodes[nodeID], conditions, required)
+	}
+	need := requiredCount(required, len(conditions))
+	met := 0
+	for _, cond := range conditions {
+		if in.conditionHolds(nodeID, cond) {
+			met++
+		}
+	}
+	return met >= need
+}
+
+func (in *Interpreter) conditionHolds(nodeID int, cond map[string]interface{}) bool {
+	world := in.world
+	progress := in.Progress(nodeID)
+
+	for kind, value := range cond {
+		switch kind {
+		case "QuestCompleted":
+			questID, _ := value.(string)
+			return world.CompletedQuests[questID]
+
+		case "ResourceAvailability":
+			ra, _ := value.(map[string]interface{})
+			resource, _ := ra["Resource"].(string)
+			available, _ := ra["Available"].(bool)
+			return world.Resources[resource] == available
+
+		case "FactionStanding":
+			fs, _ := value.(map[string]interface{})
+			faction, _ := fs["Faction"].(string)
+			level := world.Factions[faction]
+			if min, ok := toInt(fs["MinimumLevel"]); ok && level < min {
+				return false
+			}
+			if max, ok := toInt(fs["MaximumLevel"]); ok && level > max {
+				return false
+			}
+			return true
+
+		case "TimePassed":
+			s, _ := value.(string)
+			hours, ok := parseTimePassed(s)
+			return ok && progress.Hours >= hours
+
+		case "ItemLost":
+			item, _ := value.(string)
+			return progress.Events[happeningKey("ItemLost", item)] > 0
+
+		case "Inventory":
+			for _, stack := range itemStacks(value) {
+				have := world.Inventory[stack.Type]
+				if stack.QuestItem {
+					have = world.QuestItems[stack.Type]
+				}
+				if have < stack.Count {
+					return false
+				}
+			}
+			return true
+
+		case "Variable":
+			v, _ := value.(map[string]interface{})
+			name, _ := v["VariableName"].(string)
+			want, _ := toInt(v["Value"])
+			comparison, _ := v["Comparison"].(string)
+			return compare(world.Variables[name], comparison, want)
+
+		case "EventTriggered":
+			et, _ := value.(map[string]interface{})
+			event, _ := et["Event"].(string)
+			count, ok := toInt(et["Count"])
+			if !ok {
+				count = 1
+			}
+			return progress.Events[happeningKey("EventTriggered", event)] >= count
+
+		case "ItemUsedOnObject":
+			iu, _ := value.(map[string]interface{})
+			item, _ := iu["Item"].(string)
+			object, _ := iu["Object"].(string)
+			return progress.Events[happeningKey("ItemUsedOnObject", item, object)] > 0
+
+		case "ItemUsedOnNPC":
+			iu, _ := value.(map[string]interface{})
+			item, _ := iu["Item"].(string)
+			npc, _ := iu["NPC"].(string)
+			return progress.Events[happeningKey("ItemUsedOnNPC", item, npc)] > 0
+		}
+	}
+	return false
+}
+
+func compare(have int, comparison string, want int) bool {
+	switch comparison {
+	case "equal":
+		return have == want
+	case "not equal":
+		return have != want
+	case "greater than":
+		return have > want
+	case "smaller than":
+		return have < want
+	}
+	return false
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is the name of the checker configuration file. Without
+// -config, it is looked up in the current directory and its parents, up to
+// the root of the git repository.
+const configFile = ".patcheck.yaml"
+
+// supportedLanguages are the languages of quest texts.
+var supportedLanguages = []string{"en-US", "de-DE"}
+
+// Config is the checker configuration read from a .patcheck.yaml file.
+// Paths are relative to the directory of the file. Command line flags take
+// precedence over the settings of the file.
+type Config struct {
+	Quests   string `yaml:"Quests"`
+	Data     string `yaml:"Data"`
+	Schemas  string `yaml:"Schemas"`
+	Previous string `yaml:"Previous"`
+	Baseline string `yaml:"Baseline"`
+	FailOn   string `yaml:"FailOn"`
+	// Languages are the languages in which DisplayNames and stage
+	// descriptions must be unique (default: all supported languages).
+	Languages []string `yaml:"Languages"`
+	// Rules turns rules off or changes their severity.
+	Rules RuleSettings `yaml:"Rules"`
+	// Overrides change the rule settings for the files in a directory.
+	// Later overrides take precedence over earlier ones.
+	Overrides []ConfigOverride `yaml:"Overrides"`
+
+	dir string
+}
+
+// RuleSettings maps rule codes to "off" or a severity.
+type RuleSettings map[string]string
+
+// ConfigOverride holds rule settings for the files below Path.
+type ConfigOverride struct {
+	Path  string       `yaml:"Path"`
+	Rules RuleSettings `yaml:"Rules"`
+}
+
+// findConfig returns the path of the configuration file for dir, or "" if
+// there is none.
+func findConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, configFile)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// LoadConfig reads and checks a configuration file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var config Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	config.dir = filepath.Dir(path)
+
+	if err := config.check(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+func (c *Config) check() error {
+	if c.FailOn != "" {
+		if _, err := ParseSeverity(c.FailOn); err != nil {
+			return fmt.Errorf("FailOn: %w", err)
+		}
+	}
+	for _, lang := range c.Languages {
+		if !contains(supportedLanguages, lang) {
+			return fmt.Errorf("Languages: unsupported language %q (want %s)", lang, strings.Join(supportedLanguages, " or "))
+		}
+	}
+	if err := c.Rules.check(); err != nil {
+		return fmt.Errorf("Rules: %w", err)
+	}
+	for i, o := range c.Overrides {
+		if o.Path == "" {
+			return fmt.Errorf("Overrides[%d]: missing Path", i)
+		}
+		if err := o.Rules.check(); err != nil {
+			return fmt.Errorf("Overrides[%d].Rules: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (s RuleSettings) check() error {
+	for code, setting := range s {
+		if _, ok := rules[code]; !ok || code == loadErrorRule {
+			return fmt.Errorf("unknown rule %s", code)
+		}
+		if setting == "off" {
+			continue
+		}
+		if _, err := ParseSeverity(setting); err != nil {
+			return fmt.Errorf("%s: %w", code, err)
+		}
+	}
+	return nil
+}
+
+// resolve returns a path of the configuration file, which is relative to
+// the directory of the file, relative to the current directory, so that the
+// paths in the reports stay relative as with command line flags.
+func (c *Config) resolve(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	path = filepath.Join(c.dir, path)
+	if !filepath.IsAbs(path) {
+		return path
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(cwd, path); err == nil {
+			return rel
+		}
+	}
+	return path
+}
+
+// applyConfig sets the flags of fs that were not given on the command line
+// from the configuration file at path, or from the file found by
+// findConfig if path is empty. It returns nil if there is no configuration
+// file.
+func applyConfig(fs *flag.FlagSet, path string) (*Config, error) {
+	if path == "" {
+		var err error
+		if path, err = findConfig("."); err != nil || path == "" {
+			return nil, err
+		}
+	}
+	config, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	settings := map[string]string{
+		"quests":   config.resolve(config.Quests),
+		"data":     config.resolve(config.Data),
+		"schemas":  config.resolve(config.Schemas),
+		"previous": config.resolve(config.Previous),
+		"baseline": config.resolve(config.Baseline),
+		"fail-on":  config.FailOn,
+	}
+	for name, value := range settings {
+		if value == "" || set[name] || fs.Lookup(name) == nil {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			return nil, fmt.Errorf("config %s: %w", path, err)
+		}
+	}
+	return config, nil
+}
+
+// languages returns the configured languages, or all supported languages.
+func (c *Config) languages() []string {
+	if c == nil || len(c.Languages) == 0 {
+		return supportedLanguages
+	}
+	return c.Languages
+}
+
+// applyRules drops the issues of rules that are turned off and sets the
+// configured severities. The settings of the overrides apply to issues in
+// files below their Path.
+func (c *Config) applyRules(issues []ValidationError) []ValidationError {
+	if c == nil {
+		return issues
+	}
+
+	var kept []ValidationError
+	for _, issue := range issues {
+		setting := c.Rules[issue.Code]
+		if issue.Path != "" {
+			for _, o := range c.Overrides {
+				if s, ok := o.Rules[issue.Code]; ok && isWithin(issue.Path, c.resolve(o.Path)) {
+					setting = s
+				}
+			}
+		}
+		switch setting {
+		case "":
+		case "off":
+			continue
+		default:
+			issue.Severity, _ = ParseSeverity(setting)
+		}
+		kept = append(kept, issue)
+	}
+	return kept
+}
+
+// isWithin reports whether path is dir or lies below it.
+func isWithin(path, dir string) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	return absPath == absDir || strings.HasPrefix(absPath, absDir+string(filepath.Separator))
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
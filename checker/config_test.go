@@ -0,0 +1,151 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, dir, source string) string {
+	t.Helper()
+	path := filepath.Join(dir, configFile)
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func absPath(t *testing.T, path string) string {
+	t.Helper()
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return abs
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"Quest: quests\n", "field Quest not found"},
+		{"FailOn: fatal\n", "FailOn: unknown severity"},
+		{"Languages: [fr-FR]\n", `unsupported language "fr-FR"`},
+		{"Rules: { PAT099: off }\n", "Rules: unknown rule PAT099"},
+		{"Rules: { PAT013: loud }\n", "Rules: PAT013: unknown severity"},
+		{"Overrides: [{ Rules: { PAT013: off } }]\n", "Overrides[0]: missing Path"},
+	}
+	for _, tt := range tests {
+		path := writeConfig(t, t.TempDir(), tt.source)
+		_, err := LoadConfig(path)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: expected error containing %q, got %v", tt.source, tt.want, err)
+		}
+	}
+}
+
+func TestConfig_ApplyRules(t *testing.T) {
+	dir := t.TempDir()
+	config, err := LoadConfig(writeConfig(t, dir, `
+Rules:
+  PAT013: warning
+  PAT021: off
+Overrides:
+  - Path: quests/main
+    Rules:
+      PAT013: error
+      PAT014: off
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	side := filepath.Join(dir, "quests", "side", "a.yaml")
+	main := filepath.Join(dir, "quests", "main", "b.yaml")
+	issues := config.applyRules([]ValidationError{
+		{Code: "PAT013", Path: side, Message: "side NPC"},
+		{Code: "PAT013", Path: main, Message: "main NPC"},
+		{Code: "PAT014", Path: side, Message: "side journal"},
+		{Code: "PAT014", Path: main, Message: "main journal"},
+		{Code: "PAT021", Message: "display name"},
+	})
+
+	want := map[string]Severity{
+		"side NPC":     SeverityWarning,
+		"main NPC":     SeverityError,
+		"side journal": SeverityError,
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("expected %d issues, got %v", len(want), issues)
+	}
+	for _, issue := range issues {
+		if severity, ok := want[issue.Message]; !ok || issue.Severity != severity {
+			t.Errorf("unexpected issue %+v", issue)
+		}
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfig(t, dir, "Quests: content/quests\nData: content/data\nFailOn: warning\n")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	questsPath := fs.String("quests", "./quests", "")
+	dataPath := fs.String("data", "./data", "")
+	failOn := fs.String("fail-on", "error", "")
+	if err := fs.Parse([]string{"-data", "other"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := applyConfig(fs, path); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := filepath.Join(dir, "content", "quests"); absPath(t, *questsPath) != want {
+		t.Errorf("expected quests path %s from the config, got %s", want, *questsPath)
+	}
+	if filepath.IsAbs(*questsPath) {
+		t.Errorf("expected the quests path to be relative to the current directory, got %s", *questsPath)
+	}
+	if *dataPath != "other" {
+		t.Errorf("expected the -data flag to take precedence, got %s", *dataPath)
+	}
+	if *failOn != "warning" {
+		t.Errorf("expected fail-on warning from the config, got %s", *failOn)
+	}
+}
+
+func TestFindConfig(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "quests", "main")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if path, err := findConfig(sub); err != nil || path != "" {
+		t.Errorf("expected no config, got %q, %v", path, err)
+	}
+	want := writeConfig(t, root, "Quests: quests\n")
+	if path, err := findConfig(sub); err != nil || path != want {
+		t.Errorf("expected %s, got %q, %v", want, path, err)
+	}
+}
+
+func TestValidateCrossQuest_Languages(t *testing.T) {
+	quests := []*Quest{
+		{QuestID: "Quest1", DisplayName: I18nString{EnUS: "My Quest", DeDE: "Meine Quest"}},
+		{QuestID: "Quest2", DisplayName: I18nString{EnUS: "Other Quest", DeDE: "Meine Quest"}},
+	}
+
+	if errs := ValidateCrossQuest(quests, "en-US"); len(errs) != 0 {
+		t.Errorf("expected no issues in en-US, got %v", errs)
+	}
+	if errs := ValidateCrossQuest(quests); len(errs) != 1 || !strings.Contains(errs[0].Message, "(de-DE)") {
+		t.Errorf("expected a duplicate de-DE DisplayName, got %v", errs)
+	}
+}
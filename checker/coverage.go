@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CoverageRecord lists the nodes, Decision options and ConditionBranch
+// arms of a quest that were exercised. It is the format of the per-quest
+// coverage reports as well as of play logs recorded by the game.
+type CoverageRecord struct {
+	QuestID  string      `json:"questId"`
+	Nodes    []int       `json:"nodes"`
+	Options  []OptionRef `json:"options"`
+	Branches []BranchRef `json:"branches"`
+	// Uncovered is written in coverage reports and ignored in play logs.
+	Uncovered *CoverageGaps `json:"uncovered,omitempty"`
+}
+
+// CoverageGaps lists the parts of a quest that were never exercised.
+type CoverageGaps struct {
+	Nodes    []int       `json:"nodes"`
+	Options  []OptionRef `json:"options"`
+	Branches []BranchRef `json:"branches"`
+}
+
+// OptionRef is a 1-based option of a Decision node.
+type OptionRef struct {
+	NodeID int `json:"nodeId"`
+	Option int `json:"option"`
+}
+
+// BranchRef is the "true" or "false" arm of a ConditionBranch node.
+type BranchRef struct {
+	NodeID int    `json:"nodeId"`
+	Arm    string `json:"arm"`
+}
+
+// Coverage collects what was exercised of a single quest across any
+// number of playthroughs.
+type Coverage struct {
+	quest    *Quest
+	nodes    map[int]bool
+	options  map[OptionRef]bool
+	branches map[BranchRef]bool
+}
+
+// NewCoverage creates an empty coverage collector for the quest.
+func NewCoverage(quest *Quest) *Coverage {
+	return &Coverage{
+		quest:    quest,
+		nodes:    make(map[int]bool),
+		options:  make(map[OptionRef]bool),
+		branches: make(map[BranchRef]bool),
+	}
+}
+
+// Attach records everything the interpreter exercises.
+func (c *Coverage) Attach(interp *Interpreter) {
+	interp.OnEnter = func(nodeID int) {
+		c.nodes[nodeID] = true
+	}
+	interp.OnChoose = func(nodeID, option int) {
+		c.options[OptionRef{NodeID: nodeID, Option: option}] = true
+	}
+	interp.OnBranchArm = func(nodeID int, taken bool) {
+		c.branches[BranchRef{NodeID: nodeID, Arm: fmt.Sprint(taken)}] = true
+	}
+}
+
+// Add merges a coverage record, such as an imported play log.
+func (c *Coverage) Add(record *CoverageRecord) {
+	for _, id := range record.Nodes {
+		c.nodes[id] = true
+	}
+	for _, opt := range record.Options {
+		c.options[opt] = true
+	}
+	for _, branch := range record.Branches {
+		c.branches[branch] = true
+	}
+}
+
+// Report returns what was exercised and what was not, in quest order.
+func (c *Coverage) Report() CoverageRecord {
+	record := CoverageRecord{
+		QuestID:   c.quest.QuestID,
+		Nodes:     []int{},
+		Options:   []OptionRef{},
+		Branches:  []BranchRef{},
+		Uncovered: &CoverageGaps{Nodes: []int{}, Options: []OptionRef{}, Branches: []BranchRef{}},
+	}
+	nodes := append([]QuestNode(nil), c.quest.QuestNodes...)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].NodeID < nodes[j].NodeID })
+
+	for _, node := range nodes {
+		if c.nodes[node.NodeID] {
+			record.Nodes = append(record.Nodes, node.NodeID)
+		} else {
+			record.Uncovered.Nodes = append(record.Uncovered.Nodes, node.NodeID)
+		}
+		switch node.NodeType {
+		case "Decision":
+			for i := range node.Options {
+				opt := OptionRef{NodeID: node.NodeID, Option: i + 1}
+				if c.options[opt] {
+					record.Options = append(record.Options, opt)
+				} else {
+					record.Uncovered.Options = append(record.Uncovered.Options, opt)
+				}
+			}
+		case "ConditionBranch":
+			for _, arm := range []string{"true", "false"} {
+				branch := BranchRef{NodeID: node.NodeID, Arm: arm}
+				if c.branches[branch] {
+					record.Branches = append(record.Branches, branch)
+				} else {
+					record.Uncovered.Branches = append(record.Uncovered.Branches, branch)
+				}
+			}
+		}
+	}
+	return record
+}
+
+// runCoverage implements the "coverage" subcommand, which reports which
+// parts of each quest are exercised by the test scenarios and by play
+// logs recorded by the game.
+func runCoverage(args []string) int {
+	fs := flag.NewFlagSet("coverage", flag.ContinueOnError)
+	questsPath := fs.String("quests", "./quests", "Path to quests directory")
+	questID := fs.String("quest", "", "QuestID of the quest to report (default: all quests)")
+	testsPath := fs.String("tests", "", "Path to test scenarios (default: <quests>/tests)")
+	logsPath := fs.String("logs", "", "Path to a directory of play logs (*.json) recorded by the game")
+	jsonPath := fs.String("json", "", "Directory to write <QuestID>.coverage.json reports to")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if _, err := applyConfig(fs, ""); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if *testsPath == "" {
+		*testsPath = filepath.Join(*questsPath, "tests")
+	}
+
+	quests, loadErrors := LoadQuests(*questsPath)
+	files, testErrors := LoadTestFiles(*testsPath)
+	loadErrors = append(loadErrors, testErrors...)
+	var logs []*CoverageRecord
+	if *logsPath != "" {
+		var logErrors []error
+		logs, logErrors = LoadPlayLogs(*logsPath)
+		loadErrors = append(loadErrors, logErrors...)
+	}
+	for _, err := range loadErrors {
+		fmt.Fprintf(os.Stderr, "[LOAD ERROR]: %v\n", err)
+	}
+	if *questID != "" {
+		quest := findQuest(quests, *questID)
+		if quest == nil {
+			fmt.Fprintf(os.Stderr, "Error: quest %q not found\n", *questID)
+			return 2
+		}
+		quests = []*Quest{quest}
+	}
+
+	reports := CollectCoverage(quests, files, logs)
+	printCoverage(os.Stdout, reports)
+	if *jsonPath != "" {
+		if err := writeCoverageReports(*jsonPath, reports); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	}
+	if len(loadErrors) > 0 {
+		return 2
+	}
+	return 0
+}
+
+// CollectCoverage plays all test scenarios, merges the play logs and
+// returns a coverage report for each quest.
+func CollectCoverage(quests []*Quest, files []*TestFile, logs []*CoverageRecord) []CoverageRecord {
+	coverage := make(map[string]*Coverage)
+	for _, quest := range quests {
+		coverage[quest.QuestID] = NewCoverage(quest)
+	}
+	for _, file := range files {
+		c, ok := coverage[file.QuestID]
+		if !ok {
+			continue
+		}
+		for _, scenario := range file.Scenarios {
+			runScenario(c.quest, scenario, c)
+		}
+	}
+	for _, log := range logs {
+		if c, ok := coverage[log.QuestID]; ok {
+			c.Add(log)
+		}
+	}
+
+	reports := make([]CoverageRecord, 0, len(quests))
+	for _, quest := range quests {
+		reports = append(reports, coverage[quest.QuestID].Report())
+	}
+	return reports
+}
+
+// LoadPlayLogs loads all *.json play logs from the given directory.
+func LoadPlayLogs(logsPath string) ([]*CoverageRecord, []error) {
+	var logs []*CoverageRecord
+	var errors []error
+
+	paths, _ := filepath.Glob(filepath.Join(logsPath, "*.json"))
+	sort.Strings(paths)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errors = append(errors, fmt.Errorf("failed to load %s: %w", path, err))
+			continue
+		}
+		var log CoverageRecord
+		if err := json.Unmarshal(data, &log); err != nil {
+			errors = append(errors, fmt.Errorf("failed to load %s: %w", path, err))
+			continue
+		}
+		logs = append(logs, &log)
+	}
+	return logs, errors
+}
+
+func writeCoverageReports(dir string, reports []CoverageRecord) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	for _, report := range reports {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode coverage of %s: %w", report.QuestID, err)
+		}
+		path := filepath.Join(dir, report.QuestID+".coverage.json")
+		if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func printCoverage(out io.Writer, reports []CoverageRecord) {
+	var nodes, options, branches [2]int
+	for i, report := range reports {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		gaps := report.Uncovered
+		fmt.Fprintln(out, report.QuestID)
+		printCoverageLine(out, "Nodes:   ", &nodes, len(report.Nodes), len(gaps.Nodes), describeNodes(gaps.Nodes))
+		printCoverageLine(out, "Options: ", &options, len(report.Options), len(gaps.Options), describeOptions(gaps.Options))
+		printCoverageLine(out, "Branches:", &branches, len(report.Branches), len(gaps.Branches), describeBranches(gaps.Branches))
+	}
+
+	fmt.Fprintln(out, strings.Repeat("-", 40))
+	fmt.Fprintf(out, "Total: nodes %s, options %s, branches %s\n",
+		describeCoverage(nodes[0], nodes[1]), describeCoverage(options[0], options[1]), describeCoverage(branches[0], branches[1]))
+}
+
+// printCoverageLine prints one line of a quest's coverage and adds it to
+// the covered and total counts in sum.
+func printCoverageLine(out io.Writer, label string, sum *[2]int, covered, uncovered int, missing string) {
+	sum[0] += covered
+	sum[1] += covered + uncovered
+	line := fmt.Sprintf("  %s %s", label, describeCoverage(covered, covered+uncovered))
+	if missing != "" {
+		line += "  not covered: " + missing
+	}
+	fmt.Fprintln(out, line)
+}
+
+func describeCoverage(covered, total int) string {
+	if total == 0 {
+		return "0/0"
+	}
+	return fmt.Sprintf("%d/%d (%d%%)", covered, total, covered*100/total)
+}
+
+func describeNodes(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = fmt.Sprint(id)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func describeOptions(options []OptionRef) string {
+	parts := make([]string, len(options))
+	for i, opt := range options {
+		parts[i] = fmt.Sprintf("%d (option %d)", opt.NodeID, opt.Option)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func describeBranches(branches []BranchRef) string {
+	parts := make([]string, len(branches))
+	for i, branch := range branches {
+		parts[i] = fmt.Sprintf("%d (%s)", branch.NodeID, branch.Arm)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCoverage_FromScenarios(t *testing.T) {
+	files := []*TestFile{{
+		QuestID: "TestQuest",
+		Scenarios: []Scenario{{
+			Name:  "Complete",
+			Steps: []ScenarioStep{{Choose: &ChoiceStep{Node: 1, Option: 1}}},
+		}},
+	}}
+
+	reports := CollectCoverage([]*Quest{pathsQuest()}, files, nil)
+
+	if len(reports) != 1 {
+		t.Fatalf("expected 1 report, got %d", len(reports))
+	}
+	report := reports[0]
+	if !reflect.DeepEqual(report.Nodes, []int{0, 1, 2, 3}) {
+		t.Errorf("unexpected covered nodes: %v", report.Nodes)
+	}
+	if !reflect.DeepEqual(report.Options, []OptionRef{{NodeID: 1, Option: 1}}) {
+		t.Errorf("unexpected covered options: %v", report.Options)
+	}
+	if !reflect.DeepEqual(report.Branches, []BranchRef{{NodeID: 2, Arm: "true"}}) {
+		t.Errorf("unexpected covered branches: %v", report.Branches)
+	}
+	want := &CoverageGaps{
+		Nodes:    []int{4, 5},
+		Options:  []OptionRef{{NodeID: 1, Option: 2}},
+		Branches: []BranchRef{{NodeID: 2, Arm: "false"}},
+	}
+	if !reflect.DeepEqual(report.Uncovered, want) {
+		t.Errorf("unexpected gaps: %+v", report.Uncovered)
+	}
+}
+
+func TestCoverage_PlayLogs(t *testing.T) {
+	dir := t.TempDir()
+	playLog := `{
+  "questId": "TestQuest",
+  "nodes": [0, 1, 2, 4],
+  "options": [{"nodeId": 1, "option": 1}],
+  "branches": [{"nodeId": 2, "arm": "false"}]
+}`
+	if err := os.WriteFile(filepath.Join(dir, "session1.json"), []byte(playLog), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	logs, errs := LoadPlayLogs(dir)
+	if len(logs) != 1 || len(errs) != 1 {
+		t.Fatalf("expected 1 log and 1 error, got %d logs and errors %v", len(logs), errs)
+	}
+
+	report := CollectCoverage([]*Quest{pathsQuest()}, nil, logs)[0]
+	if !reflect.DeepEqual(report.Uncovered.Nodes, []int{3, 5}) {
+		t.Errorf("unexpected uncovered nodes: %v", report.Uncovered.Nodes)
+	}
+
+	// A report can be read back as a play log.
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again CoverageRecord
+	if err := json.Unmarshal(data, &again); err != nil {
+		t.Fatal(err)
+	}
+	if roundTrip := CollectCoverage([]*Quest{pathsQuest()}, nil, []*CoverageRecord{&again})[0]; !reflect.DeepEqual(roundTrip, report) {
+		t.Errorf("expected report to round-trip, got %+v", roundTrip)
+	}
+}
+
+func TestPrintCoverage(t *testing.T) {
+	files := []*TestFile{{
+		QuestID:   "TestQuest",
+		Scenarios: []Scenario{{Steps: []ScenarioStep{{Choose: &ChoiceStep{Node: 1, Option: 2}}}}},
+	}}
+	reports := CollectCoverage([]*Quest{pathsQuest()}, files, nil)
+
+	var out bytes.Buffer
+	printCoverage(&out, reports)
+
+	for _, want := range []string{
+		"TestQuest",
+		"  Nodes:    3/6 (50%)  not covered: 2, 3, 4",
+		"  Options:  1/2 (50%)  not covered: 1 (option 1)",
+		"  Branches: 0/2 (0%)  not covered: 2 (true), 2 (false)",
+		"Total: nodes 3/6 (50%), options 1/2 (50%), branches 0/2 (0%)",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
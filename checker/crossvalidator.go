@@ -5,15 +5,20 @@ import (
 	"strings"
 )
 
-// ValidateCrossQuest validates rules that span multiple quests.
-func ValidateCrossQuest(quests []*Quest) []ValidationError {
+// ValidateCrossQuest validates rules that span multiple quests. DisplayNames
+// and stage descriptions must be unique in each of the given languages, or
+// in all supported languages if none are given.
+func ValidateCrossQuest(quests []*Quest, languages ...string) []ValidationError {
 	var errors []ValidationError
 
 	questIDs := buildQuestIDSet(quests)
+	if len(languages) == 0 {
+		languages = supportedLanguages
+	}
 
 	errors = append(errors, validateUniqueQuestIDs(quests)...)
-	errors = append(errors, validateUniqueDisplayNames(quests)...)
-	errors = append(errors, validateUniqueQuestStageDescriptions(quests)...)
+	errors = append(errors, validateUniqueDisplayNames(quests, languages)...)
+	errors = append(errors, validateUniqueQuestStageDescriptions(quests, languages)...)
 	errors = append(errors, validateQuestReferences(quests, questIDs)...)
 
 	return errors
@@ -38,6 +43,7 @@ func validateUniqueQuestIDs(quests []*Quest) []ValidationError {
 	for questID, count := range seen {
 		if count > 1 {
 			errors = append(errors, ValidationError{
+				Code:    "PAT020",
 				Message: fmt.Sprintf("duplicate QuestID %q found %d times", questID, count),
 			})
 		}
@@ -46,78 +52,54 @@ func validateUniqueQuestIDs(quests []*Quest) []ValidationError {
 	return errors
 }
 
-func validateUniqueDisplayNames(quests []*Quest) []ValidationError {
+func validateUniqueDisplayNames(quests []*Quest, languages []string) []ValidationError {
 	var errors []ValidationError
 
-	// Check en-US
-	seenEnUS := make(map[string][]string)
-	for _, q := range quests {
-		if q.DisplayName.EnUS != "" {
-			seenEnUS[q.DisplayName.EnUS] = append(seenEnUS[q.DisplayName.EnUS], q.QuestID)
-		}
-	}
-	for name, questIDs := range seenEnUS {
-		if len(questIDs) > 1 {
-			errors = append(errors, ValidationError{
-				Message: fmt.Sprintf("duplicate DisplayName %q (en-US) in quests: %s", name, strings.Join(questIDs, ", ")),
-			})
-		}
-	}
-
-	// Check de-DE
-	seenDeDE := make(map[string][]string)
-	for _, q := range quests {
-		if q.DisplayName.DeDE != "" {
-			seenDeDE[q.DisplayName.DeDE] = append(seenDeDE[q.DisplayName.DeDE], q.QuestID)
+	for _, lang := range languages {
+		seen := make(map[string][]string)
+		for _, q := range quests {
+			if name := q.DisplayName.In(lang); name != "" {
+				seen[name] = append(seen[name], q.QuestID)
+			}
 		}
-	}
-	for name, questIDs := range seenDeDE {
-		if len(questIDs) > 1 {
-			errors = append(errors, ValidationError{
-				Message: fmt.Sprintf("duplicate DisplayName %q (de-DE) in quests: %s", name, strings.Join(questIDs, ", ")),
-			})
+		for name, questIDs := range seen {
+			if len(questIDs) > 1 {
+				errors = append(errors, ValidationError{
+					Code:    "PAT021",
+					Message: fmt.Sprintf("duplicate DisplayName %q (%s) in quests: %s", name, lang, strings.Join(questIDs, ", ")),
+				})
+			}
 		}
 	}
 
 	return errors
 }
 
-func validateUniqueQuestStageDescriptions(quests []*Quest) []ValidationError {
+func validateUniqueQuestStageDescriptions(quests []*Quest, languages []string) []ValidationError {
 	var errors []ValidationError
 
-	seenEnUS := make(map[string][]string)
-	seenDeDE := make(map[string][]string)
-
-	for _, q := range quests {
-		for _, node := range q.QuestNodes {
-			for _, action := range node.Actions {
-				if actionMap, ok := action.(map[string]interface{}); ok {
-					if qsd, ok := actionMap["QuestStageDescription"].(map[string]interface{}); ok {
-						if enUS, ok := qsd["en-US"].(string); ok && enUS != "" {
-							seenEnUS[enUS] = appendUnique(seenEnUS[enUS], q.QuestID)
-						}
-						if deDE, ok := qsd["de-DE"].(string); ok && deDE != "" {
-							seenDeDE[deDE] = appendUnique(seenDeDE[deDE], q.QuestID)
+	for _, lang := range languages {
+		seen := make(map[string][]string)
+		for _, q := range quests {
+			for _, node := range q.QuestNodes {
+				for _, action := range node.Actions {
+					if actionMap, ok := action.(map[string]interface{}); ok {
+						if qsd, ok := actionMap["QuestStageDescription"].(map[string]interface{}); ok {
+							if desc, ok := qsd[lang].(string); ok && desc != "" {
+								seen[desc] = appendUnique(seen[desc], q.QuestID)
+							}
 						}
 					}
 				}
 			}
 		}
-	}
-
-	for desc, questIDs := range seenEnUS {
-		if len(questIDs) > 1 {
-			errors = append(errors, ValidationError{
-				Message: fmt.Sprintf("duplicate QuestStageDescription %q (en-US) in quests: %s", desc, strings.Join(questIDs, ", ")),
-			})
-		}
-	}
-
-	for desc, questIDs := range seenDeDE {
-		if len(questIDs) > 1 {
-			errors = append(errors, ValidationError{
-				Message: fmt.Sprintf("duplicate QuestStageDescription %q (de-DE) in quests: %s", desc, strings.Join(questIDs, ", ")),
-			})
+		for desc, questIDs := range seen {
+			if len(questIDs) > 1 {
+				errors = append(errors, ValidationError{
+					Code:    "PAT022",
+					Message: fmt.Sprintf("duplicate QuestStageDescription %q (%s) in quests: %s", desc, lang, strings.Join(questIDs, ", ")),
+				})
+			}
 		}
 	}
 
@@ -128,14 +110,17 @@ func validateQuestReferences(quests []*Quest, questIDs map[string]bool) []Valida
 	var errors []ValidationError
 
 	for _, q := range quests {
-		for _, node := range q.QuestNodes {
+		for n, node := range q.QuestNodes {
 			// Check conditions
-			for _, cond := range node.Conditions {
+			for c, cond := range node.Conditions {
 				if qc, ok := cond["QuestCompleted"].(string); ok && qc != "" {
 					if !questIDs[qc] {
 						errors = append(errors, ValidationError{
+							Code:    "PAT023",
 							QuestID: q.QuestID,
 							NodeID:  intPtr(node.NodeID),
+							Path:    q.Path,
+							Field:   fmt.Sprintf("$.QuestNodes[%d].Conditions[%d]", n, c),
 							Message: fmt.Sprintf("QuestCompleted references non-existent quest %q", qc),
 						})
 					}
@@ -143,13 +128,16 @@ func validateQuestReferences(quests []*Quest, questIDs map[string]bool) []Valida
 			}
 
 			// Check dialog option conditions
-			for _, opt := range node.Options {
-				for _, cond := range opt.Conditions {
+			for o, opt := range node.Options {
+				for c, cond := range opt.Conditions {
 					if qc, ok := cond["QuestCompleted"].(string); ok && qc != "" {
 						if !questIDs[qc] {
 							errors = append(errors, ValidationError{
+								Code:    "PAT023",
 								QuestID: q.QuestID,
 								NodeID:  intPtr(node.NodeID),
+								Path:    q.Path,
+								Field:   fmt.Sprintf("$.QuestNodes[%d].Options[%d].Conditions[%d]", n, o, c),
 								Message: fmt.Sprintf("QuestCompleted references non-existent quest %q", qc),
 							})
 						}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fix is a repair the fixer applied to a quest.
+type Fix struct {
+	Code    string
+	NodeID  int
+	Message string
+}
+
+func (f Fix) String() string {
+	return fmt.Sprintf("Node %d: %s: %s", f.NodeID, f.Code, f.Message)
+}
+
+// runFix implements "checker fix": it repairs the issues that have an
+// unambiguous fix in all quests, or in the one given with -quest.
+func runFix(args []string) int {
+	fs := flag.NewFlagSet("fix", flag.ContinueOnError)
+	questsPath := fs.String("quests", "./quests", "Path to quests directory")
+	questID := fs.String("quest", "", "QuestID of the quest to fix (default: all quests)")
+	dryRun := fs.Bool("dry-run", false, "Print the changes as a diff instead of writing them")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if _, err := applyConfig(fs, ""); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	quests, loadErrors := LoadQuests(*questsPath)
+	for _, err := range loadErrors {
+		fmt.Fprintf(os.Stderr, "[LOAD ERROR]: %v\n", err)
+	}
+	if *questID != "" {
+		quest := findQuest(quests, *questID)
+		if quest == nil {
+			fmt.Fprintf(os.Stderr, "Error: quest %q not found\n", *questID)
+			return 2
+		}
+		quests = []*Quest{quest}
+	}
+
+	total := 0
+	for _, quest := range quests {
+		data, err := os.ReadFile(quest.Path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		fixed, fixes, err := FixQuestSource(data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", quest.Path, err)
+			return 2
+		}
+		if len(fixes) == 0 {
+			continue
+		}
+		total += len(fixes)
+
+		if *dryRun {
+			fmt.Print(unifiedDiff(quest.Path, quest.Path, data, fixed))
+			continue
+		}
+		if err := os.WriteFile(quest.Path, fixed, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		fmt.Printf("%s: [%s]\n", quest.Path, quest.QuestID)
+		for _, fix := range fixes {
+			fmt.Printf("  %s\n", fix)
+		}
+	}
+
+	if *dryRun {
+		fmt.Fprintf(os.Stderr, "%d fixes available.\n", total)
+	} else {
+		fmt.Printf("Applied %d fixes.\n", total)
+	}
+	return 0
+}
+
+// FixQuestSource repairs the issues of a quest file that have an
+// unambiguous fix, and returns the repaired file with the applied fixes.
+// The file is edited as a YAML tree, so comments and key order are kept.
+func FixQuestSource(data []byte) ([]byte, []Fix, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, nil, err
+	}
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 {
+		return data, nil, nil
+	}
+	fixes := fixQuest(root.Content[0])
+	if len(fixes) == 0 {
+		return data, nil, nil
+	}
+	fixed, err := encodeYAML(&root, detectIndent(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	return fixed, fixes, nil
+}
+
+// encodeYAML writes a YAML tree with the given indentation.
+func encodeYAML(root *yaml.Node, indent int) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(indent)
+	if err := enc.Encode(root); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// detectIndent returns the indentation of the first indented line of a YAML
+// file, or 4, the indentation of the quest files in this repository.
+func detectIndent(data []byte) int {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if indent := len(line) - len(trimmed); indent > 0 {
+			return indent
+		}
+	}
+	return 4
+}
+
+// fixQuest applies the fixes to the QuestNodes of a quest mapping.
+func fixQuest(quest *yaml.Node) []Fix {
+	nodes := mappingValue(quest, "QuestNodes")
+	if nodes == nil || nodes.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	nodeIDs := make(map[int]bool)
+	for _, node := range nodes.Content {
+		if id, ok := scalarInt(mappingValue(node, "NodeID")); ok {
+			nodeIDs[id] = true
+		}
+	}
+
+	var fixes []Fix
+	for _, node := range nodes.Content {
+		id, ok := scalarInt(mappingValue(node, "NodeID"))
+		if !ok || node.Kind != yaml.MappingNode {
+			continue
+		}
+		add := func(code, format string, args ...interface{}) {
+			fixes = append(fixes, Fix{Code: code, NodeID: id, Message: fmt.Sprintf(format, args...)})
+		}
+
+		nodeType := ""
+		if t := mappingValue(node, "NodeType"); t != nil {
+			nodeType = t.Value
+		}
+		next := mappingValue(node, "NextNodes")
+		hasNext := next != nil && len(next.Content) > 0
+
+		switch {
+		case nodeType == "Decision" && hasNext:
+			// A single option without NextNodes is where they belong
+			options := mappingValue(node, "Options")
+			if options == nil || len(options.Content) != 1 || options.Content[0].Kind != yaml.MappingNode {
+				break
+			}
+			option := options.Content[0]
+			if v := mappingValue(option, "NextNodes"); v != nil && len(v.Content) > 0 {
+				break
+			}
+			removeMappingKey(option, "NextNodes")
+			key := removeMappingKey(node, "NextNodes")
+			option.Content = append(option.Content, key, next)
+			add("PAT011", "moved NextNodes into the only option")
+
+		case nodeType == "ConditionBranch" && hasNext:
+			// If only one of the branches is missing, it is the one meant
+			ifTrue := mappingValue(node, "NextNodesIfTrue")
+			ifFalse := mappingValue(node, "NextNodesIfFalse")
+			missingTrue := ifTrue == nil || len(ifTrue.Content) == 0
+			missingFalse := ifFalse == nil || len(ifFalse.Content) == 0
+			if missingTrue == missingFalse {
+				break
+			}
+			field := "NextNodesIfTrue"
+			if missingFalse {
+				field = "NextNodesIfFalse"
+			}
+			removeMappingKey(node, field)
+			mappingKey(node, "NextNodes").Value = field
+			add("PAT012", "moved top-level NextNodes to %s", field)
+
+		case nodeType == "Actions" && hasNext && isTerminalActions(mappingValue(node, "Actions")):
+			removeMappingKey(node, "NextNodes")
+			add("PAT008", "removed NextNodes from terminal node")
+		}
+
+		// Clean up all edge lists of the node
+		lists := []edgeList{
+			{node, "NextNodes", "NextNodes"},
+			{node, "NextNodesIfTrue", "NextNodesIfTrue"},
+			{node, "NextNodesIfFalse", "NextNodesIfFalse"},
+		}
+		if options := mappingValue(node, "Options"); options != nil && options.Kind == yaml.SequenceNode {
+			for i, option := range options.Content {
+				lists = append(lists, edgeList{option, "NextNodes", fmt.Sprintf("the NextNodes of option %d", i+1)})
+			}
+		}
+		for _, list := range lists {
+			edges := mappingValue(list.owner, list.field)
+			if edges == nil || edges.Kind != yaml.SequenceNode || len(edges.Content) == 0 {
+				continue
+			}
+			seen := make(map[int]bool)
+			kept := edges.Content[:0]
+			for _, edge := range edges.Content {
+				target, ok := scalarInt(edge)
+				switch {
+				case !ok:
+					kept = append(kept, edge)
+				case target == id:
+					add("PAT003", "removed self-reference from %s", list.name)
+				case seen[target]:
+					add("PAT001", "removed duplicate edge to node %d from %s", target, list.name)
+				case !nodeIDs[target]:
+					add("PAT002", "removed edge to non-existent node %d from %s", target, list.name)
+				default:
+					seen[target] = true
+					kept = append(kept, edge)
+				}
+			}
+			edges.Content = kept
+			if len(kept) == 0 {
+				removeMappingKey(list.owner, list.field)
+			}
+		}
+	}
+	return fixes
+}
+
+// edgeList is a list of NodeIDs in field of owner, described by name.
+type edgeList struct {
+	owner *yaml.Node
+	field string
+	name  string
+}
+
+// isTerminalActions reports whether an Actions list contains a terminal
+// action.
+func isTerminalActions(actions *yaml.Node) bool {
+	terminalActions := map[string]bool{
+		"CompleteQuest": true,
+		"FailQuest":     true,
+		"DeclineQuest":  true,
+	}
+
+	if actions == nil {
+		return false
+	}
+	for _, action := range actions.Content {
+		if action.Kind == yaml.ScalarNode && terminalActions[action.Value] {
+			return true
+		}
+	}
+	return false
+}
+
+// mappingKey returns the key node of key in a mapping node, or nil.
+func mappingKey(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i]
+		}
+	}
+	return nil
+}
+
+// mappingValue returns the value of key in a mapping node, or nil.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// removeMappingKey removes key from a mapping node and returns its key
+// node, or nil if the mapping has no such key.
+func removeMappingKey(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			k := m.Content[i]
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return k
+		}
+	}
+	return nil
+}
+
+// scalarInt returns the integer value of a scalar node.
+func scalarInt(n *yaml.Node) (int, bool) {
+	if n == nil || n.Kind != yaml.ScalarNode {
+		return 0, false
+	}
+	i, err := strconv.Atoi(n.Value)
+	return i, err == nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestFixQuestSource(t *testing.T) {
+	source := `# Comments are kept
+QuestTypeVersion: 1
+QuestVersion: 1
+QuestID: Broken
+QuestType: SideQuest
+DisplayName:
+    en-US: Broken
+    de-DE: Kaputt
+QuestNodes:
+    - NodeID: 0
+      NodeType: EntryPoint
+      NextNodes: [1, 1, 0, 99]
+    - NodeID: 1
+      NodeType: Decision
+      NextNodes:
+        - 2 # the only way
+      Options:
+        - Text:
+            en-US: Go
+            de-DE: Los
+    - NodeID: 2
+      NodeType: ConditionBranch
+      Conditions:
+        - QuestCompleted: Other
+      NextNodes: [3]
+      NextNodesIfTrue: [3]
+    - NodeID: 3
+      NodeType: Actions
+      Actions:
+        - CompleteQuest
+      NextNodes:
+        - 3
+`
+	want := `# Comments are kept
+QuestTypeVersion: 1
+QuestVersion: 1
+QuestID: Broken
+QuestType: SideQuest
+DisplayName:
+    en-US: Broken
+    de-DE: Kaputt
+QuestNodes:
+    - NodeID: 0
+      NodeType: EntryPoint
+      NextNodes: [1]
+    - NodeID: 1
+      NodeType: Decision
+      Options:
+        - Text:
+            en-US: Go
+            de-DE: Los
+          NextNodes:
+            - 2 # the only way
+    - NodeID: 2
+      NodeType: ConditionBranch
+      Conditions:
+        - QuestCompleted: Other
+      NextNodesIfFalse: [3]
+      NextNodesIfTrue: [3]
+    - NodeID: 3
+      NodeType: Actions
+      Actions:
+        - CompleteQuest
+`
+
+	fixed, fixes, err := FixQuestSource([]byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(fixed) != want {
+		t.Errorf("unexpected result:\n%s", unifiedDiff("want", "got", []byte(want), fixed))
+	}
+
+	var codes []string
+	for _, fix := range fixes {
+		codes = append(codes, fix.Code)
+	}
+	if want := []string{"PAT001", "PAT003", "PAT002", "PAT011", "PAT012", "PAT008"}; !reflect.DeepEqual(codes, want) {
+		t.Errorf("expected fixes %v, got %v", want, fixes)
+	}
+
+	// Fixed quests need no more fixes
+	if _, fixes, err := FixQuestSource(fixed); err != nil || len(fixes) != 0 {
+		t.Errorf("expected no further fixes, got %v, %v", fixes, err)
+	}
+}
+
+func TestFixQuestSource_Ambiguous(t *testing.T) {
+	source := `QuestID: Ambiguous
+QuestNodes:
+    - NodeID: 1
+      NodeType: Decision
+      NextNodes: [2]
+      Options:
+        - Text: { en-US: A, de-DE: A }
+        - Text: { en-US: B, de-DE: B }
+    - NodeID: 2
+      NodeType: ConditionBranch
+      NextNodes: [1]
+`
+	fixed, fixes, err := FixQuestSource([]byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixes) != 0 || string(fixed) != source {
+		t.Errorf("expected no fixes, got %v", fixes)
+	}
+}
+
+func TestFixQuestSource_RepositoryQuests(t *testing.T) {
+	quests, errs := LoadQuests("../quests")
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	for _, quest := range quests {
+		data, err := os.ReadFile(quest.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, fixes, err := FixQuestSource(data); err != nil || len(fixes) != 0 {
+			t.Errorf("%s: expected no fixes, got %v, %v", quest.Path, fixes, err)
+		}
+	}
+}
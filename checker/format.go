@@ -0,0 +1,202 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// canonicalIndent is the indentation of formatted quest files.
+const canonicalIndent = 4
+
+// keyOrder lists the canonical key order of the mappings in a quest file,
+// by the key the mapping (or the list of mappings) belongs to. The root
+// mapping has the key "". The order is that of the quest schema; keys that
+// are not listed follow in their original order.
+var keyOrder = map[string][]string{
+	"": {"QuestTypeVersion", "QuestVersion", "QuestID", "QuestType", "DisplayName", "Repeatable", "QuestNodes", "Migrations", "Suppressions"},
+	"QuestNodes": {
+		"NodeID", "NodeType", "NextNodes", "NextNodesIfTrue", "NextNodesIfFalse",
+		"Conditions", "ConditionsRequired", "ConversationPartner", "Speaker", "Text",
+		"Options", "Messages", "Actions", "Suppressions",
+	},
+	"Options":      {"Text", "Conditions", "NextNodes"},
+	"Messages":     {"Speaker", "Text"},
+	"Suppressions": {"Rule", "Reason"},
+	"Migrations":   {"FromVersion", "Nodes", "Variables"},
+	"Nodes":        {"From", "To"},
+	"Variables":    {"From", "To"},
+
+	// Conditions and actions
+	"ResourceAvailability": {"Resource", "Available"},
+	"FactionStanding":      {"Faction", "MinimumLevel", "MaximumLevel", "Points"},
+	"Inventory":            {"Type", "MinCount", "QuestItem"},
+	"ItemsGained":          {"Type", "Count", "QuestItem"},
+	"ItemsLost":            {"Type", "Count", "QuestItem"},
+	"Variable":             {"VariableName", "Comparison", "Value"},
+	"SetVariable":          {"VariableName", "Operation", "Value"},
+	"EventTriggered":       {"Event", "Count"},
+	"ItemUsedOnObject":     {"Item", "Object"},
+	"ItemUsedOnNPC":        {"Item", "NPC"},
+}
+
+// runFmt implements "checker fmt": it rewrites quest files into the
+// canonical layout, or with -check only lists the ones that differ.
+func runFmt(args []string) int {
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	questsPath := fs.String("quests", "./quests", "Path to quests directory")
+	check := fs.Bool("check", false, "List unformatted files instead of rewriting them, and exit 1 if there are any")
+	diff := fs.Bool("diff", false, "Print the changes as a diff instead of rewriting the files")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if _, err := applyConfig(fs, ""); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	paths, err := listQuestFiles(*questsPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	unformatted := 0
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		formatted, err := FormatQuestSource(data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", path, err)
+			return 2
+		}
+		if string(formatted) == string(data) {
+			continue
+		}
+		unformatted++
+
+		switch {
+		case *diff:
+			fmt.Print(unifiedDiff(path, path, data, formatted))
+		case *check:
+			fmt.Println(path)
+		default:
+			if err := os.WriteFile(path, formatted, 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 2
+			}
+			fmt.Println(path)
+		}
+	}
+
+	if *check && unformatted > 0 {
+		return 1
+	}
+	return 0
+}
+
+// FormatQuestSource returns a quest file in the canonical layout: keys in
+// schema order, QuestNodes sorted by NodeID, texts in the order of
+// supportedLanguages, multi-line texts as literal block scalars, block
+// style lists and mappings, and an indentation of four spaces. Comments
+// are kept.
+func FormatQuestSource(data []byte) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 {
+		return data, nil
+	}
+	formatNode(root.Content[0], "")
+	return encodeYAML(&root, canonicalIndent)
+}
+
+// formatNode brings node, found under key, into the canonical layout.
+func formatNode(node *yaml.Node, key string) {
+	// The line comment of a flow collection belongs to its last line once
+	// it is written in block style
+	if node.Style&yaml.FlowStyle != 0 && node.LineComment != "" && len(node.Content) > 0 {
+		if last := node.Content[len(node.Content)-1]; last.LineComment == "" {
+			last.LineComment, node.LineComment = node.LineComment, ""
+		}
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		node.Style = 0
+		if isI18nMapping(node) {
+			sortMapping(node, supportedLanguages)
+		} else {
+			sortMapping(node, keyOrder[key])
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			node.Content[i].Style = 0
+			formatNode(node.Content[i+1], node.Content[i].Value)
+		}
+	case yaml.SequenceNode:
+		node.Style = 0
+		if key == "QuestNodes" {
+			sort.SliceStable(node.Content, func(i, j int) bool {
+				a, okA := scalarInt(mappingValue(node.Content[i], "NodeID"))
+				b, okB := scalarInt(mappingValue(node.Content[j], "NodeID"))
+				return okA && (!okB || a < b)
+			})
+		}
+		for _, item := range node.Content {
+			formatNode(item, key)
+		}
+	case yaml.ScalarNode:
+		// Multi-line texts are literal block scalars, all others plain
+		// unless they need quotes
+		if strings.Contains(node.Value, "\n") {
+			node.Style = yaml.LiteralStyle
+		} else {
+			node.Style = 0
+		}
+	}
+}
+
+// isI18nMapping reports whether all keys of a mapping are languages.
+func isI18nMapping(node *yaml.Node) bool {
+	if len(node.Content) == 0 {
+		return false
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if !contains(supportedLanguages, node.Content[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortMapping orders the keys of a mapping by order. Keys that are not in
+// order keep their relative order after the others.
+func sortMapping(node *yaml.Node, order []string) {
+	rank := func(key string) int {
+		for i, k := range order {
+			if k == key {
+				return i
+			}
+		}
+		return len(order)
+	}
+	pairs := make([][2]*yaml.Node, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return rank(pairs[i][0].Value) < rank(pairs[j][0].Value)
+	})
+	node.Content = node.Content[:0]
+	for _, pair := range pairs {
+		node.Content = append(node.Content, pair[0], pair[1])
+	}
+}
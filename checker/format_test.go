@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFormatQuestSource(t *testing.T) {
+	source := `QuestID: Messy
+QuestVersion: 1
+QuestTypeVersion: 1
+QuestType: SideQuest
+DisplayName: { de-DE: Unordentlich, en-US: Messy }
+QuestNodes:
+  # The end
+  - NodeID: 2
+    Actions:
+      - ItemsGained: [{ Count: 2, Type: "PackOfNails" }]
+      - CompleteQuest
+    NodeType: Actions
+  - NodeType: Dialog
+    NodeID: 1
+    NextNodes: [2]
+    Messages:
+      - Text:
+          de-DE: "Hallo.\nWie geht's?"
+          en-US: 'Hello.'
+        Speaker: NPC:Smith
+  - NodeID: 0
+    NodeType: EntryPoint
+    NextNodes: [1] # go
+`
+	want := `QuestTypeVersion: 1
+QuestVersion: 1
+QuestID: Messy
+QuestType: SideQuest
+DisplayName:
+    en-US: Messy
+    de-DE: Unordentlich
+QuestNodes:
+    - NodeID: 0
+      NodeType: EntryPoint
+      NextNodes:
+        - 1 # go
+    - NodeID: 1
+      NodeType: Dialog
+      NextNodes:
+        - 2
+      Messages:
+        - Speaker: NPC:Smith
+          Text:
+            en-US: Hello.
+            de-DE: |-
+                Hallo.
+                Wie geht's?
+    # The end
+    - NodeID: 2
+      NodeType: Actions
+      Actions:
+        - ItemsGained:
+            - Type: PackOfNails
+              Count: 2
+        - CompleteQuest
+`
+
+	formatted, err := FormatQuestSource([]byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(formatted) != want {
+		t.Errorf("unexpected result:\n%s", unifiedDiff("want", "got", []byte(want), formatted))
+	}
+
+	again, err := FormatQuestSource(formatted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(formatted) {
+		t.Errorf("formatting is not idempotent:\n%s", unifiedDiff("first", "second", formatted, again))
+	}
+}
+
+func TestFormatQuestSource_RepositoryQuests(t *testing.T) {
+	paths, err := listQuestFiles("../quests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		formatted, err := FormatQuestSource(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(formatted) != string(data) {
+			t.Errorf("%s is not formatted, run checker fmt:\n%s", path, unifiedDiff(path, path, data, formatted))
+		}
+	}
+}
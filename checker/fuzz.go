@@ -0,0 +1,388 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+)
+
+// maxFuzzSteps limits the number of player steps in a single randomized
+// playthrough.
+const maxFuzzSteps = 1000
+
+// fuzzFinding is a problem found by randomized playthroughs, with the
+// first playthrough that ran into it.
+type fuzzFinding struct {
+	Code     string
+	NodeID   *int
+	Message  string
+	Detail   string
+	Severity Severity
+	Runs     int
+	Seed     int64
+	Trace    []string
+}
+
+// fuzzProblem is a problem found in a single playthrough. Problems with
+// the same message are counted as one finding.
+type fuzzProblem struct {
+	Code    string
+	NodeID  *int // nil for problems of the whole quest
+	Message string
+	Detail  string
+}
+
+// runFuzz implements the "fuzz" subcommand, which plays through quests
+// many times with random choices and reports semantic deadlocks that the
+// structural checks cannot see.
+func runFuzz(args []string) int {
+	fs := flag.NewFlagSet("fuzz", flag.ContinueOnError)
+	questsPath := fs.String("quests", "./quests", "Path to quests directory")
+	questID := fs.String("quest", "", "QuestID of the quest to fuzz (default: all quests)")
+	runs := fs.Int("runs", 1000, "Number of playthroughs per quest")
+	seed := fs.Int64("seed", 1, "Seed of the first playthrough; playthrough i uses seed+i")
+	failOnName := fs.String("fail-on", "error", "Lowest severity of findings that makes the command fail: error, warning or info")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	config, err := applyConfig(fs, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	failOn, err := ParseSeverity(*failOnName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	quests, loadErrors := LoadQuests(*questsPath)
+	for _, err := range loadErrors {
+		fmt.Fprintf(os.Stderr, "[LOAD ERROR]: %v\n", err)
+	}
+	if *questID != "" {
+		quest := findQuest(quests, *questID)
+		if quest == nil {
+			fmt.Fprintf(os.Stderr, "Error: quest %q not found\n", *questID)
+			return 2
+		}
+		quests = []*Quest{quest}
+	}
+
+	found := false
+	for i, quest := range quests {
+		if i > 0 {
+			fmt.Println()
+		}
+		findings := filterFindings(Fuzz(quest, *runs, *seed), quest, config)
+		printFindings(os.Stdout, quest, findings, *runs)
+		for _, f := range findings {
+			found = found || f.Severity <= failOn
+		}
+	}
+	if found || len(loadErrors) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// Fuzz plays through the quest runs times. The waiting Dialog, Decision
+// or ConditionWatcher node that progresses next, the chosen Decision
+// option and the arm of a ConditionBranch are picked at random.
+//
+// Conditions on variables that the quest sets to fixed values are
+// evaluated against the playthrough, since only the quest changes them.
+// All other conditions depend on the world, which may or may not satisfy
+// them. A node can progress if its conditions can hold that way, so a
+// playthrough is stuck only if no world could move it on.
+//
+// Findings have the severity of their rule: playthroughs that get stuck
+// (PAT040), complete the quest without accepting it (PAT041) or end it
+// while other nodes are still active (PAT042).
+func Fuzz(quest *Quest, runs int, seed int64) []fuzzFinding {
+	var findings []*fuzzFinding
+	index := make(map[string]*fuzzFinding)
+
+	for i := 0; i < runs; i++ {
+		runSeed := seed + int64(i)
+		run := &fuzzRun{rng: rand.New(rand.NewSource(runSeed)), owned: ownedVariables(quest)}
+		seen := make(map[string]bool)
+		for _, problem := range run.play(quest) {
+			message := problem.Message
+			if seen[message] {
+				continue
+			}
+			seen[message] = true
+			if f, ok := index[message]; ok {
+				f.Runs++
+				continue
+			}
+			f := &fuzzFinding{
+				Code:     problem.Code,
+				NodeID:   problem.NodeID,
+				Message:  message,
+				Detail:   problem.Detail,
+				Severity: ruleSeverity(problem.Code),
+				Runs:     1,
+				Seed:     runSeed,
+				Trace:    run.trace,
+			}
+			index[message] = f
+			findings = append(findings, f)
+		}
+	}
+
+	result := make([]fuzzFinding, len(findings))
+	for i, f := range findings {
+		result[i] = *f
+	}
+	return result
+}
+
+// ownedVariables returns the variables that the quest only ever sets to
+// fixed values. Variables it increases or decreases are counters that
+// other quests may share.
+func ownedVariables(quest *Quest) map[string]bool {
+	owned := make(map[string]bool)
+	shared := make(map[string]bool)
+	for _, node := range quest.QuestNodes {
+		for _, action := range node.Actions {
+			m, _ := action.(map[string]interface{})
+			sv, ok := m["SetVariable"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := sv["VariableName"].(string)
+			if sv["Operation"] == "set to" && !shared[name] {
+				owned[name] = true
+			} else {
+				shared[name] = true
+				delete(owned, name)
+			}
+		}
+	}
+	return owned
+}
+
+// fuzzRun is a single randomized playthrough.
+type fuzzRun struct {
+	rng      *rand.Rand
+	interp   *Interpreter
+	owned    map[string]bool
+	firing   int
+	trace    []string
+	problems []fuzzProblem
+}
+
+func (r *fuzzRun) play(quest *Quest) []fuzzProblem {
+	r.interp = NewInterpreter(quest, NewWorld())
+	r.interp.Decide = r.decide
+	r.interp.OnAction = r.checkAction
+	r.firing = -1
+
+	r.interp.Start()
+	for steps := 0; !r.interp.Ended(); steps++ {
+		if steps == maxFuzzSteps {
+			r.report("PAT040", nil, fmt.Sprintf("the quest does not end within %d steps", maxFuzzSteps), "")
+			break
+		}
+		waiting := r.waitingNodes()
+		if len(waiting) == 0 {
+			r.reportStuck()
+			break
+		}
+		if !r.progress(r.interp.Node(waiting[r.rng.Intn(len(waiting))])) {
+			break
+		}
+	}
+	return r.problems
+}
+
+// waitingNodes returns the active nodes that wait for the player or for
+// their conditions, and that some world lets progress.
+func (r *fuzzRun) waitingNodes() []int {
+	var ids []int
+	for _, id := range r.interp.Active() {
+		if r.canProgress(r.interp.Node(id)) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (r *fuzzRun) canProgress(node *QuestNode) bool {
+	switch node.NodeType {
+	case "Dialog", "ConditionWatcher":
+		return r.canHold(node.NodeID, node.Conditions, node.ConditionsRequired)
+	case "Decision":
+		for _, opt := range node.Options {
+			if r.canHold(node.NodeID, opt.Conditions, "all") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// reportStuck reports the active nodes of a playthrough in which no node
+// can progress anymore.
+func (r *fuzzRun) reportStuck() {
+	active := r.interp.Active()
+	if len(active) == 0 {
+		r.report("PAT040", nil, "no node is active but the quest has not ended", "")
+		return
+	}
+	var stuck []int
+	for _, id := range active {
+		if r.interp.Node(id).NodeType == "Decision" {
+			r.report("PAT040", intPtr(id), fmt.Sprintf("Decision node %d can offer no option", id), "")
+		} else {
+			stuck = append(stuck, id)
+		}
+	}
+	if len(stuck) > 0 {
+		r.report("PAT040", nil, fmt.Sprintf("nodes %v are active but can never progress", stuck), "")
+	}
+}
+
+// progress lets a waiting node move on. It returns false if the
+// playthrough is stuck at the node.
+func (r *fuzzRun) progress(node *QuestNode) bool {
+	r.firing = node.NodeID
+	defer func() { r.firing = -1 }()
+
+	var err error
+	switch node.NodeType {
+	case "Dialog":
+		r.trace = append(r.trace, fmt.Sprintf("talk %d", node.NodeID))
+		err = r.interp.Talk(node.NodeID)
+	case "ConditionWatcher":
+		r.trace = append(r.trace, fmt.Sprintf("watcher %d fires", node.NodeID))
+		r.interp.Settle()
+	case "Decision":
+		options := r.interp.AvailableOptions(node.NodeID)
+		if len(options) == 0 {
+			r.report("PAT040", intPtr(node.NodeID), fmt.Sprintf("Decision node %d can offer no option", node.NodeID), "")
+			return false
+		}
+		option := options[r.rng.Intn(len(options))]
+		r.trace = append(r.trace, fmt.Sprintf("choose %d option %d", node.NodeID, option))
+		err = r.interp.Choose(node.NodeID, option)
+	}
+	if err != nil {
+		r.report("PAT040", intPtr(node.NodeID), err.Error(), "")
+		return false
+	}
+	return true
+}
+
+// conditionBounds counts the conditions that hold for certain, because
+// they are on variables owned by the quest, and those that the world may
+// or may not satisfy.
+func (r *fuzzRun) conditionBounds(nodeID int, conditions []map[string]interface{}) (certain, open int) {
+	for _, cond := range conditions {
+		v, _ := cond["Variable"].(map[string]interface{})
+		name, _ := v["VariableName"].(string)
+		switch {
+		case !r.owned[name]:
+			open++
+		case r.interp.conditionHolds(nodeID, cond):
+			certain++
+		}
+	}
+	return certain, open
+}
+
+// canHold reports whether some world satisfies the conditions.
+func (r *fuzzRun) canHold(nodeID int, conditions []map[string]interface{}, required string) bool {
+	certain, open := r.conditionBounds(nodeID, conditions)
+	return certain+open >= requiredCount(required, len(conditions))
+}
+
+// decide evaluates the conditions of a node. Conditions that hold or fail
+// whatever the world does are decided. Otherwise ConditionBranch nodes
+// take a random arm, and the conditions of other nodes hold only for the
+// node that is progressing.
+func (r *fuzzRun) decide(node *QuestNode, conditions []map[string]interface{}, required string) bool {
+	certain, open := r.conditionBounds(node.NodeID, conditions)
+	need := requiredCount(required, len(conditions))
+	switch {
+	case certain >= need:
+		return true
+	case certain+open < need:
+		return false
+	case node.NodeType != "ConditionBranch":
+		return node.NodeID == r.firing
+	}
+	outcome := r.rng.Intn(2) == 0
+	r.trace = append(r.trace, fmt.Sprintf("branch %d %v", node.NodeID, outcome))
+	return outcome
+}
+
+func (r *fuzzRun) checkAction(nodeID int, action interface{}) {
+	switch action {
+	case "CompleteQuest", "FailQuest", "DeclineQuest":
+	default:
+		return
+	}
+	if action == "CompleteQuest" && !r.interp.Accepted() {
+		r.report("PAT041", intPtr(nodeID), fmt.Sprintf("node %d completes the quest before AcceptQuest was executed", nodeID), "")
+	}
+	var others []int
+	for _, id := range r.interp.Active() {
+		if id != nodeID {
+			others = append(others, id)
+		}
+	}
+	if len(others) > 0 {
+		r.report("PAT042", intPtr(nodeID), fmt.Sprintf("node %d ends the quest with %s while other nodes are still active", nodeID, action),
+			fmt.Sprintf("still active: %v", others))
+	}
+}
+
+func (r *fuzzRun) report(code string, nodeID *int, message, detail string) {
+	r.problems = append(r.problems, fuzzProblem{Code: code, NodeID: nodeID, Message: message, Detail: detail})
+}
+
+// filterFindings drops the findings whose rule the quest suppresses or the
+// configuration turns off, and sets the configured severities.
+func filterFindings(findings []fuzzFinding, quest *Quest, config *Config) []fuzzFinding {
+	var kept []fuzzFinding
+	for _, f := range findings {
+		if quest.suppresses(f.Code, f.NodeID) {
+			continue
+		}
+		issues := config.applyRules([]ValidationError{{
+			Code:     f.Code,
+			Severity: f.Severity,
+			QuestID:  quest.QuestID,
+			NodeID:   f.NodeID,
+			Path:     quest.Path,
+			Message:  f.Message,
+		}})
+		if len(issues) == 0 {
+			continue
+		}
+		f.Severity = issues[0].Severity
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+func printFindings(out io.Writer, quest *Quest, findings []fuzzFinding, runs int) {
+	fmt.Fprintf(out, "%s: %d playthroughs, %d findings\n", quest.QuestID, runs, len(findings))
+	for _, f := range findings {
+		fmt.Fprintf(out, "  - %s %s: %s (%d of %d playthroughs)\n", f.Severity, f.Code, f.Message, f.Runs, runs)
+		trace := "no steps"
+		if len(f.Trace) > 0 {
+			trace = strings.Join(f.Trace, ", ")
+		}
+		if f.Detail != "" {
+			trace += " (" + f.Detail + ")"
+		}
+		fmt.Fprintf(out, "    first seen with seed %d: %s\n", f.Seed, trace)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func fuzzMessages(findings []fuzzFinding) []string {
+	messages := make([]string, len(findings))
+	for i, f := range findings {
+		messages[i] = f.Message
+	}
+	return messages
+}
+
+func TestFuzz_CleanQuest(t *testing.T) {
+	quest := &Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Decision", Options: []DialogOption{
+				{NextNodes: []int{2}},
+				{NextNodes: []int{5}},
+			}},
+			{NodeID: 2, NodeType: "Actions", NextNodes: []int{3}, Actions: []interface{}{"AcceptQuest"}},
+			{NodeID: 3, NodeType: "ConditionWatcher", NextNodes: []int{4}, Conditions: []map[string]interface{}{
+				{"EventTriggered": map[string]interface{}{"Event": "Storm"}},
+			}},
+			{NodeID: 4, NodeType: "Actions", Actions: []interface{}{"CompleteQuest"}},
+			{NodeID: 5, NodeType: "Actions", Actions: []interface{}{"DeclineQuest"}},
+		},
+	}
+
+	if findings := Fuzz(quest, 200, 1); len(findings) != 0 {
+		t.Errorf("expected no findings, got %v", fuzzMessages(findings))
+	}
+}
+
+// The sample quests wait for the world in parallel branches, which some
+// world always lets progress, so they never get stuck.
+func TestFuzz_SampleQuestsDoNotGetStuck(t *testing.T) {
+	quests, errs := LoadQuests("../quests")
+	if len(errs) > 0 {
+		t.Fatalf("failed to load sample quests: %v", errs)
+	}
+	for _, quest := range quests {
+		for _, f := range Fuzz(quest, 500, 1) {
+			if f.Code == "PAT040" {
+				t.Errorf("%s: unexpected finding %q", quest.QuestID, f.Message)
+			}
+		}
+	}
+}
+
+func TestFuzz_SemanticDeadlocks(t *testing.T) {
+	paid := func(value int) []map[string]interface{} {
+		return []map[string]interface{}{{"Variable": map[string]interface{}{"VariableName": "Paid", "Comparison": "equal", "Value": value}}}
+	}
+	quest := &Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "ConditionBranch", NextNodesIfTrue: []int{2}, NextNodesIfFalse: []int{3}, Conditions: []map[string]interface{}{
+				{"QuestCompleted": "OtherQuest"},
+			}},
+			{NodeID: 2, NodeType: "Actions", NextNodes: []int{5}},
+			{NodeID: 3, NodeType: "Decision", Options: []DialogOption{
+				{NextNodes: []int{7}},
+				{NextNodes: []int{4}},
+				{NextNodes: []int{11}},
+				{NextNodes: []int{6}, Conditions: paid(2)},
+			}},
+			{NodeID: 4, NodeType: "Dialog", NextNodes: []int{6}},
+			{NodeID: 5, NodeType: "ConditionWatcher", NextNodes: []int{10}, Conditions: []map[string]interface{}{
+				{"TimePassed": "1d"},
+			}},
+			{NodeID: 6, NodeType: "Actions", Actions: []interface{}{"CompleteQuest"}},
+			// Paid is only ever set to 1 by an unreachable node, so the
+			// conditions on it can never hold.
+			{NodeID: 7, NodeType: "ConditionWatcher", NextNodes: []int{6}, Conditions: paid(1)},
+			{NodeID: 8, NodeType: "Actions", Actions: []interface{}{
+				map[string]interface{}{"SetVariable": map[string]interface{}{"VariableName": "Paid", "Operation": "set to", "Value": 1}},
+			}},
+			{NodeID: 10, NodeType: "Decision", Options: []DialogOption{
+				{NextNodes: []int{6}, Conditions: paid(1)},
+			}},
+			{NodeID: 11, NodeType: "Dialog"},
+		},
+	}
+
+	findings := Fuzz(quest, 200, 1)
+
+	got := fuzzMessages(findings)
+	want := []string{
+		"Decision node 10 can offer no option",
+		"nodes [7] are active but can never progress",
+		"node 6 completes the quest before AcceptQuest was executed",
+		"no node is active but the quest has not ended",
+	}
+	for _, message := range want {
+		found := false
+		for _, g := range got {
+			found = found || g == message
+		}
+		if !found {
+			t.Errorf("expected finding %q, got %v", message, got)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("expected %d findings, got %v", len(want), got)
+	}
+	for _, f := range findings {
+		if f.Severity != SeverityError {
+			t.Errorf("expected %q to be an error, got %v", f.Message, f.Severity)
+		}
+	}
+}
+
+func activeAtEndQuest() *Quest {
+	return &Quest{
+		QuestID: "TestQuest",
+		Path:    "quests/test.yaml",
+		QuestNodes: []QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1, 2}},
+			{NodeID: 1, NodeType: "Dialog", NextNodes: []int{3}},
+			{NodeID: 2, NodeType: "ConditionWatcher", NextNodes: []int{4}, Conditions: []map[string]interface{}{
+				{"ItemLost": "Letter"},
+			}},
+			{NodeID: 3, NodeType: "Actions", Actions: []interface{}{"AcceptQuest", "CompleteQuest"}},
+			{NodeID: 4, NodeType: "Actions", Actions: []interface{}{"FailQuest"}},
+		},
+	}
+}
+
+func TestFuzz_ReportsActiveNodesAtEnd(t *testing.T) {
+	findings := Fuzz(activeAtEndQuest(), 50, 1)
+
+	got := fuzzMessages(findings)
+	want := []string{
+		"node 3 ends the quest with CompleteQuest while other nodes are still active",
+		"node 4 ends the quest with FailQuest while other nodes are still active",
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected findings %v, got %v", want, got)
+	}
+	for _, f := range findings {
+		if f.Code != "PAT042" || f.Severity != SeverityError {
+			t.Errorf("expected %q to be a PAT042 error, got %s %v", f.Message, f.Code, f.Severity)
+		}
+	}
+}
+
+func TestFilterFindings(t *testing.T) {
+	quest := activeAtEndQuest()
+	findings := Fuzz(quest, 50, 1)
+
+	// A node suppression only drops the findings of its node
+	quest.QuestNodes[4].Suppressions = []Suppression{{Rule: "PAT042", Reason: "losing the letter fails the quest at once"}}
+	if got := fuzzMessages(filterFindings(findings, quest, nil)); !reflect.DeepEqual(got, []string{
+		"node 3 ends the quest with CompleteQuest while other nodes are still active",
+	}) {
+		t.Errorf("unexpected findings with a node suppression: %v", got)
+	}
+	quest.QuestNodes[4].Suppressions = []Suppression{{Rule: "PAT042"}}
+	if got := filterFindings(findings, quest, nil); len(got) != 2 {
+		t.Errorf("expected a suppression without a reason to be ignored, got %v", fuzzMessages(got))
+	}
+
+	// The configuration turns rules off or changes their severity
+	config := &Config{Rules: RuleSettings{"PAT042": "warning"}}
+	for _, f := range filterFindings(findings, quest, config) {
+		if f.Severity != SeverityWarning {
+			t.Errorf("expected %q to be a warning, got %v", f.Message, f.Severity)
+		}
+	}
+	config = &Config{Overrides: []ConfigOverride{{Path: "quests", Rules: RuleSettings{"PAT042": "off"}}}}
+	if got := filterFindings(findings, quest, config); len(got) != 0 {
+		t.Errorf("expected the override to turn PAT042 off, got %v", fuzzMessages(got))
+	}
+}
+
+func TestFuzz_Reproducible(t *testing.T) {
+	quest := pathsQuest()
+
+	first := Fuzz(quest, 50, 7)
+	second := Fuzz(quest, 50, 7)
+
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("expected the same seed to give the same findings:\n%+v\n%+v", first, second)
+	}
+	for _, f := range first {
+		replay := Fuzz(quest, 1, f.Seed)
+		if len(replay) == 0 || !reflect.DeepEqual(replay[0].Trace, f.Trace) {
+			t.Errorf("expected seed %d to replay %v, got %+v", f.Seed, f.Trace, replay)
+		}
+	}
+}
+
+func TestPrintFindings(t *testing.T) {
+	quest := &Quest{QuestID: "TestQuest"}
+	findings := []fuzzFinding{{
+		Code:     "PAT042",
+		Message:  "node 4 ends the quest with FailQuest while other nodes are still active",
+		Detail:   "still active: [2]",
+		Severity: SeverityWarning,
+		Runs:     3,
+		Seed:     12,
+		Trace:    []string{"choose 1 option 2", "branch 3 false"},
+	}}
+
+	var out bytes.Buffer
+	printFindings(&out, quest, findings, 10)
+
+	for _, want := range []string{
+		"TestQuest: 10 playthroughs, 1 findings",
+		"  - warning PAT042: node 4 ends the quest with FailQuest while other nodes are still active (3 of 10 playthroughs)",
+		"    first seen with seed 12: choose 1 option 2, branch 3 false (still active: [2])",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,421 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Quest statuses reported by the interpreter.
+const (
+	statusActive    = "active"
+	statusCompleted = "completed"
+	statusFailed    = "failed"
+	statusDeclined  = "declined"
+)
+
+// World holds the facts about the game world that quest conditions
+// depend on. Actions executed by the interpreter modify it.
+type World struct {
+	CompletedQuests map[string]bool
+	Resources       map[string]bool
+	Factions        map[string]int
+	Inventory       map[string]int
+	QuestItems      map[string]int
+	Variables       map[string]int
+	Currency        int
+	Experience      int
+}
+
+// NewWorld creates an empty world.
+func NewWorld() *World {
+	return &World{
+		CompletedQuests: make(map[string]bool),
+		Resources:       make(map[string]bool),
+		Factions:        make(map[string]int),
+		Inventory:       make(map[string]int),
+		QuestItems:      make(map[string]int),
+		Variables:       make(map[string]int),
+	}
+}
+
+// NodeProgress tracks what happened since a node became active. Time and
+// events only count towards a ConditionWatcher while it is active.
+type NodeProgress struct {
+	Hours  int
+	Events map[string]int
+}
+
+// happeningKey identifies an event-like occurrence, such as an
+// EventTriggered event or an item being used on an object.
+func happeningKey(kind string, parts ...string) string {
+	return strings.Join(append([]string{kind}, parts...), " ")
+}
+
+// Interpreter plays through a single quest. It mirrors how the game runs
+// quests: several nodes can be active at once, EntryPoint, Actions and
+// ConditionBranch nodes are passed immediately, ConditionWatchers wait
+// for their conditions, and Dialog and Decision nodes wait for the player.
+type Interpreter struct {
+	quest    *Quest
+	nodes    map[int]*QuestNode
+	world    *World
+	status   string
+	accepted bool
+	active   map[int]bool
+	progress map[int]*NodeProgress
+	journal  []I18nString
+
+	// OnAction is called for every action that is executed.
+	OnAction func(nodeID int, action interface{})
+	// OnBranch is called before the conditions of a ConditionBranch
+	// node are evaluated, so callers can prepare the world.
+	OnBranch func(node *QuestNode)
+	// OnEnter is called when a node becomes active.
+	OnEnter func(nodeID int)
+	// OnChoose is called when a Decision option is chosen.
+	OnChoose func(nodeID, option int)
+	// OnBranchArm is called when a ConditionBranch node takes an arm.
+	OnBranchArm func(nodeID int, taken bool)
+	// Decide, if set, replaces evaluating a node's conditions against
+	// the world. It is not called for nodes without conditions.
+	Decide func(node *QuestNode, conditions []map[string]interface{}, required string) bool
+}
+
+// NewInterpreter creates an interpreter for the quest operating on world.
+func NewInterpreter(quest *Quest, world *World) *Interpreter {
+	nodes := make(map[int]*QuestNode)
+	for i := range quest.QuestNodes {
+		nodes[quest.QuestNodes[i].NodeID] = &quest.QuestNodes[i]
+	}
+	return &Interpreter{
+		quest:    quest,
+		nodes:    nodes,
+		world:    world,
+		status:   statusActive,
+		active:   make(map[int]bool),
+		progress: make(map[int]*NodeProgress),
+	}
+}
+
+// Start activates all EntryPoint nodes and runs until the quest waits.
+func (in *Interpreter) Start() {
+	for _, node := range in.quest.QuestNodes {
+		if node.NodeType == "EntryPoint" {
+			in.activate(node.NodeID)
+		}
+	}
+	in.Settle()
+}
+
+// Status returns the quest status.
+func (in *Interpreter) Status() string {
+	return in.status
+}
+
+// Ended reports whether a terminal action has been executed.
+func (in *Interpreter) Ended() bool {
+	return in.status != statusActive
+}
+
+// Accepted reports whether AcceptQuest has been executed.
+func (in *Interpreter) Accepted() bool {
+	return in.accepted
+}
+
+// Journal returns the journal entries written so far.
+func (in *Interpreter) Journal() []I18nString {
+	return in.journal
+}
+
+// Active returns the active node IDs in ascending order.
+func (in *Interpreter) Active() []int {
+	ids := make([]int, 0, len(in.active))
+	for id := range in.active {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// IsActive reports whether the node is active.
+func (in *Interpreter) IsActive(nodeID int) bool {
+	return in.active[nodeID]
+}
+
+// Node returns the node with the given ID, or nil.
+func (in *Interpreter) Node(nodeID int) *QuestNode {
+	return in.nodes[nodeID]
+}
+
+// Progress returns the progress record of an active node.
+func (in *Interpreter) Progress(nodeID int) *NodeProgress {
+	p, ok := in.progress[nodeID]
+	if !ok {
+		p = &NodeProgress{Events: make(map[string]int)}
+		in.progress[nodeID] = p
+	}
+	return p
+}
+
+// PassTime advances the clock of all active nodes.
+func (in *Interpreter) PassTime(hours int) {
+	for id := range in.active {
+		in.Progress(id).Hours += hours
+	}
+	in.Settle()
+}
+
+// Happen records an event-like occurrence for all active nodes.
+func (in *Interpreter) Happen(key string) {
+	for id := range in.active {
+		in.Progress(id).Events[key]++
+	}
+	in.Settle()
+}
+
+// AvailableOptions returns the 1-based options of an active Decision node
+// whose conditions hold.
+func (in *Interpreter) AvailableOptions(nodeID int) []int {
+	node := in.nodes[nodeID]
+	if !in.active[nodeID] || node.NodeType != "Decision" {
+		return nil
+	}
+	var options []int
+	for i, opt := range node.Options {
+		if in.conditionsHold(nodeID, opt.Conditions, "all") {
+			options = append(options, i+1)
+		}
+	}
+	return options
+}
+
+// Choose selects a 1-based option of an active Decision node.
+func (in *Interpreter) Choose(nodeID, option int) error {
+	for _, o := range in.AvailableOptions(nodeID) {
+		if o == option {
+			if in.OnChoose != nil {
+				in.OnChoose(nodeID, option)
+			}
+			in.leave(nodeID, in.nodes[nodeID].Options[option-1].NextNodes)
+			in.Settle()
+			return nil
+		}
+	}
+	return fmt.Errorf("option %d of node %d is not available", option, nodeID)
+}
+
+// CanTalk reports whether an active Dialog node's conditions hold.
+func (in *Interpreter) CanTalk(nodeID int) bool {
+	node := in.nodes[nodeID]
+	if !in.active[nodeID] || node.NodeType != "Dialog" {
+		return false
+	}
+	return in.conditionsHold(nodeID, node.Conditions, node.ConditionsRequired)
+}
+
+// Talk conducts the conversation of an active Dialog node.
+func (in *Interpreter) Talk(nodeID int) error {
+	if !in.CanTalk(nodeID) {
+		return fmt.Errorf("dialog node %d is not available", nodeID)
+	}
+	in.leave(nodeID, in.nodes[nodeID].NextNodes)
+	in.Settle()
+	return nil
+}
+
+// Settle passes all nodes that don't need to wait, until nothing changes.
+func (in *Interpreter) Settle() {
+	for !in.Ended() {
+		progressed := false
+		for _, id := range in.Active() {
+			if in.Ended() {
+				return
+			}
+			if in.active[id] && in.step(in.nodes[id]) {
+				progressed = true
+			}
+		}
+		if !progressed {
+			return
+		}
+	}
+}
+
+func (in *Interpreter) step(node *QuestNode) bool {
+	switch node.NodeType {
+	case "EntryPoint":
+		in.leave(node.NodeID, node.NextNodes)
+	case "Actions":
+		in.executeActions(node)
+	case "ConditionBranch":
+		if in.OnBranch != nil {
+			in.OnBranch(node)
+		}
+		taken := in.conditionsHold(node.NodeID, node.Conditions, node.ConditionsRequired)
+		if in.OnBranchArm != nil {
+			in.OnBranchArm(node.NodeID, taken)
+		}
+		if taken {
+			in.leave(node.NodeID, node.NextNodesIfTrue)
+		} else {
+			in.leave(node.NodeID, node.NextNodesIfFalse)
+		}
+	case "ConditionWatcher":
+		if !in.conditionsHold(node.NodeID, node.Conditions, node.ConditionsRequired) {
+			return false
+		}
+		in.leave(node.NodeID, node.NextNodes)
+	default:
+		return false
+	}
+	return true
+}
+
+func (in *Interpreter) executeActions(node *QuestNode) {
+	terminal := ""
+	for _, action := range node.Actions {
+		if in.OnAction != nil {
+			in.OnAction(node.NodeID, action)
+		}
+		switch a := action.(type) {
+		case string:
+			switch a {
+			case "AcceptQuest":
+				in.accepted = true
+			case "CompleteQuest":
+				terminal = statusCompleted
+			case "FailQuest":
+				terminal = statusFailed
+			case "DeclineQuest":
+				terminal = statusDeclined
+			}
+		case map[string]interface{}:
+			in.applyAction(a)
+		}
+	}
+
+	if terminal == "" {
+		in.leave(node.NodeID, node.NextNodes)
+		return
+	}
+	in.status = terminal
+	in.active = make(map[int]bool)
+	in.progress = make(map[int]*NodeProgress)
+	if terminal == statusCompleted {
+		in.world.CompletedQuests[in.quest.QuestID] = true
+	}
+}
+
+func (in *Interpreter) applyAction(action map[string]interface{}) {
+	for _, stack := range itemStacks(action["ItemsGained"]) {
+		in.world.Inventory[stack.Type] += stack.Count
+		if stack.QuestItem {
+			in.world.QuestItems[stack.Type] += stack.Count
+		}
+	}
+	for _, stack := range itemStacks(action["ItemsLost"]) {
+		in.world.Inventory[stack.Type] -= stack.Count
+		if stack.QuestItem {
+			in.world.QuestItems[stack.Type] -= stack.Count
+		}
+	}
+	if fs, ok := action["FactionStanding"].(map[string]interface{}); ok {
+		faction, _ := fs["Faction"].(string)
+		points, _ := toInt(fs["Points"])
+		in.world.Factions[faction] += points
+	}
+	if amount, ok := toInt(action["Currency"]); ok {
+		in.world.Currency += amount
+	}
+	if amount, ok := toInt(action["Experience"]); ok {
+		in.world.Experience += amount
+	}
+	if je, ok := action["JournalEntry"].(map[string]interface{}); ok {
+		in.journal = append(in.journal, toI18n(je))
+	}
+	if sv, ok := action["SetVariable"].(map[string]interface{}); ok {
+		name, _ := sv["VariableName"].(string)
+		value, _ := toInt(sv["Value"])
+		switch sv["Operation"] {
+		case "set to":
+			in.world.Variables[name] = value
+		case "unset":
+			delete(in.world.Variables, name)
+		case "increase by":
+			in.world.Variables[name] += value
+		case "decrease by":
+			in.world.Variables[name] -= value
+		}
+	}
+}
+
+func (in *Interpreter) activate(nodeID int) {
+	if _, exists := in.nodes[nodeID]; !exists || in.active[nodeID] {
+		return
+	}
+	in.active[nodeID] = true
+	in.progress[nodeID] = &NodeProgress{Events: make(map[string]int)}
+	if in.OnEnter != nil {
+		in.OnEnter(nodeID)
+	}
+}
+
+func (in *Interpreter) leave(nodeID int, next []int) {
+	delete(in.active, nodeID)
+	delete(in.progress, nodeID)
+	for _, id := range next {
+		in.activate(id)
+	}
+}
+
+// itemStack is one entry of an ItemsGained, ItemsLost or Inventory list.
+type itemStack struct {
+	Type      string
+	Count     int
+	QuestItem bool
+}
+
+func itemStacks(v interface{}) []itemStack {
+	list, _ := v.([]interface{})
+	var stacks []itemStack
+	for _, entry := range list {
+		m, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		stack := itemStack{}
+		stack.Type, _ = m["Type"].(string)
+		stack.QuestItem, _ = m["QuestItem"].(bool)
+		if count, ok := toInt(m["Count"]); ok {
+			stack.Count = count
+		} else if count, ok := toInt(m["MinCount"]); ok {
+			stack.Count = count
+		} else {
+			stack.Count = 1
+		}
+		stacks = append(stacks, stack)
+	}
+	return stacks
+}
+
+func toInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case uint64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
+
+func toI18n(m map[string]interface{}) I18nString {
+	var s I18nString
+	s.EnUS, _ = m["en-US"].(string)
+	s.DeDE, _ = m["de-DE"].(string)
+	return s
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInterpreter_WatcherWaitsForTime(t *testing.T) {
+	quest := &Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "ConditionWatcher", NextNodes: []int{2}, Conditions: []map[string]interface{}{
+				{"TimePassed": "2d"},
+			}},
+			{NodeID: 2, NodeType: "Actions", Actions: []interface{}{"CompleteQuest"}},
+		},
+	}
+	interp := NewInterpreter(quest, NewWorld())
+	interp.Start()
+
+	interp.PassTime(47)
+	if interp.Ended() {
+		t.Fatal("expected quest to wait until 48 hours have passed")
+	}
+	interp.PassTime(1)
+	if interp.Status() != statusCompleted {
+		t.Errorf("expected quest to be completed, got %s", interp.Status())
+	}
+}
+
+func TestInterpreter_ConditionsRequiredNOfM(t *testing.T) {
+	quest := &Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "ConditionWatcher", NextNodes: []int{2}, ConditionsRequired: "2", Conditions: []map[string]interface{}{
+				{"EventTriggered": map[string]interface{}{"Event": "A", "Count": 1}},
+				{"EventTriggered": map[string]interface{}{"Event": "B", "Count": 1}},
+				{"EventTriggered": map[string]interface{}{"Event": "C", "Count": 2}},
+			}},
+			{NodeID: 2, NodeType: "Actions", Actions: []interface{}{"CompleteQuest"}},
+		},
+	}
+	interp := NewInterpreter(quest, NewWorld())
+	interp.Start()
+
+	interp.Happen(happeningKey("EventTriggered", "C"))
+	interp.Happen(happeningKey("EventTriggered", "A"))
+	if interp.Ended() {
+		t.Fatal("expected C to need two occurrences")
+	}
+	interp.Happen(happeningKey("EventTriggered", "C"))
+	if interp.Status() != statusCompleted {
+		t.Errorf("expected 2 of 3 conditions to complete the quest, got %s", interp.Status())
+	}
+}
+
+func TestInterpreter_DecisionHidesUnavailableOptions(t *testing.T) {
+	quest := &Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Decision", Options: []DialogOption{
+				{NextNodes: []int{2}},
+				{NextNodes: []int{3}, Conditions: []map[string]interface{}{
+					{"FactionStanding": map[string]interface{}{"Faction": "Town", "MinimumLevel": 10}},
+				}},
+			}},
+			{NodeID: 2, NodeType: "Actions", Actions: []interface{}{"DeclineQuest"}},
+			{NodeID: 3, NodeType: "Actions", Actions: []interface{}{"CompleteQuest"}},
+		},
+	}
+	world := NewWorld()
+	interp := NewInterpreter(quest, world)
+	interp.Start()
+
+	if got := interp.AvailableOptions(1); !reflect.DeepEqual(got, []int{1}) {
+		t.Fatalf("expected only option 1, got %v", got)
+	}
+	if err := interp.Choose(1, 2); err == nil {
+		t.Error("expected error when choosing a hidden option")
+	}
+
+	world.Factions["Town"] = 10
+	if err := interp.Choose(1, 2); err != nil {
+		t.Fatalf("Choose failed: %v", err)
+	}
+	if interp.Status() != statusCompleted {
+		t.Errorf("expected quest to be completed, got %s", interp.Status())
+	}
+	if !world.CompletedQuests["TestQuest"] {
+		t.Error("expected world to record the completed quest")
+	}
+}
+
+func TestInterpreter_ActionsModifyWorld(t *testing.T) {
+	quest := &Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Actions", NextNodes: []int{2}, Actions: []interface{}{
+				"AcceptQuest",
+				map[string]interface{}{"ItemsGained": []interface{}{
+					map[string]interface{}{"Type": "PackOfNails", "Count": 1, "QuestItem": true},
+				}},
+				map[string]interface{}{"SetVariable": map[string]interface{}{"VariableName": "Delivered", "Operation": "increase by", "Value": 1}},
+				map[string]interface{}{"JournalEntry": map[string]interface{}{"en-US": "Accepted", "de-DE": "Angenommen"}},
+			}},
+			{NodeID: 2, NodeType: "Dialog", NextNodes: []int{3}, Conditions: []map[string]interface{}{
+				{"Inventory": []interface{}{map[string]interface{}{"Type": "PackOfNails", "MinCount": 1, "QuestItem": true}}},
+			}},
+			{NodeID: 3, NodeType: "Actions", Actions: []interface{}{
+				map[string]interface{}{"FactionStanding": map[string]interface{}{"Faction": "Town", "Points": -2}},
+				map[string]interface{}{"Currency": 5},
+				"CompleteQuest",
+			}},
+		},
+	}
+	world := NewWorld()
+	interp := NewInterpreter(quest, world)
+	interp.Start()
+
+	if !interp.Accepted() {
+		t.Error("expected quest to be accepted")
+	}
+	if world.QuestItems["PackOfNails"] != 1 || world.Variables["Delivered"] != 1 {
+		t.Errorf("unexpected world after first actions: %+v", world)
+	}
+	if err := interp.Talk(2); err != nil {
+		t.Fatalf("Talk failed: %v", err)
+	}
+	if world.Factions["Town"] != -2 || world.Currency != 5 {
+		t.Errorf("unexpected world after last actions: %+v", world)
+	}
+	if len(interp.Journal()) != 1 {
+		t.Errorf("expected 1 journal entry, got %d", len(interp.Journal()))
+	}
+}
+
+func TestInterpreter_TerminalActionEndsParallelFlows(t *testing.T) {
+	quest := &Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1, 2}},
+			{NodeID: 1, NodeType: "ConditionWatcher", NextNodes: []int{3}, Conditions: []map[string]interface{}{{"ItemLost": "Horseshoes"}}},
+			{NodeID: 2, NodeType: "Dialog", NextNodes: []int{4}},
+			{NodeID: 3, NodeType: "Actions", Actions: []interface{}{"FailQuest"}},
+			{NodeID: 4, NodeType: "Actions", Actions: []interface{}{"CompleteQuest"}},
+		},
+	}
+	interp := NewInterpreter(quest, NewWorld())
+	interp.Start()
+
+	if got := interp.Active(); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Fatalf("expected nodes 1 and 2 to be active, got %v", got)
+	}
+	interp.Happen(happeningKey("ItemLost", "Horseshoes"))
+	if interp.Status() != statusFailed {
+		t.Errorf("expected quest to fail, got %s", interp.Status())
+	}
+	if len(interp.Active()) != 0 {
+		t.Errorf("expected no active nodes, got %v", interp.Active())
+	}
+}
+
+func TestParseTimePassed(t *testing.T) {
+	tests := map[string]int{"36h": 36, "1d": 24, "2w": 336, "1M": 720, "1y": 8760}
+	for input, want := range tests {
+		if got, ok := parseTimePassed(input); !ok || got != want {
+			t.Errorf("parseTimePassed(%q) = %d, %v; want %d", input, got, ok, want)
+		}
+	}
+	if _, ok := parseTimePassed("0h"); ok {
+		t.Error("expected 0h to be rejected")
+	}
+}
+
+func TestPlaySession(t *testing.T) {
+	quest := &Quest{
+		QuestID:     "TestQuest",
+		DisplayName: I18nString{EnUS: "Test"},
+		QuestNodes: []QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "ConditionWatcher", NextNodes: []int{2}, Conditions: []map[string]interface{}{
+				{"FactionStanding": map[string]interface{}{"Faction": "Town", "MinimumLevel": 5}},
+			}},
+			{NodeID: 2, NodeType: "Decision", Speaker: "NPC:Smith", Text: &I18nString{EnUS: "Will you help?"}, Options: []DialogOption{
+				{Text: I18nString{EnUS: "Yes"}, NextNodes: []int{3}},
+				{Text: I18nString{EnUS: "Only as a courier"}, NextNodes: []int{4}, Conditions: []map[string]interface{}{
+					{"QuestCompleted": "CourierQuest"},
+				}},
+			}},
+			{NodeID: 3, NodeType: "Actions", Actions: []interface{}{"CompleteQuest"}},
+			{NodeID: 4, NodeType: "Actions", Actions: []interface{}{"DeclineQuest"}},
+		},
+	}
+	refData := &ReferenceData{NPCNames: map[string]I18nString{"NPC:Smith": {EnUS: "Drumin"}}}
+
+	// Pick the watcher, set standing 6, pick the decision, deny the
+	// courier quest, choose option 1.
+	input := strings.NewReader("\n6\n\nn\n1\n")
+	var out bytes.Buffer
+	code := newPlaySession(quest, refData, "en-US", input, &out).run()
+
+	if code != 0 {
+		t.Errorf("expected exit code 0, got %d", code)
+	}
+	output := out.String()
+	for _, want := range []string{"Drumin: Will you help?", "1) Yes", "Quest completed."} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "Only as a courier") {
+		t.Errorf("expected unavailable option to be hidden, got:\n%s", output)
+	}
+}
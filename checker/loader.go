@@ -9,36 +9,80 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// LoadError is a quest file that could not be loaded.
+type LoadError struct {
+	Path string
+	Err  error
+}
+
+func (e *LoadError) Error() string {
+	return fmt.Sprintf("failed to load %s: %v", e.Path, e.Err)
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
 // LoadQuests loads all quest files from the given directory.
 func LoadQuests(questsPath string) ([]*Quest, []error) {
 	var quests []*Quest
 	var errors []error
 
+	paths, err := listQuestFiles(questsPath)
+	if err != nil {
+		errors = append(errors, err)
+	}
+	for _, path := range paths {
+		quest, err := loadQuestFile(path)
+		if err != nil {
+			errors = append(errors, &LoadError{Path: path, Err: err})
+			continue
+		}
+		quests = append(quests, quest)
+	}
+
+	return quests, errors
+}
+
+// listQuestFiles returns the paths of all quest files in the given
+// directory and its subdirectories, in lexical order.
+func listQuestFiles(questsPath string) ([]string, error) {
+	var paths []string
+
 	err := filepath.Walk(questsPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		if info.IsDir() {
-			return nil
-		}
-		if !strings.HasSuffix(path, ".yaml") && !strings.HasSuffix(path, ".yml") {
-			return nil
+		if info.IsDir() && path != questsPath && isHidden(info.Name()) {
+			return filepath.SkipDir
 		}
-
-		quest, err := loadQuestFile(path)
-		if err != nil {
-			errors = append(errors, fmt.Errorf("failed to load %s: %w", path, err))
-			return nil
+		if !info.IsDir() && isQuestFile(path) {
+			paths = append(paths, path)
 		}
-		quests = append(quests, quest)
 		return nil
 	})
 
 	if err != nil {
-		errors = append(errors, fmt.Errorf("failed to walk quests directory: %w", err))
+		return paths, fmt.Errorf("failed to walk quests directory: %w", err)
 	}
 
-	return quests, errors
+	return paths, nil
+}
+
+// isHidden reports whether a file or directory name is hidden. Hidden
+// directories, such as the editor's backups, and hidden files, such as its
+// temporary files, hold no quests.
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
+
+// isQuestFile reports whether path is a quest YAML file. Test scenario
+// files (*.test.yaml) are skipped.
+func isQuestFile(path string) bool {
+	if strings.HasSuffix(path, ".test.yaml") || strings.HasSuffix(path, ".test.yml") {
+		return false
+	}
+	return strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml")
 }
 
 func loadQuestFile(path string) (*Quest, error) {
@@ -47,10 +91,21 @@ func loadQuestFile(path string) (*Quest, error) {
 		return nil, err
 	}
 
-	var quest Quest
-	if err := yaml.Unmarshal(data, &quest); err != nil {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	quest := Quest{Path: path, positions: Positions{}}
+	if root.Kind == 0 {
+		return &quest, nil // empty file
+	}
+	if err := root.Decode(&quest); err != nil {
+		return nil, err
+	}
+	if err := root.Decode(&quest.document); err != nil {
 		return nil, err
 	}
+	quest.positions = buildPositions(&root)
 
 	return &quest, nil
 }
@@ -59,6 +114,7 @@ func loadQuestFile(path string) (*Quest, error) {
 func LoadReferenceData(dataPath string) (*ReferenceData, error) {
 	refData := &ReferenceData{
 		NPCs:      make(map[string]bool),
+		NPCNames:  make(map[string]I18nString),
 		Items:     make(map[string]bool),
 		Factions:  make(map[string]bool),
 		Resources: make(map[string]bool),
@@ -72,6 +128,7 @@ func LoadReferenceData(dataPath string) (*ReferenceData, error) {
 	}
 	for _, npc := range npcs {
 		refData.NPCs[npc.NPCID] = true
+		refData.NPCNames[npc.NPCID] = npc.DisplayName
 	}
 
 	// Load Items
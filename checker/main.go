@@ -4,76 +4,180 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"strings"
 )
 
+// subcommands maps the name of each subcommand to its implementation.
+// Without a subcommand, the checker validates all quests.
+var subcommands = map[string]func(args []string) int{
+	"play":     runPlay,
+	"paths":    runPaths,
+	"test":     runTest,
+	"fuzz":     runFuzz,
+	"coverage": runCoverage,
+	"fix":      runFix,
+	"fmt":      runFmt,
+	"diff":     runDiff,
+}
+
 func main() {
-	questsPath := flag.String("quests", "./quests", "Path to quests directory")
-	dataPath := flag.String("data", "./data", "Path to reference data directory")
-	quiet := flag.Bool("quiet", false, "Only output errors, no summary")
-	flag.Parse()
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			os.Exit(cmd(os.Args[2:]))
+		}
+	}
 
-	exitCode := run(*questsPath, *dataPath, *quiet)
-	os.Exit(exitCode)
-}
+	var opts checkOptions
+	configPath := flag.String("config", "", "Path to the configuration file (default: "+configFile+" in the current directory or a parent)")
+	flag.StringVar(&opts.questsPath, "quests", "./quests", "Path to quests directory")
+	flag.StringVar(&opts.dataPath, "data", "./data", "Path to reference data directory")
+	flag.StringVar(&opts.previousPath, "previous", "", "Path to the previously released quests directory, to check savegame migrations")
+	flag.StringVar(&opts.schemasPath, "schemas", "", "Path to JSON schemas directory (default: schemas next to the data directory)")
+	failOn := flag.String("fail-on", "error", "Lowest severity that makes the checker fail: error, warning or info")
+	flag.StringVar(&opts.format, "format", "text", "Output format: text, json, sarif, junit or github")
+	flag.BoolVar(&opts.quiet, "quiet", false, "Only output errors, no summary")
+	flag.StringVar(&opts.baselinePath, "baseline", "", "Path to a baseline file; only report findings not recorded in it")
+	writeBaseline := flag.String("write-baseline", "", "Record all current findings in the given baseline file")
+	watch := flag.Bool("watch", false, "Keep running and re-check quests whenever quest or reference data files change")
+	flag.Parse()
 
-func run(questsPath, dataPath string, quiet bool) int {
-	// Load reference data
-	refData, err := LoadReferenceData(dataPath)
-	if err != nil {
+	var err error
+	if opts.config, err = applyConfig(flag.CommandLine, *configPath); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		return 2
+		os.Exit(2)
+	}
+	if opts.failOn, err = ParseSeverity(*failOn); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	if _, ok := reportWriters[opts.format]; !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q\n", opts.format)
+		os.Exit(2)
 	}
 
-	// Load all quests
-	quests, loadErrors := LoadQuests(questsPath)
-
-	// Print load errors
-	for _, err := range loadErrors {
-		fmt.Printf("[LOAD ERROR]: %v\n", err)
+	if *writeBaseline != "" {
+		if *watch {
+			fmt.Fprintln(os.Stderr, "Error: -write-baseline cannot be combined with -watch")
+			os.Exit(2)
+		}
+		os.Exit(runWriteBaseline(opts, *writeBaseline))
 	}
+	if *watch {
+		os.Exit(runWatch(opts))
+	}
+	os.Exit(run(opts))
+}
 
-	if len(quests) == 0 && len(loadErrors) == 0 {
-		if !quiet {
-			fmt.Println("No quests found.")
+// checkOptions holds the settings of a validation run.
+type checkOptions struct {
+	questsPath   string
+	dataPath     string
+	previousPath string
+	schemasPath  string
+	failOn       Severity
+	format       string
+	quiet        bool
+	baselinePath string
+	config       *Config // nil without a configuration file
+}
+
+// Report is the outcome of validating all quests.
+type Report struct {
+	Quests     []*Quest
+	LoadErrors []error
+	Issues     []ValidationError
+	Suppressed int
+	// Baselined counts the issues left out because the baseline records
+	// them, and Fixed lists the baseline entries that no longer occur.
+	Baselined int
+	Fixed     []BaselineEntry
+	FailOn    Severity
+}
+
+// Failed reports whether the report has load errors or issues at or above
+// the FailOn severity.
+func (r *Report) Failed() bool {
+	if len(r.LoadErrors) > 0 {
+		return true
+	}
+	for _, issue := range r.Issues {
+		if issue.Severity <= r.FailOn {
+			return true
 		}
-		return 0
 	}
+	return false
+}
 
-	// Run single-quest validation
-	var singleErrors []ValidationError
-	for _, quest := range quests {
-		singleErrors = append(singleErrors, ValidateQuest(quest, refData)...)
+func run(opts checkOptions) int {
+	report, err := check(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
 	}
+	return writeReport(report, opts)
+}
 
-	// Run cross-quest validation
-	crossErrors := ValidateCrossQuest(quests)
+// runWriteBaseline records all current findings in a baseline file.
+func runWriteBaseline(opts checkOptions, path string) int {
+	opts.baselinePath = ""
+	report, err := check(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
 
-	// Print all errors
-	allErrors := append(singleErrors, crossErrors...)
-	for _, verr := range allErrors {
-		fmt.Println(formatError(verr))
+	baseline := NewBaseline(report.Issues)
+	if err := baseline.Write(path); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	fmt.Printf("Recorded %d findings in %s.\n", len(baseline.Findings), path)
+	for _, err := range report.LoadErrors {
+		fmt.Fprintf(os.Stderr, "Warning: not recorded: %v\n", err)
 	}
+	return 0
+}
 
-	// Summary
-	totalErrors := len(loadErrors) + len(allErrors)
-	if !quiet {
-		fmt.Println(strings.Repeat("-", 40))
-		fmt.Printf("Checked %d quests, found %d issues.\n", len(quests), totalErrors)
+// writeReport writes the report in the format of opts and returns the exit
+// code for it.
+func writeReport(report *Report, opts checkOptions) int {
+	if err := reportWriters[opts.format](os.Stdout, report, opts.quiet); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
 	}
 
-	if totalErrors > 0 {
+	if report.Failed() {
 		return 1
 	}
 	return 0
 }
 
+// check loads and validates all quests. It only fails if the reference
+// data, the schemas or the baseline cannot be loaded.
+func check(opts checkOptions) (*Report, error) {
+	s := newSession(opts)
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s.report(), nil
+}
+
 func formatError(err ValidationError) string {
+	location := ""
+	if err.Path != "" && err.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d: ", err.Path, err.Line, err.Column)
+	}
+	message := err.Message
+	if err.Code != "" {
+		message = fmt.Sprintf("%s %s: %s", err.Severity, err.Code, err.Message)
+	}
+	if err.File != "" {
+		return fmt.Sprintf("%s[%s]: %s", location, err.File, message)
+	}
 	if err.QuestID != "" {
 		if err.NodeID != nil {
-			return fmt.Sprintf("[%s] Node %d: %s", err.QuestID, *err.NodeID, err.Message)
+			return fmt.Sprintf("%s[%s] Node %d: %s", location, err.QuestID, *err.NodeID, message)
 		}
-		return fmt.Sprintf("[%s]: %s", err.QuestID, err.Message)
+		return fmt.Sprintf("%s[%s]: %s", location, err.QuestID, message)
 	}
-	return fmt.Sprintf("[CROSS-QUEST]: %s", err.Message)
+	return fmt.Sprintf("%s[CROSS-QUEST]: %s", location, message)
 }
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// activeCapable reports whether nodes of the given type can be active in
+// a savegame. All other node types are passed as soon as they activate.
+func activeCapable(nodeType string) bool {
+	switch nodeType {
+	case "ConditionWatcher", "Dialog", "Decision":
+		return true
+	}
+	return false
+}
+
+// validateMigrations checks the Migrations section of a single quest.
+func validateMigrations(quest *Quest) []ValidationError {
+	var errors []ValidationError
+
+	nodeIDs := make(map[int]bool)
+	for _, node := range quest.QuestNodes {
+		nodeIDs[node.NodeID] = true
+	}
+
+	seen := make(map[int]bool)
+	for i, m := range quest.Migrations {
+		field := fmt.Sprintf("$.Migrations[%d]", i)
+		if m.FromVersion < 1 || m.FromVersion >= quest.QuestVersion {
+			errors = append(errors, ValidationError{
+				Code:    "PAT016",
+				QuestID: quest.QuestID,
+				Field:   field,
+				Message: fmt.Sprintf("migration from version %d must start between version 1 and %d", m.FromVersion, quest.QuestVersion-1),
+			})
+		}
+		if seen[m.FromVersion] {
+			errors = append(errors, ValidationError{
+				Code:    "PAT016",
+				QuestID: quest.QuestID,
+				Field:   field,
+				Message: fmt.Sprintf("duplicate migration from version %d", m.FromVersion),
+			})
+		}
+		seen[m.FromVersion] = true
+
+		// Only the last migration leads into the nodes of this file.
+		if m.FromVersion != quest.QuestVersion-1 {
+			continue
+		}
+		for _, nm := range m.Nodes {
+			for _, to := range nm.To {
+				if !nodeIDs[to] {
+					errors = append(errors, ValidationError{
+						Code:    "PAT016",
+						QuestID: quest.QuestID,
+						Field:   field,
+						Message: fmt.Sprintf("migration from version %d maps node %d to non-existent NodeID %d", m.FromVersion, nm.From, to),
+					})
+				}
+			}
+		}
+	}
+
+	return errors
+}
+
+// ValidateQuestVersions compares quests with their previously released
+// versions. Savegames may point at any active-capable node of the previous
+// version and hold any of its variables, so removing or replacing such a
+// node, or dropping or renaming such a variable, requires a QuestVersion
+// bump and a migration for the node or variable.
+func ValidateQuestVersions(quests, previous []*Quest) []ValidationError {
+	var errors []ValidationError
+
+	released := make(map[string]*Quest)
+	for _, q := range previous {
+		released[q.QuestID] = q
+	}
+
+	for _, quest := range quests {
+		prev, ok := released[quest.QuestID]
+		if !ok {
+			continue
+		}
+		if quest.QuestVersion < prev.QuestVersion {
+			errors = append(errors, ValidationError{
+				Code:    "PAT024",
+				QuestID: quest.QuestID,
+				Path:    quest.Path,
+				Message: fmt.Sprintf("QuestVersion decreased from %d to %d", prev.QuestVersion, quest.QuestVersion),
+			})
+			continue
+		}
+		errors = append(errors, validateVersionBump(quest, prev)...)
+	}
+
+	return errors
+}
+
+// nodeIdentity describes what a savegame relies on when it points at an
+// active-capable node: its type, whom it talks to and what it waits for.
+// A node that reuses a released NodeID with a different identity is a
+// different node, even if its type is the same.
+func nodeIdentity(node *QuestNode) string {
+	identity := node.NodeType + "|" + node.ConversationPartner
+	if node.NodeType == "ConditionWatcher" {
+		conditions, _ := json.Marshal(node.Conditions)
+		identity += "|" + node.ConditionsRequired + "|" + string(conditions)
+	}
+	return identity
+}
+
+// questVariables returns the names of the variables a quest sets or reads.
+func questVariables(quest *Quest) map[string]bool {
+	variables := make(map[string]bool)
+	addConditions := func(conditions []map[string]interface{}) {
+		for _, cond := range conditions {
+			if v, ok := cond["Variable"].(map[string]interface{}); ok {
+				if name, _ := v["VariableName"].(string); name != "" {
+					variables[name] = true
+				}
+			}
+		}
+	}
+	for i := range quest.QuestNodes {
+		node := &quest.QuestNodes[i]
+		addConditions(node.Conditions)
+		for _, opt := range node.Options {
+			addConditions(opt.Conditions)
+		}
+		for _, action := range node.Actions {
+			if m, ok := action.(map[string]interface{}); ok {
+				if sv, ok := m["SetVariable"].(map[string]interface{}); ok {
+					if name, _ := sv["VariableName"].(string); name != "" {
+						variables[name] = true
+					}
+				}
+			}
+		}
+	}
+	return variables
+}
+
+func validateVersionBump(quest, prev *Quest) []ValidationError {
+	var errors []ValidationError
+
+	current := make(map[int]string)
+	for i := range quest.QuestNodes {
+		current[quest.QuestNodes[i].NodeID] = nodeIdentity(&quest.QuestNodes[i])
+	}
+	migrated := make(map[int]bool)
+	migratedVariables := make(map[string]bool)
+	for _, m := range quest.Migrations {
+		if m.FromVersion < prev.QuestVersion || m.FromVersion >= quest.QuestVersion {
+			continue
+		}
+		for _, nm := range m.Nodes {
+			migrated[nm.From] = true
+		}
+		for _, vm := range m.Variables {
+			migratedVariables[vm.From] = true
+		}
+	}
+
+	for i := range prev.QuestNodes {
+		node := &prev.QuestNodes[i]
+		identity, exists := current[node.NodeID]
+		if !activeCapable(node.NodeType) || identity == nodeIdentity(node) {
+			continue
+		}
+		change := "removed"
+		if exists {
+			change = "replaced"
+		}
+		var message string
+		switch {
+		case quest.QuestVersion == prev.QuestVersion:
+			message = fmt.Sprintf("%s node of released QuestVersion %d was %s without a QuestVersion bump",
+				node.NodeType, prev.QuestVersion, change)
+		case !migrated[node.NodeID]:
+			message = fmt.Sprintf("%s node of released QuestVersion %d was %s in QuestVersion %d without a migration",
+				node.NodeType, prev.QuestVersion, change, quest.QuestVersion)
+		default:
+			continue
+		}
+		errors = append(errors, ValidationError{
+			Code:    "PAT025",
+			QuestID: quest.QuestID,
+			NodeID:  intPtr(node.NodeID),
+			Path:    quest.Path,
+			Message: message,
+		})
+	}
+
+	variables := questVariables(quest)
+	var removed []string
+	for name := range questVariables(prev) {
+		if !variables[name] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		var message string
+		switch {
+		case quest.QuestVersion == prev.QuestVersion:
+			message = fmt.Sprintf("variable %s of released QuestVersion %d was removed or renamed without a QuestVersion bump",
+				name, prev.QuestVersion)
+		case !migratedVariables[name]:
+			message = fmt.Sprintf("variable %s of released QuestVersion %d was removed or renamed in QuestVersion %d without a migration",
+				name, prev.QuestVersion, quest.QuestVersion)
+		default:
+			continue
+		}
+		errors = append(errors, ValidationError{
+			Code:    "PAT026",
+			QuestID: quest.QuestID,
+			Path:    quest.Path,
+			Message: message,
+		})
+	}
+
+	return errors
+}
@@ -0,0 +1,183 @@
+package main
+
+import "testing"
+
+func TestValidateMigrations(t *testing.T) {
+	quest := &Quest{
+		QuestID:      "TestQuest",
+		QuestVersion: 3,
+		QuestNodes: []QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Actions", Actions: []interface{}{"CompleteQuest"}},
+		},
+		Migrations: []Migration{
+			{FromVersion: 1, Nodes: []NodeMigration{{From: 4, To: []int{5}}}},
+			{FromVersion: 2, Nodes: []NodeMigration{{From: 2, To: []int{1, 6}}}},
+			{FromVersion: 2},
+			{FromVersion: 3},
+		},
+	}
+
+	errors := validateMigrations(quest)
+
+	expected := []string{
+		"migration from version 2 maps node 2 to non-existent NodeID 6",
+		"duplicate migration from version 2",
+		"migration from version 3 must start between version 1 and 2",
+	}
+	if len(errors) != len(expected) {
+		t.Fatalf("expected %d errors, got %v", len(expected), errors)
+	}
+	for i, msg := range expected {
+		if errors[i].Message != msg {
+			t.Errorf("error %d: expected %q, got %q", i, msg, errors[i].Message)
+		}
+	}
+}
+
+func TestValidateQuestVersions(t *testing.T) {
+	released := &Quest{
+		QuestID:      "TestQuest",
+		QuestVersion: 1,
+		QuestNodes: []QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1, 2, 3}},
+			{NodeID: 1, NodeType: "Dialog"},
+			{NodeID: 2, NodeType: "ConditionWatcher"},
+			{NodeID: 3, NodeType: "Actions"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		quest   *Quest
+		wantIDs []int
+	}{
+		{
+			name:  "unchanged",
+			quest: &Quest{QuestID: "TestQuest", QuestVersion: 1, QuestNodes: released.QuestNodes},
+		},
+		{
+			name: "changed without bump",
+			quest: &Quest{QuestID: "TestQuest", QuestVersion: 1, QuestNodes: []QuestNode{
+				{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+				{NodeID: 1, NodeType: "Dialog"},
+			}},
+			wantIDs: []int{2},
+		},
+		{
+			name: "bump without migration",
+			quest: &Quest{QuestID: "TestQuest", QuestVersion: 2, QuestNodes: []QuestNode{
+				{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+				{NodeID: 1, NodeType: "Decision"},
+			}},
+			wantIDs: []int{1, 2},
+		},
+		{
+			name: "bump with migration",
+			quest: &Quest{QuestID: "TestQuest", QuestVersion: 2, QuestNodes: []QuestNode{
+				{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{4}},
+				{NodeID: 4, NodeType: "Dialog"},
+			}, Migrations: []Migration{
+				{FromVersion: 1, Nodes: []NodeMigration{{From: 1, To: []int{4}}, {From: 2, To: []int{}}}},
+			}},
+		},
+		{
+			name: "renumbered without migration",
+			quest: &Quest{QuestID: "TestQuest", QuestVersion: 2, QuestNodes: []QuestNode{
+				{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1, 2, 3}},
+				{NodeID: 1, NodeType: "Dialog", ConversationPartner: "NPC:Baker"},
+				{NodeID: 2, NodeType: "ConditionWatcher", Conditions: []map[string]interface{}{{"EventTriggered": map[string]interface{}{"Event": "Rain"}}}},
+				{NodeID: 3, NodeType: "Actions"},
+			}},
+			wantIDs: []int{1, 2},
+		},
+		{
+			name:  "new quest",
+			quest: &Quest{QuestID: "OtherQuest", QuestVersion: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := ValidateQuestVersions([]*Quest{tt.quest}, []*Quest{released})
+			if len(errors) != len(tt.wantIDs) {
+				t.Fatalf("expected %d errors, got %v", len(tt.wantIDs), errors)
+			}
+			for i, id := range tt.wantIDs {
+				if errors[i].NodeID == nil || *errors[i].NodeID != id {
+					t.Errorf("error %d: expected node %d, got %v", i, id, errors[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateQuestVersions_Decreased(t *testing.T) {
+	errors := ValidateQuestVersions(
+		[]*Quest{{QuestID: "TestQuest", QuestVersion: 1}},
+		[]*Quest{{QuestID: "TestQuest", QuestVersion: 2}},
+	)
+
+	if len(errors) != 1 || errors[0].Message != "QuestVersion decreased from 2 to 1" {
+		t.Errorf("expected decreased version error, got %v", errors)
+	}
+}
+
+func TestValidateQuestVersions_Variables(t *testing.T) {
+	setVariable := func(name string) []interface{} {
+		return []interface{}{map[string]interface{}{"SetVariable": map[string]interface{}{"VariableName": name, "Operation": "Add", "Value": 1}}}
+	}
+	released := &Quest{
+		QuestID:      "TestQuest",
+		QuestVersion: 1,
+		QuestNodes: []QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Actions", Actions: setVariable("Visits"), NextNodes: []int{2}},
+			{NodeID: 2, NodeType: "ConditionBranch", Conditions: []map[string]interface{}{
+				{"Variable": map[string]interface{}{"VariableName": "Gifts", "Comparison": "AtLeast", "Value": 2}},
+			}},
+		},
+	}
+	renamed := []QuestNode{
+		{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+		{NodeID: 1, NodeType: "Actions", Actions: setVariable("TimesVisited")},
+	}
+
+	tests := []struct {
+		name  string
+		quest *Quest
+		want  []string
+	}{
+		{
+			name:  "unchanged",
+			quest: &Quest{QuestID: "TestQuest", QuestVersion: 1, QuestNodes: released.QuestNodes},
+		},
+		{
+			name:  "renamed without bump",
+			quest: &Quest{QuestID: "TestQuest", QuestVersion: 1, QuestNodes: renamed},
+			want: []string{
+				"variable Gifts of released QuestVersion 1 was removed or renamed without a QuestVersion bump",
+				"variable Visits of released QuestVersion 1 was removed or renamed without a QuestVersion bump",
+			},
+		},
+		{
+			name: "bump with partial migration",
+			quest: &Quest{QuestID: "TestQuest", QuestVersion: 2, QuestNodes: renamed, Migrations: []Migration{
+				{FromVersion: 1, Variables: []VariableMigration{{From: "Visits", To: "TimesVisited"}}},
+			}},
+			want: []string{"variable Gifts of released QuestVersion 1 was removed or renamed in QuestVersion 2 without a migration"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := ValidateQuestVersions([]*Quest{tt.quest}, []*Quest{released})
+			if len(errors) != len(tt.want) {
+				t.Fatalf("expected %d errors, got %v", len(tt.want), errors)
+			}
+			for i, msg := range tt.want {
+				if errors[i].Code != "PAT026" || errors[i].Message != msg {
+					t.Errorf("error %d: expected PAT026 %q, got %v", i, msg, errors[i])
+				}
+			}
+		})
+	}
+}
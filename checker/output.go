@@ -0,0 +1,433 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// reportWriters maps each -format to the function printing a report in it.
+var reportWriters = map[string]func(w io.Writer, r *Report, quiet bool) error{
+	"text":   writeText,
+	"json":   writeJSON,
+	"sarif":  writeSARIF,
+	"junit":  writeJUnit,
+	"github": writeGitHub,
+}
+
+// finding is an issue or load error in the form the machine-readable
+// formats share.
+type finding struct {
+	File     string `json:"file,omitempty"`
+	QuestID  string `json:"questId,omitempty"`
+	NodeID   *int   `json:"nodeId,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+
+	issue ValidationError
+}
+
+// findings returns the load errors and issues of a report.
+func (r *Report) findings() []finding {
+	var findings []finding
+	for _, err := range r.LoadErrors {
+		issue := ValidationError{Code: loadErrorRule, Message: err.Error()}
+		var loadErr *LoadError
+		if errors.As(err, &loadErr) {
+			issue.Path = loadErr.Path
+			issue.File = filepath.ToSlash(loadErr.Path)
+			issue.Message = loadErr.Err.Error()
+		}
+		findings = append(findings, finding{
+			File:     filepath.ToSlash(issue.Path),
+			Rule:     issue.Code,
+			Severity: issue.Severity.String(),
+			Message:  issue.Message,
+			issue:    issue,
+		})
+	}
+	for _, issue := range r.Issues {
+		findings = append(findings, finding{
+			File:     filepath.ToSlash(issue.Path),
+			QuestID:  issue.QuestID,
+			NodeID:   issue.NodeID,
+			Line:     issue.Line,
+			Column:   issue.Column,
+			Rule:     issue.Code,
+			Severity: issue.Severity.String(),
+			Message:  issue.Message,
+			issue:    issue,
+		})
+	}
+	return findings
+}
+
+// text describes the finding for formats that show the rule and location
+// separately.
+func (f finding) text() string {
+	issue := f.issue
+	issue.Code = ""
+	issue.Path = ""
+	return formatError(issue)
+}
+
+func writeText(w io.Writer, r *Report, quiet bool) error {
+	for _, err := range r.LoadErrors {
+		fmt.Fprintf(w, "[LOAD ERROR]: %v\n", err)
+	}
+	for _, issue := range r.Issues {
+		fmt.Fprintln(w, formatError(issue))
+	}
+	if quiet {
+		return nil
+	}
+	if len(r.Quests) == 0 && len(r.LoadErrors) == 0 {
+		fmt.Fprintln(w, "No quests found.")
+		return nil
+	}
+	if len(r.Fixed) > 0 {
+		fmt.Fprintln(w, strings.Repeat("-", 40))
+		fmt.Fprintf(w, "Fixed since the baseline (%d), update it with -write-baseline:\n", len(r.Fixed))
+		for _, entry := range r.Fixed {
+			fmt.Fprintf(w, "  %s\n", entry)
+		}
+	}
+	fmt.Fprintln(w, strings.Repeat("-", 40))
+	fmt.Fprintln(w, r.summary())
+	return nil
+}
+
+// summary counts the quests and issues of a report.
+func (r *Report) summary() string {
+	counts := make([]int, len(severityNames))
+	for _, issue := range r.Issues {
+		counts[issue.Severity]++
+	}
+	s := fmt.Sprintf("Checked %d quests, found %d errors, %d warnings and %d infos",
+		len(r.Quests), len(r.LoadErrors)+counts[SeverityError], counts[SeverityWarning], counts[SeverityInfo])
+	var left []string
+	if r.Suppressed > 0 {
+		left = append(left, fmt.Sprintf("%d suppressed", r.Suppressed))
+	}
+	if r.Baselined > 0 {
+		left = append(left, fmt.Sprintf("%d baselined", r.Baselined))
+	}
+	if len(left) > 0 {
+		s += " (" + strings.Join(left, ", ") + ")"
+	}
+	return s + "."
+}
+
+func writeJSON(w io.Writer, r *Report, quiet bool) error {
+	findings := r.findings()
+	if findings == nil {
+		findings = []finding{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Quests     int             `json:"quests"`
+		Failed     bool            `json:"failed"`
+		Suppressed int             `json:"suppressed"`
+		Baselined  int             `json:"baselined"`
+		Findings   []finding       `json:"findings"`
+		Fixed      []BaselineEntry `json:"fixed,omitempty"`
+	}{len(r.Quests), r.Failed(), r.Suppressed, r.Baselined, findings, r.Fixed})
+}
+
+// SARIF 2.1.0, as understood by code scanning.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string        `json:"id"`
+	ShortDescription     sarifMessage  `json:"shortDescription"`
+	DefaultConfiguration sarifRuleConf `json:"defaultConfiguration"`
+}
+
+type sarifRuleConf struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string                 `json:"ruleId"`
+	RuleIndex  int                    `json:"ruleIndex"`
+	Level      string                 `json:"level"`
+	Message    sarifMessage           `json:"message"`
+	Locations  []sarifLocation        `json:"locations,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// sarifLevels maps severities to SARIF result levels.
+var sarifLevels = map[string]string{
+	"error":   "error",
+	"warning": "warning",
+	"info":    "note",
+}
+
+func writeSARIF(w io.Writer, r *Report, quiet bool) error {
+	var codes []string
+	for code := range rules {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	driver := sarifDriver{
+		Name:           "pat-quest-checker",
+		InformationURI: "https://github.com/tinx/pat-quest-editor",
+	}
+	ruleIndex := make(map[string]int)
+	for _, code := range codes {
+		rule := rules[code]
+		ruleIndex[code] = len(driver.Rules)
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   code,
+			ShortDescription:     sarifMessage{rule.Summary},
+			DefaultConfiguration: sarifRuleConf{sarifLevels[rule.Severity.String()]},
+		})
+	}
+
+	results := []sarifResult{}
+	for _, f := range r.findings() {
+		message := f.Message
+		if f.NodeID != nil {
+			message = fmt.Sprintf("Node %d: %s", *f.NodeID, message)
+		}
+		result := sarifResult{
+			RuleID:    f.Rule,
+			RuleIndex: ruleIndex[f.Rule],
+			Level:     sarifLevels[f.Severity],
+			Message:   sarifMessage{message},
+		}
+		var location sarifLocation
+		if f.File != "" {
+			location.PhysicalLocation = &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{f.File}}
+			if f.Line > 0 {
+				location.PhysicalLocation.Region = &sarifRegion{f.Line, f.Column}
+			}
+		}
+		if f.QuestID != "" {
+			name, kind := f.QuestID, "module"
+			if f.NodeID != nil {
+				name, kind = fmt.Sprintf("%s/Node %d", f.QuestID, *f.NodeID), "object"
+			}
+			location.LogicalLocations = []sarifLogicalLocation{{Name: name, FullyQualifiedName: name, Kind: kind}}
+			result.Properties = map[string]interface{}{"questId": f.QuestID}
+			if f.NodeID != nil {
+				result.Properties["nodeId"] = *f.NodeID
+			}
+		}
+		if location.PhysicalLocation != nil || location.LogicalLocations != nil {
+			result.Locations = []sarifLocation{location}
+		}
+		results = append(results, result)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{driver}, Results: results}},
+	})
+}
+
+// JUnit XML, with one test case per quest, reference data file and failed
+// load. Findings at or above the -fail-on severity fail their test case,
+// the others are listed in its output.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut *junitOutput  `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+type junitOutput struct {
+	Text string `xml:",cdata"`
+}
+
+func writeJUnit(w io.Writer, r *Report, quiet bool) error {
+	suite := junitTestSuite{Name: "quests"}
+	cases := make(map[string]*junitTestCase)
+	var order []string
+	testCase := func(name, file string) *junitTestCase {
+		if tc, ok := cases[name]; ok {
+			return tc
+		}
+		cases[name] = &junitTestCase{Name: name, ClassName: "quests", File: file}
+		order = append(order, name)
+		return cases[name]
+	}
+
+	for _, quest := range r.Quests {
+		testCase(quest.QuestID, filepath.ToSlash(quest.Path))
+	}
+	failing := make(map[string][]finding)
+	for _, f := range r.findings() {
+		name := f.QuestID
+		switch {
+		case f.issue.File != "":
+			name = f.issue.File
+		case name == "":
+			name = "CROSS-QUEST"
+		}
+		tc := testCase(name, f.File)
+		if f.issue.Severity <= r.FailOn {
+			failing[name] = append(failing[name], f)
+			continue
+		}
+		if tc.SystemOut == nil {
+			tc.SystemOut = &junitOutput{}
+		}
+		tc.SystemOut.Text += formatError(f.issue) + "\n"
+	}
+
+	for _, name := range order {
+		tc := cases[name]
+		if fs := failing[name]; len(fs) > 0 {
+			var lines []string
+			for _, f := range fs {
+				lines = append(lines, formatError(f.issue))
+			}
+			message := fmt.Sprintf("%d issues", len(fs))
+			if len(fs) == 1 {
+				message = "1 issue"
+			}
+			tc.Failure = &junitFailure{
+				Message: message,
+				Type:    fs[0].Rule,
+				Text:    strings.Join(lines, "\n"),
+			}
+			suite.Failures++
+		}
+		suite.TestCases = append(suite.TestCases, *tc)
+	}
+	suite.Tests = len(suite.TestCases)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{
+		Name:     "pat-quest-checker",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Suites:   []junitTestSuite{suite},
+	}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// githubLevels maps severities to GitHub Actions workflow commands.
+var githubLevels = map[string]string{
+	"error":   "error",
+	"warning": "warning",
+	"info":    "notice",
+}
+
+// writeGitHub prints GitHub Actions annotations, followed by the summary.
+func writeGitHub(w io.Writer, r *Report, quiet bool) error {
+	for _, f := range r.findings() {
+		var properties []string
+		if f.File != "" {
+			properties = append(properties, "file="+escapeGitHubProperty(f.File))
+			if f.Line > 0 {
+				properties = append(properties, fmt.Sprintf("line=%d,col=%d", f.Line, f.Column))
+			}
+		}
+		properties = append(properties, "title="+escapeGitHubProperty(f.Rule))
+		fmt.Fprintf(w, "::%s %s::%s\n", githubLevels[f.Severity], strings.Join(properties, ","), escapeGitHubData(f.text()))
+	}
+	if !quiet {
+		fmt.Fprintln(w, r.summary())
+	}
+	return nil
+}
+
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testReport() *Report {
+	return &Report{
+		Quests: []*Quest{
+			{QuestID: "Quest1", Path: "quests/quest1.yaml"},
+			{QuestID: "Quest2", Path: "quests/quest2.yaml"},
+		},
+		LoadErrors: []error{&LoadError{Path: "quests/broken.yaml", Err: errors.New("yaml: line 3: bad indentation")}},
+		Issues: []ValidationError{
+			{Code: "PAT001", QuestID: "Quest1", NodeID: intPtr(4), Path: "quests/quest1.yaml", Line: 12, Column: 5, Message: "duplicate edge to node 5"},
+			{Code: "PAT021", Severity: SeverityWarning, Message: "duplicate DisplayName \"A, B\"\nin quests: Quest1, Quest2"},
+			{Code: "PAT032", Severity: SeverityInfo, QuestID: "Quest2", Path: "quests/quest2.yaml", Message: "suppression of PAT013 matches no issue"},
+		},
+		Suppressed: 1,
+		FailOn:     SeverityError,
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeJSON(&out, testReport(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		Quests     int
+		Failed     bool
+		Suppressed int
+		Findings   []map[string]interface{}
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if got.Quests != 2 || !got.Failed || got.Suppressed != 1 || len(got.Findings) != 4 {
+		t.Fatalf("unexpected report: %+v", got)
+	}
+	want := map[string]interface{}{
+		"file":     "quests/quest1.yaml",
+		"questId":  "Quest1",
+		"nodeId":   float64(4),
+		"line":     float64(12),
+		"column":   float64(5),
+		"rule":     "PAT001",
+		"severity": "error",
+		"message":  "duplicate edge to node 5",
+	}
+	for k, v := range want {
+		if got.Findings[1][k] != v {
+			t.Errorf("finding %s: got %v, want %v", k, got.Findings[1][k], v)
+		}
+	}
+	if got.Findings[0]["rule"] != "PAT000" || got.Findings[0]["file"] != "quests/broken.yaml" {
+		t.Errorf("unexpected load error finding: %v", got.Findings[0])
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeSARIF(&out, testReport(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(out.Bytes(), &log); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log: %+v", log)
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != len(rules) {
+		t.Errorf("expected %d rules, got %d", len(rules), len(run.Tool.Driver.Rules))
+	}
+	if len(run.Results) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(run.Results))
+	}
+	for _, result := range run.Results {
+		if run.Tool.Driver.Rules[result.RuleIndex].ID != result.RuleID {
+			t.Errorf("result %s has rule index %d", result.RuleID, result.RuleIndex)
+		}
+	}
+
+	r := run.Results[1]
+	if r.Level != "error" || r.Message.Text != "Node 4: duplicate edge to node 5" {
+		t.Errorf("unexpected result: %+v", r)
+	}
+	if len(r.Locations) != 1 || r.Locations[0].PhysicalLocation.ArtifactLocation.URI != "quests/quest1.yaml" ||
+		*r.Locations[0].PhysicalLocation.Region != (sarifRegion{12, 5}) ||
+		r.Locations[0].LogicalLocations[0].FullyQualifiedName != "Quest1/Node 4" {
+		t.Errorf("unexpected locations: %+v", r.Locations)
+	}
+	if r := run.Results[2]; r.Level != "warning" || r.Locations != nil {
+		t.Errorf("unexpected cross-quest result: %+v", r)
+	}
+	if r := run.Results[3]; r.Level != "note" {
+		t.Errorf("expected info to map to note, got %q", r.Level)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeJUnit(&out, testReport(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal(out.Bytes(), &suites); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, out.String())
+	}
+	if suites.Tests != 4 || suites.Failures != 2 || len(suites.Suites) != 1 {
+		t.Fatalf("unexpected test suites: %+v", suites)
+	}
+
+	cases := make(map[string]junitTestCase)
+	for _, tc := range suites.Suites[0].TestCases {
+		cases[tc.Name] = tc
+	}
+	if tc := cases["Quest1"]; tc.Failure == nil || tc.Failure.Type != "PAT001" || tc.File != "quests/quest1.yaml" ||
+		tc.Failure.Text != "quests/quest1.yaml:12:5: [Quest1] Node 4: error PAT001: duplicate edge to node 5" {
+		t.Errorf("unexpected Quest1 test case: %+v", tc)
+	}
+	if tc := cases["Quest2"]; tc.Failure != nil || tc.SystemOut == nil || !strings.Contains(tc.SystemOut.Text, "info PAT032") {
+		t.Errorf("expected Quest2 to pass with output, got %+v", tc)
+	}
+	if tc := cases["quests/broken.yaml"]; tc.Failure == nil || tc.Failure.Type != "PAT000" {
+		t.Errorf("unexpected load error test case: %+v", tc)
+	}
+	if tc := cases["CROSS-QUEST"]; tc.Failure != nil || tc.SystemOut == nil {
+		t.Errorf("expected the cross-quest warning not to fail, got %+v", tc)
+	}
+}
+
+func TestWriteGitHub(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeGitHub(&out, testReport(), true); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"::error file=quests/broken.yaml,title=PAT000::[quests/broken.yaml]: yaml: line 3: bad indentation",
+		"::error file=quests/quest1.yaml,line=12,col=5,title=PAT001::[Quest1] Node 4: duplicate edge to node 5",
+		`::warning title=PAT021::[CROSS-QUEST]: duplicate DisplayName "A, B"%0Ain quests: Quest1, Quest2`,
+		"::notice file=quests/quest2.yaml,title=PAT032::[Quest2]: suppression of PAT013 matches no issue",
+	}
+	if got := strings.Split(strings.TrimSpace(out.String()), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected annotations:\n got %q\nwant %q", got, want)
+	}
+	if got := escapeGitHubProperty("C:\\quests\\a,b.yaml"); got != "C%3A\\quests\\a%2Cb.yaml" {
+		t.Errorf("unexpected escaped property: %q", got)
+	}
+}
+
+func TestReportFailed(t *testing.T) {
+	r := &Report{Issues: []ValidationError{{Code: "PAT021", Severity: SeverityWarning}}}
+	if r.Failed() {
+		t.Error("expected warnings not to fail with -fail-on error")
+	}
+	r.FailOn = SeverityWarning
+	if !r.Failed() {
+		t.Error("expected warnings to fail with -fail-on warning")
+	}
+}
+
+func TestLoadQuests_Paths(t *testing.T) {
+	quests, errs := LoadQuests("../quests")
+	if len(errs) != 0 || len(quests) == 0 {
+		t.Fatalf("LoadQuests failed: %v", errs)
+	}
+	for _, quest := range quests {
+		if !strings.HasPrefix(quest.Path, "../quests/") {
+			t.Errorf("unexpected path for %s: %q", quest.QuestID, quest.Path)
+		}
+	}
+}
+
+func TestWriteGitHub_ConfigPaths(t *testing.T) {
+	root := t.TempDir()
+	questsDir := filepath.Join(root, "content", "quests")
+	if err := os.MkdirAll(questsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(questsDir, "broken.yaml"), []byte("QuestNodes: {"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeConfig(t, root, "Quests: content/quests\n")
+	data, schemas := absPath(t, "../data"), absPath(t, "../schemas")
+
+	// Run from a directory below the configuration file, which is found
+	// automatically
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join(root, "content")); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	opts := checkOptions{dataPath: data, schemasPath: schemas}
+	fs.StringVar(&opts.questsPath, "quests", "./quests", "")
+	if opts.config, err = applyConfig(fs, ""); err != nil {
+		t.Fatal(err)
+	}
+	report, err := check(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := writeGitHub(&out, report, true); err != nil {
+		t.Fatal(err)
+	}
+	if want := "::error file=quests/broken.yaml,"; !strings.HasPrefix(out.String(), want) {
+		t.Errorf("expected an annotation with a path relative to the current directory, got %q", out.String())
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// maxPaths limits path enumeration for quests with very many combinations.
+const maxPaths = 10000
+
+// pathStep is a node on a path, with the Decision option or
+// ConditionBranch arm taken to leave it.
+type pathStep struct {
+	NodeID int
+	Option int
+	Branch string
+}
+
+// questPath is one way from an EntryPoint to a terminal Actions node,
+// with everything the player ends up with along the way. Items holds the
+// items gained minus the items lost, so it is negative for items the
+// player loses.
+type questPath struct {
+	Steps      []pathStep
+	Outcome    string
+	Items      map[string]int
+	Currency   int
+	Experience int
+	Factions   map[string]int
+	Journal    []I18nString
+}
+
+// runPaths implements the "paths" subcommand, which lists every path
+// through a quest together with its outcome, rewards and journal.
+func runPaths(args []string) int {
+	fs := flag.NewFlagSet("paths", flag.ContinueOnError)
+	questsPath := fs.String("quests", "./quests", "Path to quests directory")
+	questID := fs.String("quest", "", "QuestID of the quest to analyze (default: all quests)")
+	lang := fs.String("lang", "en-US", "Language of journal entries (en-US or de-DE)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if _, err := applyConfig(fs, ""); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	quests, loadErrors := LoadQuests(*questsPath)
+	for _, err := range loadErrors {
+		fmt.Fprintf(os.Stderr, "[LOAD ERROR]: %v\n", err)
+	}
+	if *questID != "" {
+		quest := findQuest(quests, *questID)
+		if quest == nil {
+			fmt.Fprintf(os.Stderr, "Error: quest %q not found\n", *questID)
+			return 2
+		}
+		quests = []*Quest{quest}
+	}
+
+	for i, quest := range quests {
+		if i > 0 {
+			fmt.Println()
+		}
+		paths, truncated := EnumeratePaths(quest)
+		printPaths(os.Stdout, quest, paths, truncated, *lang)
+	}
+	if len(loadErrors) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// EnumeratePaths follows every Decision option, both ConditionBranch arms
+// and each of several parallel NextNodes from every EntryPoint until a
+// terminal Actions node is reached. truncated is set if there were more
+// than maxPaths paths.
+func EnumeratePaths(quest *Quest) (paths []questPath, truncated bool) {
+	nodes := make(map[int]*QuestNode)
+	for i := range quest.QuestNodes {
+		nodes[quest.QuestNodes[i].NodeID] = &quest.QuestNodes[i]
+	}
+	onPath := make(map[int]bool)
+
+	var walk func(nodeID int, steps []pathStep)
+	follow := func(next []int, steps []pathStep, step pathStep) {
+		extended := append(append([]pathStep(nil), steps...), step)
+		for _, id := range next {
+			walk(id, extended)
+		}
+	}
+	walk = func(nodeID int, steps []pathStep) {
+		node, exists := nodes[nodeID]
+		if !exists || onPath[nodeID] || truncated {
+			return
+		}
+		onPath[nodeID] = true
+		defer delete(onPath, nodeID)
+
+		switch node.NodeType {
+		case "Decision":
+			for i, opt := range node.Options {
+				follow(opt.NextNodes, steps, pathStep{NodeID: nodeID, Option: i + 1})
+			}
+		case "ConditionBranch":
+			follow(node.NextNodesIfTrue, steps, pathStep{NodeID: nodeID, Branch: "true"})
+			follow(node.NextNodesIfFalse, steps, pathStep{NodeID: nodeID, Branch: "false"})
+		default:
+			step := pathStep{NodeID: nodeID}
+			if outcome := terminalOutcome(node); outcome != "" {
+				if len(paths) >= maxPaths {
+					truncated = true
+					return
+				}
+				all := append(append([]pathStep(nil), steps...), step)
+				paths = append(paths, summarizePath(all, outcome, nodes))
+				return
+			}
+			follow(node.NextNodes, steps, step)
+		}
+	}
+
+	for _, node := range quest.QuestNodes {
+		if node.NodeType == "EntryPoint" {
+			walk(node.NodeID, nil)
+		}
+	}
+	return paths, truncated
+}
+
+// terminalOutcome returns Complete, Fail or Decline for a terminal
+// Actions node, or an empty string for any other node.
+func terminalOutcome(node *QuestNode) string {
+	if node.NodeType != "Actions" {
+		return ""
+	}
+	for _, action := range node.Actions {
+		switch action {
+		case "CompleteQuest":
+			return "Complete"
+		case "FailQuest":
+			return "Fail"
+		case "DeclineQuest":
+			return "Decline"
+		}
+	}
+	return ""
+}
+
+func summarizePath(steps []pathStep, outcome string, nodes map[int]*QuestNode) questPath {
+	path := questPath{
+		Steps:    steps,
+		Outcome:  outcome,
+		Items:    make(map[string]int),
+		Factions: make(map[string]int),
+	}
+	for _, step := range steps {
+		for _, action := range nodes[step.NodeID].Actions {
+			a, ok := action.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for _, stack := range itemStacks(a["ItemsGained"]) {
+				path.Items[stack.Type] += stack.Count
+			}
+			for _, stack := range itemStacks(a["ItemsLost"]) {
+				path.Items[stack.Type] -= stack.Count
+			}
+			if amount, ok := toInt(a["Currency"]); ok {
+				path.Currency += amount
+			}
+			if amount, ok := toInt(a["Experience"]); ok {
+				path.Experience += amount
+			}
+			if fs, ok := a["FactionStanding"].(map[string]interface{}); ok {
+				faction, _ := fs["Faction"].(string)
+				points, _ := toInt(fs["Points"])
+				path.Factions[faction] += points
+			}
+			if je, ok := a["JournalEntry"].(map[string]interface{}); ok {
+				path.Journal = append(path.Journal, toI18n(je))
+			}
+		}
+	}
+	// Items that are taken away again are no reward
+	for item, count := range path.Items {
+		if count == 0 {
+			delete(path.Items, item)
+		}
+	}
+	return path
+}
+
+func printPaths(out io.Writer, quest *Quest, paths []questPath, truncated bool, lang string) {
+	fmt.Fprintf(out, "%s: %d paths\n", quest.QuestID, len(paths))
+	if truncated {
+		fmt.Fprintf(out, "(only the first %d paths are listed)\n", maxPaths)
+	}
+	for i, path := range paths {
+		fmt.Fprintf(out, "\nPath %d: %s\n", i+1, path.Outcome)
+		fmt.Fprintf(out, "  Nodes:   %s\n", describeSteps(path.Steps))
+		fmt.Fprintf(out, "  Rewards: %s\n", describeRewards(path))
+		for j, entry := range path.Journal {
+			label := "  Journal:"
+			if j > 0 {
+				label = "          "
+			}
+			fmt.Fprintf(out, "%s %q\n", label, localized(entry, lang))
+		}
+	}
+}
+
+func describeSteps(steps []pathStep) string {
+	parts := make([]string, 0, len(steps))
+	for _, step := range steps {
+		switch {
+		case step.Option > 0:
+			parts = append(parts, fmt.Sprintf("%d (option %d)", step.NodeID, step.Option))
+		case step.Branch != "":
+			parts = append(parts, fmt.Sprintf("%d (%s)", step.NodeID, step.Branch))
+		default:
+			parts = append(parts, fmt.Sprint(step.NodeID))
+		}
+	}
+	return strings.Join(parts, " -> ")
+}
+
+func describeRewards(path questPath) string {
+	var parts []string
+	for _, item := range sortedKeys(path.Items) {
+		parts = append(parts, fmt.Sprintf("%dx %s", path.Items[item], item))
+	}
+	if path.Currency != 0 {
+		parts = append(parts, fmt.Sprintf("%d currency", path.Currency))
+	}
+	if path.Experience != 0 {
+		parts = append(parts, fmt.Sprintf("%d experience", path.Experience))
+	}
+	for _, faction := range sortedKeys(path.Factions) {
+		parts = append(parts, fmt.Sprintf("%s %+d", faction, path.Factions[faction]))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func pathsQuest() *Quest {
+	return &Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Decision", Options: []DialogOption{
+				{NextNodes: []int{2}},
+				{NextNodes: []int{5}},
+			}},
+			{NodeID: 2, NodeType: "ConditionBranch", NextNodesIfTrue: []int{3}, NextNodesIfFalse: []int{4}},
+			{NodeID: 3, NodeType: "Actions", Actions: []interface{}{
+				map[string]interface{}{"JournalEntry": map[string]interface{}{"en-US": "Done", "de-DE": "Fertig"}},
+				map[string]interface{}{"ItemsGained": []interface{}{map[string]interface{}{"Type": "PackOfNails", "Count": 2}}},
+				map[string]interface{}{"Currency": 50},
+				map[string]interface{}{"FactionStanding": map[string]interface{}{"Faction": "Town", "Points": 3}},
+				"CompleteQuest",
+			}},
+			{NodeID: 4, NodeType: "Actions", Actions: []interface{}{"FailQuest"}},
+			{NodeID: 5, NodeType: "Actions", Actions: []interface{}{"DeclineQuest"}},
+		},
+	}
+}
+
+func TestEnumeratePaths(t *testing.T) {
+	paths, truncated := EnumeratePaths(pathsQuest())
+
+	if truncated {
+		t.Error("expected paths not to be truncated")
+	}
+	if len(paths) != 3 {
+		t.Fatalf("expected 3 paths, got %d", len(paths))
+	}
+
+	wantSteps := []pathStep{{NodeID: 0}, {NodeID: 1, Option: 1}, {NodeID: 2, Branch: "true"}, {NodeID: 3}}
+	if !reflect.DeepEqual(paths[0].Steps, wantSteps) {
+		t.Errorf("unexpected steps: %+v", paths[0].Steps)
+	}
+	outcomes := []string{paths[0].Outcome, paths[1].Outcome, paths[2].Outcome}
+	if !reflect.DeepEqual(outcomes, []string{"Complete", "Fail", "Decline"}) {
+		t.Errorf("unexpected outcomes: %v", outcomes)
+	}
+	if paths[0].Items["PackOfNails"] != 2 || paths[0].Currency != 50 || paths[0].Factions["Town"] != 3 {
+		t.Errorf("unexpected rewards: %+v", paths[0])
+	}
+	if len(paths[0].Journal) != 1 || len(paths[1].Journal) != 0 {
+		t.Errorf("unexpected journal entries: %v / %v", paths[0].Journal, paths[1].Journal)
+	}
+}
+
+func TestPrintPaths(t *testing.T) {
+	quest := pathsQuest()
+	paths, truncated := EnumeratePaths(quest)
+
+	var out bytes.Buffer
+	printPaths(&out, quest, paths, truncated, "de-DE")
+
+	for _, want := range []string{
+		"TestQuest: 3 paths",
+		"Path 1: Complete",
+		"Nodes:   0 -> 1 (option 1) -> 2 (true) -> 3",
+		"Rewards: 2x PackOfNails, 50 currency, Town +3",
+		`Journal: "Fertig"`,
+		"Path 3: Decline",
+		"Rewards: none",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestEnumeratePaths_ItemsLost(t *testing.T) {
+	quest := &Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Actions", NextNodes: []int{2}, Actions: []interface{}{
+				map[string]interface{}{"ItemsGained": []interface{}{
+					map[string]interface{}{"Type": "PackOfNails", "Count": 2},
+					map[string]interface{}{"Type": "MineKey", "Count": 1},
+				}},
+			}},
+			{NodeID: 2, NodeType: "Actions", Actions: []interface{}{
+				map[string]interface{}{"ItemsLost": []interface{}{
+					map[string]interface{}{"Type": "MineKey", "Count": 1},
+					map[string]interface{}{"Type": "Rope", "Count": 1},
+				}},
+				"CompleteQuest",
+			}},
+		},
+	}
+
+	paths, _ := EnumeratePaths(quest)
+	if len(paths) != 1 {
+		t.Fatalf("expected 1 path, got %d", len(paths))
+	}
+	// The key is given back, the rope is lost
+	if want := map[string]int{"PackOfNails": 2, "Rope": -1}; !reflect.DeepEqual(paths[0].Items, want) {
+		t.Errorf("expected items %v, got %v", want, paths[0].Items)
+	}
+	if got := describeRewards(paths[0]); got != "2x PackOfNails, -1x Rope" {
+		t.Errorf("unexpected rewards: %q", got)
+	}
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// runPlay implements the "play" subcommand, an interactive text
+// playthrough of a single quest.
+func runPlay(args []string) int {
+	fs := flag.NewFlagSet("play", flag.ContinueOnError)
+	questsPath := fs.String("quests", "./quests", "Path to quests directory")
+	dataPath := fs.String("data", "./data", "Path to reference data directory")
+	questID := fs.String("quest", "", "QuestID of the quest to play")
+	lang := fs.String("lang", "en-US", "Language of quest texts (en-US or de-DE)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if _, err := applyConfig(fs, ""); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if *questID == "" {
+		fmt.Fprintln(os.Stderr, "Error: -quest is required")
+		return 2
+	}
+
+	refData, err := LoadReferenceData(*dataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	quests, loadErrors := LoadQuests(*questsPath)
+	quest := findQuest(quests, *questID)
+	if quest == nil {
+		for _, err := range loadErrors {
+			fmt.Fprintf(os.Stderr, "[LOAD ERROR]: %v\n", err)
+		}
+		fmt.Fprintf(os.Stderr, "Error: quest %q not found\n", *questID)
+		return 2
+	}
+
+	session := newPlaySession(quest, refData, *lang, os.Stdin, os.Stdout)
+	return session.run()
+}
+
+func findQuest(quests []*Quest, questID string) *Quest {
+	for _, q := range quests {
+		if q.QuestID == questID {
+			return q
+		}
+	}
+	return nil
+}
+
+// playSession walks a designer through a quest in the terminal. Whenever
+// conditions need to be evaluated, it asks for the relevant world facts.
+type playSession struct {
+	quest   *Quest
+	refData *ReferenceData
+	lang    string
+	in      *bufio.Scanner
+	out     io.Writer
+	world   *World
+	interp  *Interpreter
+	quit    bool
+}
+
+func newPlaySession(quest *Quest, refData *ReferenceData, lang string, in io.Reader, out io.Writer) *playSession {
+	s := &playSession{
+		quest:   quest,
+		refData: refData,
+		lang:    lang,
+		in:      bufio.NewScanner(in),
+		out:     out,
+		world:   NewWorld(),
+	}
+	s.interp = NewInterpreter(quest, s.world)
+	s.interp.OnAction = s.showAction
+	s.interp.OnBranch = s.prepareBranch
+	return s
+}
+
+func (s *playSession) run() int {
+	s.printf("=== %s (%s) ===\n", s.text(s.quest.DisplayName), s.quest.QuestID)
+	s.interp.Start()
+
+	for !s.interp.Ended() && !s.quit {
+		active := s.interp.Active()
+		if len(active) == 0 {
+			s.printf("No active nodes left: the quest is stuck.\n")
+			return 1
+		}
+		nodeID, ok := s.pickNode(active)
+		if !ok {
+			break
+		}
+		s.playNode(s.interp.Node(nodeID))
+	}
+
+	if !s.interp.Ended() {
+		s.printf("Playthrough aborted.\n")
+		return 0
+	}
+	s.printf("Quest %s.\n", s.interp.Status())
+	return 0
+}
+
+func (s *playSession) pickNode(active []int) (int, bool) {
+	s.printf("\nActive nodes:\n")
+	for i, id := range active {
+		s.printf("  %d) %s\n", i+1, s.describeNode(s.interp.Node(id)))
+	}
+	for {
+		line, ok := s.prompt(fmt.Sprintf("Continue with [1-%d, q to quit, default 1]: ", len(active)))
+		if !ok || line == "q" {
+			s.quit = true
+			return 0, false
+		}
+		if line == "" {
+			return active[0], true
+		}
+		if n, err := strconv.Atoi(line); err == nil && n >= 1 && n <= len(active) {
+			return active[n-1], true
+		}
+	}
+}
+
+func (s *playSession) playNode(node *QuestNode) {
+	switch node.NodeType {
+	case "Dialog":
+		s.playDialog(node)
+	case "Decision":
+		s.playDecision(node)
+	case "ConditionWatcher":
+		s.playWatcher(node)
+	default:
+		s.printf("Node %d (%s) cannot progress.\n", node.NodeID, node.NodeType)
+	}
+}
+
+func (s *playSession) playDialog(node *QuestNode) {
+	if len(node.Conditions) > 0 {
+		s.printf("The conversation requires %s:\n", s.describeRequired(node.ConditionsRequired, len(node.Conditions)))
+		s.askFacts(node.NodeID, node.Conditions)
+		if !s.interp.CanTalk(node.NodeID) {
+			s.printf("The conversation is not available yet.\n")
+			return
+		}
+	}
+	for _, msg := range node.Messages {
+		s.printf("%s: %s\n", s.npcName(msg.Speaker), s.text(msg.Text))
+	}
+	if err := s.interp.Talk(node.NodeID); err != nil {
+		s.printf("Error: %v\n", err)
+	}
+}
+
+func (s *playSession) playDecision(node *QuestNode) {
+	for _, opt := range node.Options {
+		s.askFacts(node.NodeID, opt.Conditions)
+	}
+	if node.Text != nil {
+		s.printf("%s: %s\n", s.npcName(node.Speaker), s.text(*node.Text))
+	}
+
+	options := s.interp.AvailableOptions(node.NodeID)
+	if len(options) == 0 {
+		s.printf("No option is available.\n")
+		return
+	}
+	for _, o := range options {
+		s.printf("  %d) %s\n", o, s.text(node.Options[o-1].Text))
+	}
+	for {
+		line, ok := s.prompt("Your choice: ")
+		if !ok || line == "q" {
+			s.quit = true
+			return
+		}
+		if n, err := strconv.Atoi(line); err == nil {
+			if err := s.interp.Choose(node.NodeID, n); err == nil {
+				return
+			}
+		}
+		s.printf("Please pick one of the listed options.\n")
+	}
+}
+
+func (s *playSession) playWatcher(node *QuestNode) {
+	s.printf("Node %d waits for %s:\n", node.NodeID, s.describeRequired(node.ConditionsRequired, len(node.Conditions)))
+	s.askFacts(node.NodeID, node.Conditions)
+	s.interp.Settle()
+	if s.interp.IsActive(node.NodeID) {
+		s.printf("The conditions are not met yet.\n")
+	}
+}
+
+func (s *playSession) prepareBranch(node *QuestNode) {
+	s.printf("Node %d branches on %s:\n", node.NodeID, s.describeRequired(node.ConditionsRequired, len(node.Conditions)))
+	s.askFacts(node.NodeID, node.Conditions)
+}
+
+// askFacts asks for every world fact the conditions depend on, offering
+// the currently known value as default.
+func (s *playSession) askFacts(nodeID int, conditions []map[string]interface{}) {
+	for _, cond := range conditions {
+		if s.quit {
+			return
+		}
+		s.printf("  - %s\n", describeCondition(cond))
+		s.askFact(nodeID, cond)
+	}
+}
+
+func (s *playSession) askFact(nodeID int, cond map[string]interface{}) {
+	world := s.world
+	progress := s.interp.Progress(nodeID)
+
+	for kind, value := range cond {
+		switch kind {
+		case "QuestCompleted":
+			questID, _ := value.(string)
+			world.CompletedQuests[questID] = s.askBool("Has quest "+questID+" been completed?", world.CompletedQuests[questID])
+		case "ResourceAvailability":
+			ra, _ := value.(map[string]interface{})
+			resource, _ := ra["Resource"].(string)
+			world.Resources[resource] = s.askBool("Is resource "+resource+" available?", world.Resources[resource])
+		case "FactionStanding":
+			fs, _ := value.(map[string]interface{})
+			faction, _ := fs["Faction"].(string)
+			world.Factions[faction] = s.askInt("Standing with "+faction, world.Factions[faction])
+		case "TimePassed":
+			progress.Hours = s.askInt(fmt.Sprintf("Hours passed since node %d became active", nodeID), progress.Hours)
+		case "ItemLost":
+			item, _ := value.(string)
+			s.askEvent(progress, "Has "+item+" been lost?", happeningKey("ItemLost", item))
+		case "Inventory":
+			for _, stack := range itemStacks(value) {
+				if stack.QuestItem {
+					world.QuestItems[stack.Type] = s.askInt("Quest items "+stack.Type+" carried", world.QuestItems[stack.Type])
+				} else {
+					world.Inventory[stack.Type] = s.askInt("Items "+stack.Type+" carried", world.Inventory[stack.Type])
+				}
+			}
+		case "Variable":
+			v, _ := value.(map[string]interface{})
+			name, _ := v["VariableName"].(string)
+			world.Variables[name] = s.askInt("Value of variable "+name, world.Variables[name])
+		case "EventTriggered":
+			et, _ := value.(map[string]interface{})
+			event, _ := et["Event"].(string)
+			key := happeningKey("EventTriggered", event)
+			progress.Events[key] = s.askInt(fmt.Sprintf("Times %s was triggered since node %d became active", event, nodeID), progress.Events[key])
+		case "ItemUsedOnObject":
+			iu, _ := value.(map[string]interface{})
+			item, _ := iu["Item"].(string)
+			object, _ := iu["Object"].(string)
+			s.askEvent(progress, "Has "+item+" been used on "+object+"?", happeningKey("ItemUsedOnObject", item, object))
+		case "ItemUsedOnNPC":
+			iu, _ := value.(map[string]interface{})
+			item, _ := iu["Item"].(string)
+			npc, _ := iu["NPC"].(string)
+			s.askEvent(progress, "Has "+item+" been used on "+npc+"?", happeningKey("ItemUsedOnNPC", item, npc))
+		}
+	}
+}
+
+func (s *playSession) askEvent(progress *NodeProgress, question, key string) {
+	if s.askBool(question, progress.Events[key] > 0) {
+		if progress.Events[key] == 0 {
+			progress.Events[key] = 1
+		}
+	} else {
+		delete(progress.Events, key)
+	}
+}
+
+func (s *playSession) askBool(question string, current bool) bool {
+	def := "n"
+	if current {
+		def = "y"
+	}
+	for {
+		line, ok := s.prompt(fmt.Sprintf("    %s [y/n, default %s]: ", question, def))
+		if !ok {
+			return current
+		}
+		switch strings.ToLower(line) {
+		case "":
+			return current
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		}
+	}
+}
+
+func (s *playSession) askInt(question string, current int) int {
+	for {
+		line, ok := s.prompt(fmt.Sprintf("    %s [default %d]: ", question, current))
+		if !ok || line == "" {
+			return current
+		}
+		if n, err := strconv.Atoi(line); err == nil {
+			return n
+		}
+	}
+}
+
+func (s *playSession) showAction(nodeID int, action interface{}) {
+	switch a := action.(type) {
+	case string:
+		s.printf("  [node %d] %s\n", nodeID, a)
+	case map[string]interface{}:
+		if je, ok := a["JournalEntry"].(map[string]interface{}); ok {
+			s.printf("  [node %d] Journal: %s\n", nodeID, s.text(toI18n(je)))
+			return
+		}
+		if qsd, ok := a["QuestStageDescription"].(map[string]interface{}); ok {
+			s.printf("  [node %d] Stage: %s\n", nodeID, s.text(toI18n(qsd)))
+			return
+		}
+		s.printf("  [node %d] %s\n", nodeID, describeAction(a))
+	}
+}
+
+func (s *playSession) describeNode(node *QuestNode) string {
+	switch node.NodeType {
+	case "Dialog":
+		return fmt.Sprintf("Node %d: Dialog with %s", node.NodeID, s.npcName(node.ConversationPartner))
+	case "Decision":
+		return fmt.Sprintf("Node %d: Decision offered by %s", node.NodeID, s.npcName(node.Speaker))
+	case "ConditionWatcher":
+		parts := make([]string, 0, len(node.Conditions))
+		for _, cond := range node.Conditions {
+			parts = append(parts, describeCondition(cond))
+		}
+		return fmt.Sprintf("Node %d: waiting for %s", node.NodeID, strings.Join(parts, "; "))
+	}
+	return fmt.Sprintf("Node %d: %s", node.NodeID, node.NodeType)
+}
+
+func (s *playSession) describeRequired(required string, total int) string {
+	n := requiredCount(required, total)
+	if n >= total {
+		return "all conditions"
+	}
+	return fmt.Sprintf("%d of %d conditions", n, total)
+}
+
+func (s *playSession) npcName(npcID string) string {
+	if npcID == "Player" {
+		return "You"
+	}
+	if name, ok := s.refData.NPCNames[npcID]; ok {
+		if text := s.text(name); text != "" {
+			return text
+		}
+	}
+	return npcID
+}
+
+func (s *playSession) text(str I18nString) string {
+	return localized(str, s.lang)
+}
+
+// localized returns the text in the given language, falling back to
+// whichever translation exists.
+func localized(str I18nString, lang string) string {
+	if lang == "de-DE" && str.DeDE != "" {
+		return str.DeDE
+	}
+	if str.EnUS != "" {
+		return str.EnUS
+	}
+	return str.DeDE
+}
+
+func (s *playSession) prompt(question string) (string, bool) {
+	fmt.Fprint(s.out, question)
+	if !s.in.Scan() {
+		fmt.Fprintln(s.out)
+		return "", false
+	}
+	return strings.TrimSpace(s.in.Text()), true
+}
+
+func (s *playSession) printf(format string, args ...interface{}) {
+	fmt.Fprintf(s.out, format, args...)
+}
+
+// describeCondition renders a condition as a short human readable text.
+func describeCondition(cond map[string]interface{}) string {
+	for kind, value := range cond {
+		switch v := value.(type) {
+		case string:
+			return kind + " " + v
+		case map[string]interface{}:
+			return kind + " " + describeFields(v)
+		case []interface{}:
+			parts := make([]string, 0, len(v))
+			for _, entry := range v {
+				if m, ok := entry.(map[string]interface{}); ok {
+					parts = append(parts, describeFields(m))
+				}
+			}
+			return kind + " " + strings.Join(parts, ", ")
+		}
+		return fmt.Sprintf("%s %v", kind, value)
+	}
+	return "(empty condition)"
+}
+
+// describeAction renders a non-string action as a short human readable text.
+func describeAction(action map[string]interface{}) string {
+	return describeCondition(action)
+}
+
+func describeFields(m map[string]interface{}) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%v", k, m[k]))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Position is a line and column in a YAML file, both starting at 1.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// Positions maps the JSON paths of the values in a YAML file, written like
+// the paths of schema errors (e.g. "$.QuestNodes[2].NextNodes"), to where
+// they start. Mapping entries start at their key.
+type Positions map[string]Position
+
+// buildPositions records the position of every value below node.
+func buildPositions(node *yaml.Node) Positions {
+	positions := make(Positions)
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	positions.add(node, "$", Position{node.Line, node.Column})
+	return positions
+}
+
+func (p Positions) add(node *yaml.Node, path string, pos Position) {
+	p[path] = pos
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			p.add(value, childPath(path, key.Value), Position{key.Line, key.Column})
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			p.add(item, fmt.Sprintf("%s[%d]", path, i), Position{item.Line, item.Column})
+		}
+	}
+}
+
+// Lookup returns the position of path, or of the closest value enclosing
+// it if path itself does not exist.
+func (p Positions) Lookup(path string) (Position, bool) {
+	for {
+		if pos, ok := p[path]; ok {
+			return pos, true
+		}
+		parent, ok := parentPath(path)
+		if !ok {
+			return Position{}, false
+		}
+		path = parent
+	}
+}
+
+// parentPath strips the last property or index from a JSON path.
+func parentPath(path string) (string, bool) {
+	if strings.HasSuffix(path, "]") {
+		if i := strings.LastIndex(path, "["); i > 0 {
+			return path[:i], true
+		}
+		return "", false
+	}
+	if i := strings.LastIndex(path, "."); i > 0 {
+		return path[:i], true
+	}
+	return "", false
+}
+
+// locate sets the line and column of an issue in the quest's file: those of
+// the field the issue is about if known, otherwise those of its node, and
+// otherwise those of the quest itself.
+func (q *Quest) locate(issue *ValidationError) {
+	path := issue.Field
+	if path == "" && issue.NodeID != nil {
+		for i, node := range q.QuestNodes {
+			if node.NodeID == *issue.NodeID {
+				path = fmt.Sprintf("$.QuestNodes[%d]", i)
+				break
+			}
+		}
+	}
+	if path == "" {
+		path = "$"
+	}
+	if pos, ok := q.positions.Lookup(path); ok {
+		issue.Line, issue.Column = pos.Line, pos.Column
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestBuildPositions(t *testing.T) {
+	source := `QuestID: Quest1
+DisplayName: { en-US: Test, de-DE: Test }
+QuestNodes:
+  - NodeID: 0
+    NextNodes:
+      - 1
+      - 2
+`
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(source), &root); err != nil {
+		t.Fatal(err)
+	}
+	positions := buildPositions(&root)
+
+	tests := []struct {
+		path string
+		want Position
+	}{
+		{"$", Position{1, 1}},
+		{"$.QuestID", Position{1, 1}},
+		{`$.DisplayName["de-DE"]`, Position{2, 29}},
+		{"$.QuestNodes[0]", Position{4, 5}},
+		{"$.QuestNodes[0].NextNodes", Position{5, 5}},
+		{"$.QuestNodes[0].NextNodes[1]", Position{7, 9}},
+		// Missing values fall back to the closest enclosing value.
+		{"$.QuestNodes[0].Conditions[3].Variable", Position{4, 5}},
+		{`$.DisplayName["fr-FR"]`, Position{2, 1}},
+	}
+	for _, tt := range tests {
+		if got, ok := positions.Lookup(tt.path); !ok || got != tt.want {
+			t.Errorf("Lookup(%s) = %v, %v; want %v", tt.path, got, ok, tt.want)
+		}
+	}
+}
+
+func TestQuestLocate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quest.yaml")
+	source := `QuestID: Quest1
+QuestNodes:
+  - NodeID: 0
+    NodeType: EntryPoint
+    NextNodes: [7]
+  - NodeID: 7
+    NodeType: Actions
+    Actions: [CompleteQuest]
+Migrations:
+  - FromVersion: 3
+`
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	quest, err := loadQuestFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		issue ValidationError
+		want  Position
+	}{
+		{ValidationError{NodeID: intPtr(7)}, Position{6, 5}},
+		{ValidationError{NodeID: intPtr(7), Field: "$.QuestNodes[1].Actions[0]"}, Position{8, 15}},
+		{ValidationError{Field: "$.Migrations[0]"}, Position{10, 5}},
+		{ValidationError{NodeID: intPtr(99)}, Position{1, 1}},
+		{ValidationError{}, Position{1, 1}},
+	}
+	for _, tt := range tests {
+		issue := tt.issue
+		quest.locate(&issue)
+		if got := (Position{issue.Line, issue.Column}); got != tt.want {
+			t.Errorf("locate(%+v) = %v, want %v", tt.issue, got, tt.want)
+		}
+	}
+}
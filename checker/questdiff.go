@@ -0,0 +1,322 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Kinds of quest changes
+const (
+	changeQuest          = "quest-changed"
+	changeNodeAdded      = "node-added"
+	changeNodeRemoved    = "node-removed"
+	changeNode           = "node-changed"
+	changeEdgeAdded      = "edge-added"
+	changeEdgeRemoved    = "edge-removed"
+	changeOptionAdded    = "option-added"
+	changeOptionRemoved  = "option-removed"
+	changeMessageAdded   = "message-added"
+	changeMessageRemoved = "message-removed"
+	changeConditions     = "conditions-changed"
+	changeActions        = "actions-changed"
+	changeText           = "text-changed"
+)
+
+// QuestChange is a difference between two versions of a quest, in terms of
+// the quest model rather than of lines. NodeID is nil for changes of the
+// quest itself; Field names the changed value within the quest or node,
+// like "NextNodes" or "Options[1].Text". Target is the node an added or
+// removed edge leads to.
+type QuestChange struct {
+	Kind     string `json:"kind"`
+	NodeID   *int   `json:"nodeId,omitempty"`
+	Field    string `json:"field,omitempty"`
+	Language string `json:"language,omitempty"`
+	Target   *int   `json:"target,omitempty"`
+	Old      string `json:"old,omitempty"`
+	New      string `json:"new,omitempty"`
+}
+
+func (c QuestChange) String() string {
+	where := "quest"
+	if c.NodeID != nil {
+		where = fmt.Sprintf("node %d", *c.NodeID)
+	}
+	switch c.Kind {
+	case changeNodeAdded:
+		return fmt.Sprintf("+ %s (%s)", where, c.New)
+	case changeNodeRemoved:
+		return fmt.Sprintf("- %s (%s)", where, c.Old)
+	case changeEdgeAdded:
+		return fmt.Sprintf("%s: + %s -> %d", where, c.Field, *c.Target)
+	case changeEdgeRemoved:
+		return fmt.Sprintf("%s: - %s -> %d", where, c.Field, *c.Target)
+	case changeOptionAdded, changeMessageAdded:
+		return fmt.Sprintf("%s: + %s %q", where, c.Field, c.New)
+	case changeOptionRemoved, changeMessageRemoved:
+		return fmt.Sprintf("%s: - %s %q", where, c.Field, c.Old)
+	case changeText:
+		return fmt.Sprintf("%s: %s [%s]: %q -> %q", where, c.Field, c.Language, c.Old, c.New)
+	default:
+		return fmt.Sprintf("%s: %s: %s -> %s", where, c.Field, orNone(c.Old), orNone(c.New))
+	}
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+// runDiff implements "checker diff": it lists the changes between two
+// versions of a quest file, such as added nodes, rewired edges and changed
+// texts. It exits with 1 if the quests differ, like diff.
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	format := fs.String("format", "text", "Output format: text or json")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: checker diff [-format text|json] old.yaml new.yaml")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q\n", *format)
+		return 2
+	}
+
+	var quests [2]*Quest
+	for i, path := range fs.Args() {
+		quest, err := loadQuestFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", path, err)
+			return 2
+		}
+		quests[i] = quest
+	}
+
+	changes := DiffQuests(quests[0], quests[1])
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(changes); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	} else if len(changes) > 0 {
+		fmt.Printf("--- %s\n+++ %s\n", fs.Arg(0), fs.Arg(1))
+		for _, change := range changes {
+			fmt.Println(change)
+		}
+	}
+
+	if len(changes) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// DiffQuests returns the changes from quest a to quest b: the changes of
+// the quest's own properties, then those of its nodes by NodeID. The edges
+// of added and removed nodes are reported as well, so that the changes
+// describe the whole change of the quest flow.
+func DiffQuests(a, b *Quest) []QuestChange {
+	d := questDiff{changes: []QuestChange{}}
+	d.value(nil, changeQuest, "QuestTypeVersion", a.QuestTypeVersion, b.QuestTypeVersion)
+	d.value(nil, changeQuest, "QuestVersion", a.QuestVersion, b.QuestVersion)
+	d.value(nil, changeQuest, "QuestID", a.QuestID, b.QuestID)
+	d.value(nil, changeQuest, "QuestType", a.QuestType, b.QuestType)
+	d.text(nil, "DisplayName", a.DisplayName, b.DisplayName)
+	d.value(nil, changeQuest, "Repeatable", a.Repeatable, b.Repeatable)
+	d.value(nil, changeQuest, "Migrations", a.Migrations, b.Migrations)
+	d.value(nil, changeQuest, "Suppressions", a.Suppressions, b.Suppressions)
+
+	nodesA, nodesB := nodesByID(a), nodesByID(b)
+	var ids []int
+	for id := range nodesA {
+		ids = append(ids, id)
+	}
+	for id := range nodesB {
+		if _, ok := nodesA[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		nodeA, nodeB := nodesA[id], nodesB[id]
+		switch {
+		case nodeA == nil:
+			d.add(QuestChange{Kind: changeNodeAdded, NodeID: intPtr(id), New: nodeB.NodeType})
+			d.edges(id, &QuestNode{}, nodeB)
+		case nodeB == nil:
+			d.add(QuestChange{Kind: changeNodeRemoved, NodeID: intPtr(id), Old: nodeA.NodeType})
+			d.edges(id, nodeA, &QuestNode{})
+		default:
+			d.node(id, nodeA, nodeB)
+		}
+	}
+	return d.changes
+}
+
+func nodesByID(quest *Quest) map[int]*QuestNode {
+	nodes := make(map[int]*QuestNode, len(quest.QuestNodes))
+	for i := range quest.QuestNodes {
+		nodes[quest.QuestNodes[i].NodeID] = &quest.QuestNodes[i]
+	}
+	return nodes
+}
+
+// questDiff collects the changes between two versions of a quest.
+type questDiff struct {
+	changes []QuestChange
+}
+
+func (d *questDiff) add(change QuestChange) {
+	d.changes = append(d.changes, change)
+}
+
+// node adds the changes between two versions of a node.
+func (d *questDiff) node(id int, a, b *QuestNode) {
+	nodeID := intPtr(id)
+	d.value(nodeID, changeNode, "NodeType", a.NodeType, b.NodeType)
+	d.edges(id, a, b)
+	d.value(nodeID, changeConditions, "Conditions", a.Conditions, b.Conditions)
+	d.value(nodeID, changeNode, "ConditionsRequired", a.ConditionsRequired, b.ConditionsRequired)
+	d.value(nodeID, changeNode, "ConversationPartner", a.ConversationPartner, b.ConversationPartner)
+	d.value(nodeID, changeNode, "Speaker", a.Speaker, b.Speaker)
+
+	var textA, textB I18nString
+	if a.Text != nil {
+		textA = *a.Text
+	}
+	if b.Text != nil {
+		textB = *b.Text
+	}
+	d.text(nodeID, "Text", textA, textB)
+
+	for i := 0; i < max(len(a.Options), len(b.Options)); i++ {
+		field := fmt.Sprintf("Options[%d]", i)
+		switch {
+		case i >= len(a.Options):
+			d.add(QuestChange{Kind: changeOptionAdded, NodeID: nodeID, Field: field, New: b.Options[i].Text.In(supportedLanguages[0])})
+		case i >= len(b.Options):
+			d.add(QuestChange{Kind: changeOptionRemoved, NodeID: nodeID, Field: field, Old: a.Options[i].Text.In(supportedLanguages[0])})
+		default:
+			d.text(nodeID, field+".Text", a.Options[i].Text, b.Options[i].Text)
+			d.value(nodeID, changeConditions, field+".Conditions", a.Options[i].Conditions, b.Options[i].Conditions)
+		}
+	}
+
+	for i := 0; i < max(len(a.Messages), len(b.Messages)); i++ {
+		field := fmt.Sprintf("Messages[%d]", i)
+		switch {
+		case i >= len(a.Messages):
+			d.add(QuestChange{Kind: changeMessageAdded, NodeID: nodeID, Field: field, New: b.Messages[i].Text.In(supportedLanguages[0])})
+		case i >= len(b.Messages):
+			d.add(QuestChange{Kind: changeMessageRemoved, NodeID: nodeID, Field: field, Old: a.Messages[i].Text.In(supportedLanguages[0])})
+		default:
+			d.value(nodeID, changeNode, field+".Speaker", a.Messages[i].Speaker, b.Messages[i].Speaker)
+			d.text(nodeID, field+".Text", a.Messages[i].Text, b.Messages[i].Text)
+		}
+	}
+
+	d.value(nodeID, changeActions, "Actions", a.Actions, b.Actions)
+	d.value(nodeID, changeNode, "Suppressions", a.Suppressions, b.Suppressions)
+}
+
+// edges adds the edges that were added to or removed from a node, by the
+// list they are in.
+func (d *questDiff) edges(id int, a, b *QuestNode) {
+	d.edgeList(id, "NextNodes", a.NextNodes, b.NextNodes)
+	d.edgeList(id, "NextNodesIfTrue", a.NextNodesIfTrue, b.NextNodesIfTrue)
+	d.edgeList(id, "NextNodesIfFalse", a.NextNodesIfFalse, b.NextNodesIfFalse)
+	for i := 0; i < max(len(a.Options), len(b.Options)); i++ {
+		var nextA, nextB []int
+		if i < len(a.Options) {
+			nextA = a.Options[i].NextNodes
+		}
+		if i < len(b.Options) {
+			nextB = b.Options[i].NextNodes
+		}
+		d.edgeList(id, fmt.Sprintf("Options[%d].NextNodes", i), nextA, nextB)
+	}
+}
+
+func (d *questDiff) edgeList(id int, field string, a, b []int) {
+	for _, target := range subtractEdges(a, b) {
+		d.add(QuestChange{Kind: changeEdgeRemoved, NodeID: intPtr(id), Field: field, Target: intPtr(target)})
+	}
+	for _, target := range subtractEdges(b, a) {
+		d.add(QuestChange{Kind: changeEdgeAdded, NodeID: intPtr(id), Field: field, Target: intPtr(target)})
+	}
+}
+
+// subtractEdges returns the targets of a that are not in b, counting
+// duplicates.
+func subtractEdges(a, b []int) []int {
+	remaining := make(map[int]int)
+	for _, target := range b {
+		remaining[target]++
+	}
+	var missing []int
+	for _, target := range a {
+		if remaining[target] > 0 {
+			remaining[target]--
+		} else {
+			missing = append(missing, target)
+		}
+	}
+	return missing
+}
+
+// text adds a change for each language in which a text differs.
+func (d *questDiff) text(nodeID *int, field string, a, b I18nString) {
+	for _, lang := range supportedLanguages {
+		if a.In(lang) != b.In(lang) {
+			d.add(QuestChange{Kind: changeText, NodeID: nodeID, Field: field, Language: lang, Old: a.In(lang), New: b.In(lang)})
+		}
+	}
+}
+
+// value adds a change if a value differs, with both versions rendered as
+// JSON. Empty lists count as missing.
+func (d *questDiff) value(nodeID *int, kind, field string, a, b interface{}) {
+	oldValue, newValue := renderValue(a), renderValue(b)
+	if oldValue != newValue {
+		d.add(QuestChange{Kind: kind, NodeID: nodeID, Field: field, Old: oldValue, New: newValue})
+	}
+}
+
+func renderValue(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String()
+	case reflect.Int:
+		if rv.Int() == 0 {
+			return ""
+		}
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Slice, reflect.Map:
+		if rv.Len() == 0 {
+			return ""
+		}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func parseDiffQuest(t *testing.T, source string) *Quest {
+	t.Helper()
+	var quest Quest
+	if err := yaml.Unmarshal([]byte(source), &quest); err != nil {
+		t.Fatal(err)
+	}
+	return &quest
+}
+
+func diffStrings(changes []QuestChange) []string {
+	lines := []string{}
+	for _, change := range changes {
+		lines = append(lines, change.String())
+	}
+	return lines
+}
+
+func TestDiffQuests(t *testing.T) {
+	old := parseDiffQuest(t, `
+QuestVersion: 1
+QuestID: Diffed
+DisplayName: { en-US: Diffed, de-DE: Verglichen }
+QuestNodes:
+  - NodeID: 0
+    NodeType: EntryPoint
+    NextNodes: [1]
+  - NodeID: 1
+    NodeType: PlayerDecisionDialog
+    ConversationPartner: NPC:Smith
+    Text: { en-US: Hello, de-DE: Hallo }
+    Options:
+      - Text: { en-US: Bye, de-DE: Tschüss }
+        NextNodes: [2]
+      - Text: { en-US: Help, de-DE: Hilfe }
+        Conditions: [{ FactionStanding: { Faction: Smiths, MinimumLevel: 1 } }]
+        NextNodes: [3]
+  - NodeID: 2
+    NodeType: Actions
+    Actions: [FailQuest]
+  - NodeID: 3
+    NodeType: Actions
+    Actions: [CompleteQuest]
+`)
+	updated := parseDiffQuest(t, `
+QuestVersion: 2
+QuestID: Diffed
+DisplayName: { en-US: Diffed, de-DE: Verglichen }
+QuestNodes:
+  - NodeID: 3
+    NodeType: Actions
+    Actions: [CompleteQuest, { ItemsGained: [{ Type: Coin, Count: 1 }] }]
+  - NodeID: 0
+    NodeType: EntryPoint
+    NextNodes: [1]
+  - NodeID: 1
+    NodeType: PlayerDecisionDialog
+    ConversationPartner: NPC:Smith
+    Text: { en-US: Hello, de-DE: Guten Tag }
+    Options:
+      - Text: { en-US: Bye, de-DE: Tschüss }
+        NextNodes: [4]
+      - Text: { en-US: Help, de-DE: Hilfe }
+        Conditions: [{ FactionStanding: { Faction: Smiths, MinimumLevel: 2 } }]
+        NextNodes: [3]
+  - NodeID: 4
+    NodeType: ConditionBranch
+    Conditions: [{ EventTriggered: { Event: Rain } }]
+    NextNodesIfTrue: [3]
+    NextNodesIfFalse: [3]
+`)
+
+	want := []string{
+		"quest: QuestVersion: 1 -> 2",
+		"node 1: - Options[0].NextNodes -> 2",
+		"node 1: + Options[0].NextNodes -> 4",
+		`node 1: Text [de-DE]: "Hallo" -> "Guten Tag"`,
+		`node 1: Options[1].Conditions: [{"FactionStanding":{"Faction":"Smiths","MinimumLevel":1}}] -> [{"FactionStanding":{"Faction":"Smiths","MinimumLevel":2}}]`,
+		"- node 2 (Actions)",
+		`node 3: Actions: ["CompleteQuest"] -> ["CompleteQuest",{"ItemsGained":[{"Count":1,"Type":"Coin"}]}]`,
+		"+ node 4 (ConditionBranch)",
+		"node 4: + NextNodesIfTrue -> 3",
+		"node 4: + NextNodesIfFalse -> 3",
+	}
+	if got := diffStrings(DiffQuests(old, updated)); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected changes:\n got: %q\nwant: %q", got, want)
+	}
+
+	if got := DiffQuests(old, old); len(got) != 0 {
+		t.Errorf("expected no changes between equal quests, got %q", diffStrings(got))
+	}
+}
+
+func TestDiffQuests_OptionsAndMessages(t *testing.T) {
+	old := parseDiffQuest(t, `
+QuestNodes:
+  - NodeID: 1
+    NodeType: NPCDialog
+    Messages:
+      - { Speaker: NPC:Smith, Text: { en-US: Hi, de-DE: Hi } }
+      - { Speaker: NPC:Smith, Text: { en-US: Bye, de-DE: Tschüss } }
+    NextNodes: [2, 2]
+`)
+	updated := parseDiffQuest(t, `
+QuestNodes:
+  - NodeID: 1
+    NodeType: PlayerDecisionDialog
+    Options:
+      - { Text: { en-US: Go, de-DE: Los }, NextNodes: [2] }
+    Messages:
+      - { Speaker: NPC:Baker, Text: { en-US: Hi, de-DE: Hi } }
+    NextNodes: [2]
+`)
+
+	want := []string{
+		"node 1: NodeType: NPCDialog -> PlayerDecisionDialog",
+		"node 1: - NextNodes -> 2",
+		"node 1: + Options[0].NextNodes -> 2",
+		`node 1: + Options[0] "Go"`,
+		"node 1: Messages[0].Speaker: NPC:Smith -> NPC:Baker",
+		`node 1: - Messages[1] "Bye"`,
+	}
+	if got := diffStrings(DiffQuests(old, updated)); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected changes:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestRunDiff_ExitCode(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.yaml")
+	b := filepath.Join(dir, "b.yaml")
+	if err := os.WriteFile(a, []byte("QuestID: Diffed\nQuestNodes: [{ NodeID: 0, NodeType: EntryPoint }]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte("QuestID: Diffed\nQuestNodes: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	os.Stdout = devNull
+	defer func() { os.Stdout = stdout }()
+
+	if code := runDiff([]string{a, a}); code != 0 {
+		t.Errorf("expected exit code 0 for equal quests, got %d", code)
+	}
+	if code := runDiff([]string{"-format", "json", a, b}); code != 1 {
+		t.Errorf("expected exit code 1 for changed quests, got %d", code)
+	}
+	if code := runDiff([]string{a}); code != 2 {
+		t.Errorf("expected exit code 2 for a missing argument, got %d", code)
+	}
+}
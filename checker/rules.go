@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity classifies a validation issue. The zero value is an error, and
+// lower values are more severe.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityInfo
+)
+
+var severityNames = []string{"error", "warning", "info"}
+
+func (s Severity) String() string {
+	if int(s) < len(severityNames) {
+		return severityNames[s]
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// ParseSeverity converts "error", "warning" or "info" to a Severity.
+func ParseSeverity(name string) (Severity, error) {
+	for i, n := range severityNames {
+		if n == name {
+			return Severity(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown severity %q (want error, warning or info)", name)
+}
+
+// Rule describes a validation rule. Codes are stable, so scripts and
+// suppressions can refer to a rule without matching on message text. The
+// editor backend uses the same codes.
+type Rule struct {
+	Severity Severity
+	Summary  string
+}
+
+// loadErrorRule is the rule of quest files that cannot be loaded.
+const loadErrorRule = "PAT000"
+
+var rules = map[string]Rule{
+	"PAT000": {SeverityError, "quest file cannot be loaded"},
+	"PAT001": {SeverityError, "duplicate edge"},
+	"PAT002": {SeverityError, "edge to a non-existent node"},
+	"PAT003": {SeverityError, "node references itself"},
+	"PAT004": {SeverityError, "duplicate NodeID"},
+	"PAT005": {SeverityError, "node has no incoming connections"},
+	"PAT006": {SeverityError, "quest has no EntryPoint"},
+	"PAT007": {SeverityError, "quest contains a cycle"},
+	"PAT008": {SeverityError, "terminal Actions node has NextNodes"},
+	"PAT009": {SeverityError, "Actions node has more than one terminal action"},
+	"PAT010": {SeverityError, "non-terminal node has no outgoing edges"},
+	"PAT011": {SeverityError, "malformed Decision node"},
+	"PAT012": {SeverityError, "malformed ConditionBranch node"},
+	"PAT013": {SeverityError, "unknown NPC, item, faction, resource or object"},
+	"PAT014": {SeverityError, "quest flow starts without a journal entry"},
+	"PAT015": {SeverityError, "quest flow ends without a journal entry"},
+	"PAT016": {SeverityError, "invalid savegame migration"},
+	"PAT017": {SeverityError, "JSON schema violation"},
+	"PAT020": {SeverityError, "duplicate QuestID"},
+	"PAT021": {SeverityWarning, "duplicate DisplayName"},
+	"PAT022": {SeverityWarning, "duplicate QuestStageDescription"},
+	"PAT023": {SeverityError, "QuestCompleted references an unknown quest"},
+	"PAT024": {SeverityError, "QuestVersion decreased"},
+	"PAT025": {SeverityError, "released node removed or replaced without a version bump or migration"},
+	"PAT026": {SeverityError, "released variable removed without a version bump or migration"},
+	"PAT030": {SeverityError, "suppression without a reason"},
+	"PAT031": {SeverityWarning, "suppression of an unknown rule"},
+	"PAT032": {SeverityInfo, "suppression that matches no issue"},
+	"PAT040": {SeverityError, "playthrough gets stuck or does not end"},
+	"PAT041": {SeverityError, "CompleteQuest before AcceptQuest"},
+	"PAT042": {SeverityError, "quest ends while other nodes are still active"},
+}
+
+// fuzzRules are the rules that only "checker fuzz" reports. Validation
+// never matches their suppressions, so it doesn't report them as unused.
+var fuzzRules = map[string]bool{"PAT040": true, "PAT041": true, "PAT042": true}
+
+// ruleSeverity returns the severity of a rule. Issues without a known rule
+// are errors.
+func ruleSeverity(code string) Severity {
+	if rule, ok := rules[code]; ok {
+		return rule.Severity
+	}
+	return SeverityError
+}
+
+// declaredSuppression is a Suppression together with where it was declared.
+type declaredSuppression struct {
+	Suppression
+	questID string
+	path    string
+	field   string
+	nodeID  *int // nil for quest-wide suppressions
+	used    bool
+}
+
+// ApplySuppressions sets the severity of each issue from its rule and drops
+// the issues that the quests suppress. A quest-wide suppression matches all
+// issues of its rule in the quest, a node suppression only those of its
+// node. Suppressions without a reason are not applied. Problems with the
+// suppressions themselves are appended to the result and cannot be
+// suppressed. It returns the remaining issues and the number of suppressed
+// ones.
+func ApplySuppressions(errs []ValidationError, quests []*Quest) ([]ValidationError, int) {
+	byQuest := make(map[string][]*declaredSuppression)
+	var all []*declaredSuppression
+	for _, quest := range quests {
+		var declared []*declaredSuppression
+		for i, s := range quest.Suppressions {
+			declared = append(declared, &declaredSuppression{
+				Suppression: s,
+				questID:     quest.QuestID,
+				path:        quest.Path,
+				field:       fmt.Sprintf("$.Suppressions[%d]", i),
+			})
+		}
+		for n, node := range quest.QuestNodes {
+			for i, s := range node.Suppressions {
+				declared = append(declared, &declaredSuppression{
+					Suppression: s,
+					questID:     quest.QuestID,
+					path:        quest.Path,
+					field:       fmt.Sprintf("$.QuestNodes[%d].Suppressions[%d]", n, i),
+					nodeID:      intPtr(node.NodeID),
+				})
+			}
+		}
+		byQuest[quest.QuestID] = append(byQuest[quest.QuestID], declared...)
+		all = append(all, declared...)
+	}
+
+	var kept []ValidationError
+	suppressed := 0
+	for _, err := range errs {
+		err.Severity = ruleSeverity(err.Code)
+		matched := false
+		for _, s := range byQuest[err.QuestID] {
+			if err.QuestID == "" || s.Rule != err.Code || strings.TrimSpace(s.Reason) == "" {
+				continue
+			}
+			if s.nodeID != nil && (err.NodeID == nil || *err.NodeID != *s.nodeID) {
+				continue
+			}
+			s.used = true
+			matched = true
+		}
+		if matched {
+			suppressed++
+			continue
+		}
+		kept = append(kept, err)
+	}
+
+	for _, s := range all {
+		var code, message string
+		switch _, known := rules[s.Rule]; {
+		case strings.TrimSpace(s.Reason) == "":
+			code, message = "PAT030", fmt.Sprintf("suppression of %s must give a Reason", s.Rule)
+		case !known:
+			code, message = "PAT031", fmt.Sprintf("suppression of unknown rule %s", s.Rule)
+		case !s.used && !fuzzRules[s.Rule]:
+			code, message = "PAT032", fmt.Sprintf("suppression of %s matches no issue", s.Rule)
+		default:
+			continue
+		}
+		kept = append(kept, ValidationError{
+			Code:     code,
+			Severity: ruleSeverity(code),
+			QuestID:  s.questID,
+			NodeID:   s.nodeID,
+			Path:     s.path,
+			Field:    s.field,
+			Message:  message,
+		})
+	}
+
+	return kept, suppressed
+}
+
+// suppresses reports whether the quest suppresses the issues of a rule at
+// a node, or at the quest if nodeID is nil. Suppressions without a reason
+// are not applied.
+func (q *Quest) suppresses(code string, nodeID *int) bool {
+	for _, s := range q.Suppressions {
+		if s.Rule == code && strings.TrimSpace(s.Reason) != "" {
+			return true
+		}
+	}
+	if nodeID == nil {
+		return false
+	}
+	for _, node := range q.QuestNodes {
+		if node.NodeID != *nodeID {
+			continue
+		}
+		for _, s := range node.Suppressions {
+			if s.Rule == code && strings.TrimSpace(s.Reason) != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplySuppressions(t *testing.T) {
+	quests := []*Quest{
+		{
+			QuestID: "Quest1",
+			Suppressions: []Suppression{
+				{Rule: "PAT023", Reason: "the quest ships in the next update"},
+				{Rule: "PAT099", Reason: "typo"},
+			},
+			QuestNodes: []QuestNode{
+				{NodeID: 1, Suppressions: []Suppression{
+					{Rule: "PAT013", Reason: "placeholder NPC"},
+					{Rule: "PAT001", Reason: "left over"},
+					// Only "checker fuzz" reports PAT042, so it is never unused here
+					{Rule: "PAT042", Reason: "races the delivery against losing the item"},
+				}},
+				{NodeID: 2, Suppressions: []Suppression{{Rule: "PAT013"}}},
+			},
+		},
+	}
+	errs := []ValidationError{
+		{Code: "PAT023", QuestID: "Quest1", NodeID: intPtr(3), Message: "quest reference"},
+		{Code: "PAT013", QuestID: "Quest1", NodeID: intPtr(1), Message: "unknown NPC on node 1"},
+		{Code: "PAT013", QuestID: "Quest1", NodeID: intPtr(2), Message: "unknown NPC on node 2"},
+		{Code: "PAT013", QuestID: "Quest2", NodeID: intPtr(1), Message: "other quest"},
+		{Code: "PAT021", Message: "duplicate DisplayName"},
+	}
+
+	kept, suppressed := ApplySuppressions(errs, quests)
+
+	if suppressed != 2 {
+		t.Errorf("expected 2 suppressed issues, got %d", suppressed)
+	}
+	var got []string
+	for _, err := range kept {
+		got = append(got, formatError(err))
+	}
+	want := []string{
+		"[Quest1] Node 2: error PAT013: unknown NPC on node 2",
+		"[Quest2] Node 1: error PAT013: other quest",
+		"[CROSS-QUEST]: warning PAT021: duplicate DisplayName",
+		"[Quest1]: warning PAT031: suppression of unknown rule PAT099",
+		"[Quest1] Node 1: info PAT032: suppression of PAT001 matches no issue",
+		"[Quest1] Node 2: error PAT030: suppression of PAT013 must give a Reason",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected issues:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	for _, s := range []Severity{SeverityError, SeverityWarning, SeverityInfo} {
+		if got, err := ParseSeverity(s.String()); err != nil || got != s {
+			t.Errorf("ParseSeverity(%q) = %v, %v", s.String(), got, err)
+		}
+	}
+	if _, err := ParseSeverity("fatal"); err == nil {
+		t.Error("expected an error for an unknown severity")
+	}
+}
+
+func TestRules_KnownCodes(t *testing.T) {
+	// Every issue the validators report must use a rule from the table.
+	quest := &Quest{
+		QuestID: "Quest1",
+		QuestNodes: []QuestNode{
+			{NodeID: 1, NodeType: "Actions", NextNodes: []int{1, 1, 9}, Actions: []interface{}{"CompleteQuest", "FailQuest"}},
+			{NodeID: 1, NodeType: "Dialog", ConversationPartner: "NPC:Nobody"},
+		},
+		Migrations: []Migration{{FromVersion: 5}},
+	}
+	errs := ValidateQuest(quest, &ReferenceData{})
+	errs = append(errs, ValidateCrossQuest([]*Quest{quest, quest})...)
+	if len(errs) == 0 {
+		t.Fatal("expected issues")
+	}
+	for _, err := range errs {
+		if _, ok := rules[err.Code]; !ok {
+			t.Errorf("issue %q has unknown rule code %q", err.Message, err.Code)
+		}
+	}
+}
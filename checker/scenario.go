@@ -0,0 +1,315 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TestFile holds the test scenarios of a single quest. Test files are
+// named <QuestID>.test.yaml and live in the tests directory next to the
+// quests.
+type TestFile struct {
+	QuestID   string     `yaml:"QuestID"`
+	Scenarios []Scenario `yaml:"Scenarios"`
+}
+
+// Scenario plays through a quest from a given world state and checks the
+// result.
+type Scenario struct {
+	Name   string         `yaml:"Name"`
+	World  WorldFacts     `yaml:"World"`
+	Steps  []ScenarioStep `yaml:"Steps"`
+	Expect Expectations   `yaml:"Expect"`
+}
+
+// WorldFacts sets facts about the world. Facts that are not mentioned
+// keep their value.
+type WorldFacts struct {
+	CompletedQuests []string        `yaml:"CompletedQuests"`
+	Resources       map[string]bool `yaml:"Resources"`
+	Factions        map[string]int  `yaml:"Factions"`
+	Inventory       map[string]int  `yaml:"Inventory"`
+	QuestItems      map[string]int  `yaml:"QuestItems"`
+	Variables       map[string]int  `yaml:"Variables"`
+}
+
+// ScenarioStep is one thing the player does or one thing that happens in
+// the world. Exactly one field should be set.
+type ScenarioStep struct {
+	Talk             *int        `yaml:"Talk"`
+	Choose           *ChoiceStep `yaml:"Choose"`
+	PassTime         string      `yaml:"PassTime"`
+	Event            string      `yaml:"Event"`
+	ItemLost         string      `yaml:"ItemLost"`
+	ItemUsedOnObject *ItemUse    `yaml:"ItemUsedOnObject"`
+	ItemUsedOnNPC    *ItemUse    `yaml:"ItemUsedOnNPC"`
+	World            *WorldFacts `yaml:"World"`
+}
+
+// ChoiceStep picks a 1-based option of a Decision node.
+type ChoiceStep struct {
+	Node   int `yaml:"Node"`
+	Option int `yaml:"Option"`
+}
+
+// ItemUse describes an item being used on a world object or an NPC.
+type ItemUse struct {
+	Item   string `yaml:"Item"`
+	Object string `yaml:"Object"`
+	NPC    string `yaml:"NPC"`
+}
+
+// Expectations are checked after all steps of a scenario ran. Fields
+// that are not set are not checked.
+type Expectations struct {
+	Status      string         `yaml:"Status"`
+	Accepted    *bool          `yaml:"Accepted"`
+	Active      []int          `yaml:"Active"`
+	Variables   map[string]int `yaml:"Variables"`
+	ItemsGained map[string]int `yaml:"ItemsGained"`
+	Inventory   map[string]int `yaml:"Inventory"`
+	Factions    map[string]int `yaml:"Factions"`
+	Currency    *int           `yaml:"Currency"`
+	Experience  *int           `yaml:"Experience"`
+	// Journal lists texts that must each be contained in a journal entry.
+	Journal []string `yaml:"Journal"`
+}
+
+// runTest implements the "test" subcommand, which runs all quest test
+// scenarios and reports pass/fail per scenario.
+func runTest(args []string) int {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	questsPath := fs.String("quests", "./quests", "Path to quests directory")
+	testsPath := fs.String("tests", "", "Path to test scenarios (default: <quests>/tests)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if _, err := applyConfig(fs, ""); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if *testsPath == "" {
+		*testsPath = filepath.Join(*questsPath, "tests")
+	}
+
+	quests, loadErrors := LoadQuests(*questsPath)
+	files, testErrors := LoadTestFiles(*testsPath)
+	loadErrors = append(loadErrors, testErrors...)
+	for _, err := range loadErrors {
+		fmt.Printf("[LOAD ERROR]: %v\n", err)
+	}
+
+	failed := runTestFiles(os.Stdout, files, quests)
+	if len(loadErrors) > 0 {
+		return 2
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// LoadTestFiles loads all *.test.yaml files from the given directory.
+func LoadTestFiles(testsPath string) ([]*TestFile, []error) {
+	var files []*TestFile
+	var errors []error
+
+	paths, _ := filepath.Glob(filepath.Join(testsPath, "*.test.yaml"))
+	more, _ := filepath.Glob(filepath.Join(testsPath, "*.test.yml"))
+	paths = append(paths, more...)
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errors = append(errors, fmt.Errorf("failed to load %s: %w", path, err))
+			continue
+		}
+		var file TestFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			errors = append(errors, fmt.Errorf("failed to load %s: %w", path, err))
+			continue
+		}
+		files = append(files, &file)
+	}
+
+	return files, errors
+}
+
+// runTestFiles runs every scenario and returns the number of failures.
+func runTestFiles(out io.Writer, files []*TestFile, quests []*Quest) int {
+	passed, failed := 0, 0
+	for _, file := range files {
+		fmt.Fprintln(out, file.QuestID)
+		quest := findQuest(quests, file.QuestID)
+		for _, scenario := range file.Scenarios {
+			var problems []string
+			if quest == nil {
+				problems = []string{fmt.Sprintf("quest %q not found", file.QuestID)}
+			} else {
+				problems = RunScenario(quest, scenario)
+			}
+
+			if len(problems) == 0 {
+				passed++
+				fmt.Fprintf(out, "  PASS  %s\n", scenario.Name)
+				continue
+			}
+			failed++
+			fmt.Fprintf(out, "  FAIL  %s\n", scenario.Name)
+			for _, problem := range problems {
+				fmt.Fprintf(out, "        %s\n", problem)
+			}
+		}
+	}
+
+	fmt.Fprintln(out, strings.Repeat("-", 40))
+	fmt.Fprintf(out, "Ran %d scenarios: %d passed, %d failed.\n", passed+failed, passed, failed)
+	return failed
+}
+
+// RunScenario plays a scenario and returns the failed expectations. A
+// step that cannot be performed ends the scenario.
+func RunScenario(quest *Quest, scenario Scenario) []string {
+	return runScenario(quest, scenario, nil)
+}
+
+// runScenario is RunScenario that also records coverage, unless coverage
+// is nil.
+func runScenario(quest *Quest, scenario Scenario, coverage *Coverage) []string {
+	world := NewWorld()
+	scenario.World.applyTo(world)
+	interp := NewInterpreter(quest, world)
+	if coverage != nil {
+		coverage.Attach(interp)
+	}
+
+	gained := make(map[string]int)
+	interp.OnAction = func(nodeID int, action interface{}) {
+		if a, ok := action.(map[string]interface{}); ok {
+			for _, stack := range itemStacks(a["ItemsGained"]) {
+				gained[stack.Type] += stack.Count
+			}
+		}
+	}
+
+	interp.Start()
+	for i, step := range scenario.Steps {
+		if err := runStep(interp, world, step); err != nil {
+			return []string{fmt.Sprintf("step %d: %v", i+1, err)}
+		}
+	}
+	return checkExpectations(scenario.Expect, interp, world, gained)
+}
+
+func runStep(interp *Interpreter, world *World, step ScenarioStep) error {
+	switch {
+	case step.Talk != nil:
+		return interp.Talk(*step.Talk)
+	case step.Choose != nil:
+		return interp.Choose(step.Choose.Node, step.Choose.Option)
+	case step.PassTime != "":
+		hours, ok := parseTimePassed(step.PassTime)
+		if !ok {
+			return fmt.Errorf("invalid PassTime %q", step.PassTime)
+		}
+		interp.PassTime(hours)
+	case step.Event != "":
+		interp.Happen(happeningKey("EventTriggered", step.Event))
+	case step.ItemLost != "":
+		interp.Happen(happeningKey("ItemLost", step.ItemLost))
+	case step.ItemUsedOnObject != nil:
+		interp.Happen(happeningKey("ItemUsedOnObject", step.ItemUsedOnObject.Item, step.ItemUsedOnObject.Object))
+	case step.ItemUsedOnNPC != nil:
+		interp.Happen(happeningKey("ItemUsedOnNPC", step.ItemUsedOnNPC.Item, step.ItemUsedOnNPC.NPC))
+	case step.World != nil:
+		step.World.applyTo(world)
+		interp.Settle()
+	default:
+		return fmt.Errorf("empty step")
+	}
+	return nil
+}
+
+func (f WorldFacts) applyTo(w *World) {
+	for _, questID := range f.CompletedQuests {
+		w.CompletedQuests[questID] = true
+	}
+	for k, v := range f.Resources {
+		w.Resources[k] = v
+	}
+	for k, v := range f.Factions {
+		w.Factions[k] = v
+	}
+	for k, v := range f.Inventory {
+		w.Inventory[k] = v
+	}
+	for k, v := range f.QuestItems {
+		w.QuestItems[k] = v
+	}
+	for k, v := range f.Variables {
+		w.Variables[k] = v
+	}
+}
+
+func checkExpectations(expect Expectations, interp *Interpreter, world *World, gained map[string]int) []string {
+	var problems []string
+	fail := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if expect.Status != "" && !strings.EqualFold(expect.Status, interp.Status()) {
+		fail("expected status %s, got %s", expect.Status, interp.Status())
+	}
+	if expect.Accepted != nil && *expect.Accepted != interp.Accepted() {
+		fail("expected accepted to be %v", *expect.Accepted)
+	}
+	if expect.Active != nil {
+		want := append([]int(nil), expect.Active...)
+		sort.Ints(want)
+		if got := interp.Active(); fmt.Sprint(got) != fmt.Sprint(want) {
+			fail("expected active nodes %v, got %v", want, got)
+		}
+	}
+	checkCounts(fail, "variable", expect.Variables, world.Variables)
+	checkCounts(fail, "items gained of", expect.ItemsGained, gained)
+	checkCounts(fail, "inventory count of", expect.Inventory, world.Inventory)
+	checkCounts(fail, "standing with", expect.Factions, world.Factions)
+	if expect.Currency != nil && *expect.Currency != world.Currency {
+		fail("expected currency %d, got %d", *expect.Currency, world.Currency)
+	}
+	if expect.Experience != nil && *expect.Experience != world.Experience {
+		fail("expected experience %d, got %d", *expect.Experience, world.Experience)
+	}
+	for _, text := range expect.Journal {
+		if !journalContains(interp.Journal(), text) {
+			fail("expected a journal entry containing %q", text)
+		}
+	}
+
+	return problems
+}
+
+func checkCounts(fail func(string, ...interface{}), what string, want, got map[string]int) {
+	for _, name := range sortedKeys(want) {
+		if got[name] != want[name] {
+			fail("expected %s %s to be %d, got %d", what, name, want[name], got[name])
+		}
+	}
+}
+
+func journalContains(journal []I18nString, text string) bool {
+	for _, entry := range journal {
+		if strings.Contains(entry.EnUS, text) || strings.Contains(entry.DeDE, text) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func scenarioQuest() *Quest {
+	return &Quest{
+		QuestID: "TestQuest",
+		QuestNodes: []QuestNode{
+			{NodeID: 0, NodeType: "EntryPoint", NextNodes: []int{1}},
+			{NodeID: 1, NodeType: "Decision", Options: []DialogOption{
+				{NextNodes: []int{2}},
+				{NextNodes: []int{4}},
+			}},
+			{NodeID: 2, NodeType: "Actions", NextNodes: []int{3}, Actions: []interface{}{
+				"AcceptQuest",
+				map[string]interface{}{"ItemsGained": []interface{}{map[string]interface{}{"Type": "Horseshoes", "Count": 2}}},
+				map[string]interface{}{"JournalEntry": map[string]interface{}{"en-US": "You took the horseshoes.", "de-DE": "Du hast die Hufeisen."}},
+			}},
+			{NodeID: 3, NodeType: "ConditionWatcher", NextNodes: []int{5}, Conditions: []map[string]interface{}{
+				{"ItemUsedOnNPC": map[string]interface{}{"Item": "Horseshoes", "NPC": "NPC:Smith"}},
+			}},
+			{NodeID: 4, NodeType: "Actions", Actions: []interface{}{"DeclineQuest"}},
+			{NodeID: 5, NodeType: "Actions", Actions: []interface{}{
+				map[string]interface{}{"SetVariable": map[string]interface{}{"VariableName": "Shod", "Operation": "set to", "Value": 1}},
+				"CompleteQuest",
+			}},
+		},
+	}
+}
+
+func TestRunScenario_Pass(t *testing.T) {
+	accepted := true
+	scenario := Scenario{
+		Name: "Shoe the horse",
+		Steps: []ScenarioStep{
+			{Choose: &ChoiceStep{Node: 1, Option: 1}},
+			{ItemUsedOnNPC: &ItemUse{Item: "Horseshoes", NPC: "NPC:Smith"}},
+		},
+		Expect: Expectations{
+			Status:      "Completed",
+			Accepted:    &accepted,
+			Active:      []int{},
+			Variables:   map[string]int{"Shod": 1},
+			ItemsGained: map[string]int{"Horseshoes": 2},
+			Journal:     []string{"horseshoes"},
+		},
+	}
+
+	if problems := RunScenario(scenarioQuest(), scenario); len(problems) != 0 {
+		t.Errorf("expected scenario to pass, got %v", problems)
+	}
+}
+
+func TestRunScenario_Failures(t *testing.T) {
+	scenario := Scenario{
+		Steps: []ScenarioStep{
+			{Choose: &ChoiceStep{Node: 1, Option: 1}},
+		},
+		Expect: Expectations{
+			Status:    "completed",
+			Active:    []int{5},
+			Variables: map[string]int{"Shod": 1},
+			Journal:   []string{"You shod the horse."},
+		},
+	}
+
+	problems := RunScenario(scenarioQuest(), scenario)
+
+	expected := []string{
+		"expected status completed, got active",
+		"expected active nodes [5], got [3]",
+		"expected variable Shod to be 1, got 0",
+		`expected a journal entry containing "You shod the horse."`,
+	}
+	if strings.Join(problems, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected problems:\n%s", strings.Join(problems, "\n"))
+	}
+}
+
+func TestRunScenario_StepNotPossible(t *testing.T) {
+	scenario := Scenario{
+		Steps: []ScenarioStep{
+			{Choose: &ChoiceStep{Node: 1, Option: 3}},
+			{Talk: intPtr(2)},
+		},
+	}
+
+	problems := RunScenario(scenarioQuest(), scenario)
+
+	if len(problems) != 1 || !strings.HasPrefix(problems[0], "step 1: ") {
+		t.Errorf("expected step 1 to fail, got %v", problems)
+	}
+}
+
+func TestRunTestFiles(t *testing.T) {
+	dir := t.TempDir()
+	testFile := `QuestID: TestQuest
+Scenarios:
+  - Name: Decline
+    Steps:
+      - Choose: {Node: 1, Option: 2}
+    Expect:
+      Status: declined
+  - Name: Wrong expectation
+    Expect:
+      Status: completed
+`
+	if err := os.WriteFile(filepath.Join(dir, "TestQuest.test.yaml"), []byte(testFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, errs := LoadTestFiles(dir)
+	if len(errs) != 0 {
+		t.Fatalf("LoadTestFiles failed: %v", errs)
+	}
+	var out bytes.Buffer
+	failed := runTestFiles(&out, files, []*Quest{scenarioQuest()})
+
+	if failed != 1 {
+		t.Errorf("expected 1 failed scenario, got %d", failed)
+	}
+	for _, want := range []string{"PASS  Decline", "FAIL  Wrong expectation", "Ran 2 scenarios: 1 passed, 1 failed."} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestLoadQuests_SkipsTestFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "tests"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"quest.yaml":                "QuestID: TestQuest\n",
+		"tests/TestQuest.test.yaml": "QuestID: TestQuest\nScenarios: []\n",
+		"tests/TestQuest.test.yml":  "QuestID: TestQuest\nScenarios: []\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	quests, errs := LoadQuests(dir)
+
+	if len(errs) != 0 || len(quests) != 1 {
+		t.Errorf("expected exactly one quest, got %d quests and errors %v", len(quests), errs)
+	}
+}
@@ -0,0 +1,449 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// JSONSchema is a JSON schema from the schemas directory. It supports the
+// subset of draft 2020-12 the project's schemas use: type, properties,
+// items, required, $ref within the same file, oneOf, const, enum,
+// pattern, minimum, minItems and uniqueItems.
+type JSONSchema struct {
+	root    map[string]interface{}
+	regexps map[string]*regexp.Regexp
+}
+
+// schemaError is a single schema violation at a JSON path.
+type schemaError struct {
+	Path    string
+	Keyword string
+	Message string
+}
+
+// referenceFiles maps each reference data file to its schema.
+var referenceFiles = []struct {
+	File   string
+	Schema string
+}{
+	{"npcs.yaml", "npc"},
+	{"items.yaml", "item"},
+	{"factions.yaml", "faction"},
+	{"resources.yaml", "resource"},
+	{"objects.yaml", "object"},
+}
+
+// LoadSchemas loads quest.json and the reference data schemas from the
+// given directory.
+func LoadSchemas(schemasPath string) (map[string]*JSONSchema, error) {
+	schemas := make(map[string]*JSONSchema)
+	names := []string{"quest"}
+	for _, ref := range referenceFiles {
+		names = append(names, ref.Schema)
+	}
+	for _, name := range names {
+		path := filepath.Join(schemasPath, name+".json")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load schema: %w", err)
+		}
+		schema, err := ParseSchema(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load schema %s: %w", path, err)
+		}
+		schemas[name] = schema
+	}
+	return schemas, nil
+}
+
+// ParseSchema parses a JSON schema and checks its patterns and references.
+func ParseSchema(data []byte) (*JSONSchema, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	schema := &JSONSchema{root: root, regexps: make(map[string]*regexp.Regexp)}
+
+	var prepare func(v interface{}) error
+	prepare = func(v interface{}) error {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if p, ok := v["pattern"].(string); ok {
+				re, err := regexp.Compile(p)
+				if err != nil {
+					return fmt.Errorf("invalid pattern %q: %w", p, err)
+				}
+				schema.regexps[p] = re
+			}
+			if ref, ok := v["$ref"].(string); ok && schema.lookup(ref) == nil {
+				return fmt.Errorf("cannot resolve $ref %q", ref)
+			}
+			for _, child := range v {
+				if err := prepare(child); err != nil {
+					return err
+				}
+			}
+		case []interface{}:
+			for _, child := range v {
+				if err := prepare(child); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := prepare(root); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// lookup resolves a "#/$defs/Name" reference, or returns nil.
+func (s *JSONSchema) lookup(ref string) interface{} {
+	if !strings.HasPrefix(ref, "#") {
+		return nil
+	}
+	var current interface{} = s.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+		if part == "" {
+			continue
+		}
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = m[strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")]
+		if current == nil {
+			return nil
+		}
+	}
+	return current
+}
+
+// Check validates a document decoded from YAML or JSON. path is the JSON
+// path of the document, usually "$".
+func (s *JSONSchema) Check(document interface{}, path string) []schemaError {
+	var errs []schemaError
+	s.check(s.root, plainValue(document), path, &errs)
+	return errs
+}
+
+// plainValue converts YAML-decoded numbers to float64, as JSON decoding
+// would, so values compare equal to the ones in the schema.
+func plainValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			out[k] = plainValue(child)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			out[i] = plainValue(child)
+		}
+		return out
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	}
+	return v
+}
+
+func (s *JSONSchema) check(node interface{}, value interface{}, path string, errs *[]schemaError) {
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		if node == false {
+			*errs = append(*errs, schemaError{path, "false", "is not allowed"})
+		}
+		return
+	}
+	report := func(keyword, format string, args ...interface{}) {
+		*errs = append(*errs, schemaError{path, keyword, fmt.Sprintf(format, args...)})
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		s.check(s.lookup(ref), value, path, errs)
+	}
+	if t, ok := schema["type"]; ok && !matchesType(value, t) {
+		report("type", "expected %s, got %s", typeNames(t), jsonType(value))
+		return
+	}
+	if c, ok := schema["const"]; ok && !reflect.DeepEqual(value, c) {
+		report("const", "must be %s", jsonText(c))
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		texts := make([]string, len(enum))
+		for i, e := range enum {
+			found = found || reflect.DeepEqual(value, e)
+			texts[i] = jsonText(e)
+		}
+		if !found {
+			report("enum", "must be one of %s", strings.Join(texts, ", "))
+		}
+	}
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		s.checkOneOf(oneOf, value, path, errs)
+	}
+
+	switch v := value.(type) {
+	case string:
+		if p, ok := schema["pattern"].(string); ok && !s.regexps[p].MatchString(v) {
+			report("pattern", "%s does not match pattern %s", jsonText(v), p)
+		}
+	case float64:
+		if min, ok := schema["minimum"].(float64); ok && v < min {
+			report("minimum", "must be at least %v", min)
+		}
+	case []interface{}:
+		if min, ok := schema["minItems"].(float64); ok && float64(len(v)) < min {
+			report("minItems", "must have at least %v items", min)
+		}
+		if unique, _ := schema["uniqueItems"].(bool); unique {
+		outer:
+			for i := range v {
+				for j := i + 1; j < len(v); j++ {
+					if reflect.DeepEqual(v[i], v[j]) {
+						report("uniqueItems", "items %d and %d are equal", i, j)
+						break outer
+					}
+				}
+			}
+		}
+		if items, ok := schema["items"]; ok {
+			for i, item := range v {
+				s.check(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, r := range required {
+				name, _ := r.(string)
+				if _, present := v[name]; !present {
+					report("required", "missing required property %s", jsonText(name))
+				}
+			}
+		}
+		if props, ok := schema["properties"].(map[string]interface{}); ok {
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if prop, ok := props[k]; ok {
+					s.check(prop, v[k], childPath(path, k), errs)
+				}
+			}
+		}
+	}
+}
+
+// checkOneOf requires exactly one alternative to match. When none does,
+// it reports the errors of the alternative the author most likely meant:
+// one whose type and constants (such as NodeType) match, with the fewest
+// errors on the value itself.
+func (s *JSONSchema) checkOneOf(oneOf []interface{}, value interface{}, path string, errs *[]schemaError) {
+	var candidates [][]schemaError
+	matched := 0
+	for _, alt := range oneOf {
+		var altErrs []schemaError
+		s.check(alt, value, path, &altErrs)
+		if len(altErrs) == 0 {
+			matched++
+			continue
+		}
+		plausible := true
+		for _, e := range altErrs {
+			if (e.Keyword == "type" && e.Path == path) || (e.Keyword == "const" && isDirectChild(path, e.Path)) {
+				plausible = false
+			}
+		}
+		if plausible {
+			candidates = append(candidates, altErrs)
+		}
+	}
+
+	switch {
+	case matched == 1:
+		return
+	case matched > 1:
+		*errs = append(*errs, schemaError{path, "oneOf", "matches more than one of the allowed forms"})
+		return
+	}
+
+	score := func(altErrs []schemaError) (int, int) {
+		own := 0
+		for _, e := range altErrs {
+			if e.Path == path {
+				own++
+			}
+		}
+		return own, len(altErrs)
+	}
+	best := -1
+	ambiguous := false
+	for i, c := range candidates {
+		if best < 0 {
+			best = i
+			continue
+		}
+		own, total := score(c)
+		bestOwn, bestTotal := score(candidates[best])
+		if own < bestOwn || (own == bestOwn && total < bestTotal) {
+			best, ambiguous = i, false
+		} else if own == bestOwn && total == bestTotal {
+			ambiguous = true
+		}
+	}
+	if best < 0 || ambiguous {
+		*errs = append(*errs, schemaError{path, "oneOf", "does not match any of the allowed forms"})
+		return
+	}
+	*errs = append(*errs, candidates[best]...)
+}
+
+// isDirectChild reports whether path is parent itself or one property or
+// index below it.
+func isDirectChild(parent, path string) bool {
+	if path == parent {
+		return true
+	}
+	rest := strings.TrimPrefix(path, parent)
+	if rest == path {
+		return false
+	}
+	if strings.HasPrefix(rest, ".") {
+		return !strings.ContainsAny(rest[1:], ".[")
+	}
+	return strings.HasPrefix(rest, "[") && strings.Count(rest, "]") == 1 && strings.HasSuffix(rest, "]")
+}
+
+var plainKey = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func childPath(path, key string) string {
+	if plainKey.MatchString(key) {
+		return path + "." + key
+	}
+	return path + "[" + strconv.Quote(key) + "]"
+}
+
+func matchesType(value interface{}, t interface{}) bool {
+	names, ok := t.([]interface{})
+	if !ok {
+		names = []interface{}{t}
+	}
+	for _, name := range names {
+		actual := jsonType(value)
+		if name == actual || (name == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v != math.Trunc(v) {
+			return "number"
+		}
+		return "integer"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func typeNames(t interface{}) string {
+	if names, ok := t.([]interface{}); ok {
+		parts := make([]string, len(names))
+		for i, n := range names {
+			parts[i] = fmt.Sprint(n)
+		}
+		return strings.Join(parts, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func jsonText(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// ValidateSchema checks a quest file against the quest schema. Problems
+// inside a quest node are reported on that node.
+func ValidateSchema(quest *Quest, schema *JSONSchema) []ValidationError {
+	var errors []ValidationError
+	for _, e := range schema.Check(quest.document, "$") {
+		verr := ValidationError{Code: "PAT017", QuestID: quest.QuestID, Field: e.Path, Message: fmt.Sprintf("%s: %s", e.Path, e.Message)}
+		var index int
+		if _, err := fmt.Sscanf(e.Path, "$.QuestNodes[%d]", &index); err == nil && index < len(quest.QuestNodes) {
+			verr.NodeID = intPtr(quest.QuestNodes[index].NodeID)
+		}
+		errors = append(errors, verr)
+	}
+	return errors
+}
+
+// ValidateReferenceSchemas checks every entry of the reference data files
+// against its schema.
+func ValidateReferenceSchemas(dataPath string, schemas map[string]*JSONSchema) []ValidationError {
+	var errors []ValidationError
+	for _, ref := range referenceFiles {
+		path := filepath.Join(dataPath, ref.File)
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		var root yaml.Node
+		var entries []interface{}
+		if err == nil {
+			err = yaml.Unmarshal(data, &root)
+		}
+		if err == nil && root.Kind != 0 {
+			err = root.Decode(&entries)
+		}
+		if err != nil {
+			errors = append(errors, ValidationError{Code: "PAT017", File: ref.File, Path: path, Message: err.Error()})
+			continue
+		}
+		positions := buildPositions(&root)
+		for i, entry := range entries {
+			for _, e := range schemas[ref.Schema].Check(entry, fmt.Sprintf("$[%d]", i)) {
+				verr := ValidationError{Code: "PAT017", File: ref.File, Path: path, Field: e.Path, Message: fmt.Sprintf("%s: %s", e.Path, e.Message)}
+				if pos, ok := positions.Lookup(e.Path); ok {
+					verr.Line, verr.Column = pos.Line, pos.Column
+				}
+				errors = append(errors, verr)
+			}
+		}
+	}
+	return errors
+}
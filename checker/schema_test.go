@@ -0,0 +1,182 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func loadQuestSchema(t *testing.T) *JSONSchema {
+	t.Helper()
+	schemas, err := LoadSchemas("../schemas")
+	if err != nil {
+		t.Fatalf("LoadSchemas failed: %v", err)
+	}
+	return schemas["quest"]
+}
+
+func parseQuest(t *testing.T, source string) *Quest {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "quest.yaml")
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	quest, err := loadQuestFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return quest
+}
+
+func TestValidateSchema(t *testing.T) {
+	quest := parseQuest(t, `
+QuestTypeVersion: 1
+QuestVersion: 0
+QuestID: TestQuest
+QuestType: SecretQuest
+DisplayName: { en-US: Test, de-DE: Test }
+QuestNodes:
+  - NodeID: 0
+    NodeType: EntryPoint
+    NextNodes: [10, 10]
+  - NodeID: 10
+    NodeType: ConditionBranch
+    Conditions:
+      - EventTriggered: { Event: Storm, Count: 1 }
+    NextNodesIfTrue: [20]
+  - NodeID: 20
+    NodeType: ConditionWatcher
+    Conditions:
+      - Variable: { VariableName: Done, Comparison: larger, Value: 1 }
+    NextNodes: [30]
+  - NodeID: 30
+    NodeType: Dialog
+    ConversationPartner: NPC:Smith
+    Messages: []
+    NextNodes: [40]
+  - NodeID: 40
+    NodeType: Actions
+    Actions: [CompleteQuest]
+`)
+
+	var got []string
+	for _, err := range ValidateSchema(quest, loadQuestSchema(t)) {
+		got = append(got, formatError(err))
+	}
+
+	want := []string{
+		`[TestQuest] Node 0: error PAT017: $.QuestNodes[0].NextNodes: items 0 and 1 are equal`,
+		`[TestQuest] Node 10: error PAT017: $.QuestNodes[1].Conditions[0]: does not match any of the allowed forms`,
+		`[TestQuest] Node 20: error PAT017: $.QuestNodes[2].Conditions[0].Variable.Comparison: must be one of "equal", "not equal", "greater than", "smaller than"`,
+		`[TestQuest] Node 30: error PAT017: $.QuestNodes[3].Messages: must have at least 1 items`,
+		`[TestQuest]: error PAT017: $.QuestType: must be one of "SideQuest", "MainQuest", "CompanionQuest", "FactionQuest", "DistrictQuest", "MasteryQuest"`,
+		`[TestQuest]: error PAT017: $.QuestVersion: must be at least 1`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected errors:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestValidateSchema_RepositoryQuests(t *testing.T) {
+	schema := loadQuestSchema(t)
+	quests, errs := LoadQuests("../quests")
+	if len(errs) != 0 {
+		t.Fatalf("LoadQuests failed: %v", errs)
+	}
+	for _, quest := range quests {
+		if errors := ValidateSchema(quest, schema); len(errors) != 0 {
+			t.Errorf("expected %s to match the schema, got %v", quest.QuestID, errors)
+		}
+	}
+}
+
+func TestJSONSchema_Keywords(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{
+		"type": "object",
+		"properties": {
+			"Count": { "type": "integer", "minimum": 1 },
+			"Name": { "$ref": "#/$defs/Name" },
+			"Kind": { "const": "Tool" }
+		},
+		"required": [ "Kind" ],
+		"$defs": {
+			"Name": {
+				"type": "object",
+				"properties": { "en-US": { "type": "string", "pattern": "^[A-Z]" } },
+				"required": [ "en-US" ]
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("ParseSchema failed: %v", err)
+	}
+
+	tests := []struct {
+		document string
+		want     []schemaError
+	}{
+		{`{Kind: Tool, Count: 2, Name: {en-US: Hammer}}`, nil},
+		{`{Count: 2}`, []schemaError{{"$", "required", `missing required property "Kind"`}}},
+		{`{Kind: Tool, Count: 1.5}`, []schemaError{{"$.Count", "type", "expected integer, got number"}}},
+		{`{Kind: Tool, Count: 0}`, []schemaError{{"$.Count", "minimum", "must be at least 1"}}},
+		{`{Kind: Toy}`, []schemaError{{"$.Kind", "const", `must be "Tool"`}}},
+		{`{Kind: Tool, Name: {en-US: hammer}}`, []schemaError{{`$.Name["en-US"]`, "pattern", `"hammer" does not match pattern ^[A-Z]`}}},
+		{`[1]`, []schemaError{{"$", "type", "expected object, got array"}}},
+	}
+	for _, tt := range tests {
+		var doc interface{}
+		if err := yaml.Unmarshal([]byte(tt.document), &doc); err != nil {
+			t.Fatal(err)
+		}
+		if got := schema.Check(doc, "$"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.document, got, tt.want)
+		}
+	}
+}
+
+func TestParseSchema_Errors(t *testing.T) {
+	for _, source := range []string{`[`, `{"pattern": "["}`, `{"$ref": "#/$defs/Nothing"}`} {
+		if _, err := ParseSchema([]byte(source)); err == nil {
+			t.Errorf("expected ParseSchema(%s) to fail", source)
+		}
+	}
+}
+
+func TestValidateReferenceSchemas(t *testing.T) {
+	schemas, err := LoadSchemas("../schemas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	items := `
+- ItemID: PackOfNails
+  DisplayName: { en-US: Pack of Nails, de-DE: Packung Nägel }
+  Category: Material
+- ItemID: rope
+  DisplayName: { en-US: Rope }
+  Category: Material
+  MaxStack: 0
+`
+	if err := os.WriteFile(filepath.Join(dir, "items.yaml"), []byte(items), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, err := range ValidateReferenceSchemas(dir, schemas) {
+		got = append(got, strings.TrimPrefix(formatError(err), dir+string(filepath.Separator)))
+	}
+
+	want := []string{
+		`items.yaml:6:3: [items.yaml]: error PAT017: $[1].DisplayName: missing required property "de-DE"`,
+		`items.yaml:5:3: [items.yaml]: error PAT017: $[1].ItemID: "rope" does not match pattern ^[A-Z][A-Za-z0-9\.\-_:]*$`,
+		`items.yaml:8:3: [items.yaml]: error PAT017: $[1].MaxStack: must be at least 1`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected errors:\n got %q\nwant %q", got, want)
+	}
+}
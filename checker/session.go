@@ -0,0 +1,340 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// session holds the results of validating all quests and updates them when
+// files change: single-quest checks re-run for changed quest files only,
+// cross-quest checks only when something they read changed, and everything
+// when the reference data changes.
+type session struct {
+	opts       checkOptions
+	refData    *ReferenceData
+	schemas    map[string]*JSONSchema
+	dataIssues []ValidationError // reference data schema violations
+	previous   []*Quest
+	prevErrors []error
+	walkErr    error
+	order      []string // quest file paths, in load order
+	files      map[string]*questFile
+	cross      []ValidationError
+	baseline   *Baseline
+}
+
+// questFile is a quest file and the results of its single-quest checks.
+type questFile struct {
+	quest    *Quest
+	loadErr  error
+	issues   []ValidationError // checks of the quest on its own
+	versions []ValidationError // checks against the previous release
+	crossKey string
+}
+
+func newSession(opts checkOptions) *session {
+	return &session{opts: opts, files: make(map[string]*questFile)}
+}
+
+// load loads and validates everything. It only fails if the reference data,
+// the schemas or the baseline cannot be loaded.
+func (s *session) load() error {
+	if err := s.loadData(); err != nil {
+		return err
+	}
+	if s.opts.baselinePath != "" {
+		baseline, err := LoadBaseline(s.opts.baselinePath)
+		if err != nil {
+			return err
+		}
+		s.baseline = baseline
+	}
+
+	// Load the previously released quests, if given
+	if s.opts.previousPath != "" {
+		s.previous, s.prevErrors = LoadQuests(s.opts.previousPath)
+	}
+
+	paths, err := listQuestFiles(s.opts.questsPath)
+	s.walkErr = err
+	for _, path := range paths {
+		s.loadQuest(path)
+	}
+	s.cross = ValidateCrossQuest(s.quests(), s.opts.config.languages()...)
+	return nil
+}
+
+// loadData loads the reference data and the schemas, and validates the
+// reference data against the schemas.
+func (s *session) loadData() error {
+	refData, err := LoadReferenceData(s.opts.dataPath)
+	if err != nil {
+		return err
+	}
+
+	// Load schemas; without -schemas, a missing schemas directory is skipped
+	var schemas map[string]*JSONSchema
+	schemasPath := s.opts.schemasPath
+	if schemasPath == "" {
+		schemasPath = filepath.Join(filepath.Dir(filepath.Clean(s.opts.dataPath)), "schemas")
+		if _, err := os.Stat(schemasPath); err != nil {
+			schemasPath = ""
+		}
+	}
+	if schemasPath != "" {
+		schemas, err = LoadSchemas(schemasPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.refData, s.schemas, s.dataIssues = refData, schemas, nil
+	if schemas != nil {
+		s.dataIssues = ValidateReferenceSchemas(s.opts.dataPath, schemas)
+	}
+	return nil
+}
+
+// loadQuest (re)loads a quest file and runs its single-quest checks.
+func (s *session) loadQuest(path string) *questFile {
+	if _, ok := s.files[path]; !ok {
+		s.order = append(s.order, path)
+	}
+	f := &questFile{}
+	s.files[path] = f
+
+	quest, err := loadQuestFile(path)
+	if err != nil {
+		f.loadErr = &LoadError{Path: path, Err: err}
+		return f
+	}
+	f.quest = quest
+	s.checkQuest(f)
+	return f
+}
+
+// checkQuest runs the single-quest checks of a loaded quest file.
+func (s *session) checkQuest(f *questFile) {
+	quest := f.quest
+	var issues []ValidationError
+	if s.schemas != nil {
+		issues = append(issues, ValidateSchema(quest, s.schemas["quest"])...)
+	}
+	issues = append(issues, ValidateQuest(quest, s.refData)...)
+	for i := range issues {
+		issues[i].Path = quest.Path
+	}
+	f.issues = issues
+	f.versions = ValidateQuestVersions([]*Quest{quest}, s.previous)
+	f.crossKey = crossKey(quest)
+}
+
+// quests returns the loaded quests in load order.
+func (s *session) quests() []*Quest {
+	var quests []*Quest
+	for _, path := range s.order {
+		if f := s.files[path]; f.quest != nil {
+			quests = append(quests, f.quest)
+		}
+	}
+	return quests
+}
+
+// crossKey summarizes what the cross-quest checks read from a quest: its
+// QuestID, DisplayName, stage descriptions and QuestCompleted references.
+func crossKey(quest *Quest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%q %q %q\n", quest.QuestID, quest.DisplayName.EnUS, quest.DisplayName.DeDE)
+	for n, node := range quest.QuestNodes {
+		for _, action := range node.Actions {
+			if actionMap, ok := action.(map[string]interface{}); ok {
+				if qsd, ok := actionMap["QuestStageDescription"].(map[string]interface{}); ok {
+					fmt.Fprintf(&b, "stage %q %q\n", qsd["en-US"], qsd["de-DE"])
+				}
+			}
+		}
+		for c, cond := range node.Conditions {
+			if qc, ok := cond["QuestCompleted"]; ok {
+				fmt.Fprintf(&b, "node %d %d condition %d %q\n", n, node.NodeID, c, qc)
+			}
+		}
+		for o, opt := range node.Options {
+			for c, cond := range opt.Conditions {
+				if qc, ok := cond["QuestCompleted"]; ok {
+					fmt.Fprintf(&b, "node %d %d option %d condition %d %q\n", n, node.NodeID, o, c, qc)
+				}
+			}
+		}
+	}
+	return b.String()
+}
+
+// recheck describes which checks an update re-ran.
+type recheck struct {
+	all    bool     // the reference data changed
+	quests []string // quest files that were re-checked
+	cross  bool     // the cross-quest checks re-ran
+}
+
+func (r recheck) String() string {
+	if r.all {
+		return "all quests"
+	}
+	var parts []string
+	switch len(r.quests) {
+	case 0:
+	case 1:
+		parts = append(parts, r.quests[0])
+	default:
+		parts = append(parts, fmt.Sprintf("%d quests", len(r.quests)))
+	}
+	if r.cross {
+		parts = append(parts, "cross-quest checks")
+	}
+	if len(parts) == 0 {
+		return "nothing"
+	}
+	return strings.Join(parts, " and ")
+}
+
+// update re-runs the checks affected by changes to the given files. A
+// change to the quests directory itself reloads all quest files, and quest
+// files that appeared or disappeared are picked up even if they are not
+// among the changed files.
+func (s *session) update(changed []string) (recheck, error) {
+	var rc recheck
+	reloadAll := false
+	for i, path := range changed {
+		changed[i] = filepath.Clean(path)
+		if s.isDataFile(changed[i]) {
+			rc.all = true
+		}
+		if changed[i] == filepath.Clean(s.opts.questsPath) {
+			reloadAll = true
+		}
+	}
+	if rc.all {
+		if err := s.loadData(); err != nil {
+			return rc, err
+		}
+	}
+
+	paths, err := listQuestFiles(s.opts.questsPath)
+	s.walkErr = err
+	present := make(map[string]bool)
+	for _, path := range paths {
+		present[path] = true
+	}
+
+	// Forget removed quest files
+	order := s.order[:0]
+	for _, path := range s.order {
+		if present[path] {
+			order = append(order, path)
+			continue
+		}
+		if s.files[path].quest != nil {
+			rc.cross = true
+		}
+		delete(s.files, path)
+	}
+	s.order = order
+
+	// Load new and changed quest files
+	reload := make(map[string]bool)
+	for _, path := range changed {
+		reload[path] = present[path]
+	}
+	for _, path := range paths {
+		if _, ok := s.files[path]; !ok || reloadAll {
+			reload[path] = true
+		}
+	}
+	for _, path := range paths {
+		if !reload[path] {
+			continue
+		}
+		old := s.files[path]
+		f := s.loadQuest(path)
+		rc.quests = append(rc.quests, path)
+		if (old == nil || old.quest == nil) != (f.quest == nil) || (old != nil && old.crossKey != f.crossKey) {
+			rc.cross = true
+		}
+	}
+
+	// Re-check all other quests against the new reference data
+	if rc.all {
+		for _, path := range s.order {
+			if f := s.files[path]; !reload[path] && f.quest != nil {
+				s.checkQuest(f)
+			}
+		}
+	}
+
+	if rc.all || rc.cross {
+		s.cross = ValidateCrossQuest(s.quests(), s.opts.config.languages()...)
+		rc.cross = true
+	}
+	return rc, nil
+}
+
+// isDataFile reports whether path is a reference data file.
+func (s *session) isDataFile(path string) bool {
+	dataPath := filepath.Clean(s.opts.dataPath)
+	if path == dataPath {
+		return true
+	}
+	ext := filepath.Ext(path)
+	return filepath.Dir(path) == dataPath && (ext == ".yaml" || ext == ".yml")
+}
+
+// report applies severities, suppressions, the configured rule settings and
+// the baseline to the current results, and locates the issues in their quest files.
+func (s *session) report() *Report {
+	quests := s.quests()
+
+	var loadErrors []error
+	var issues []ValidationError
+	var versions []ValidationError
+	issues = append(issues, s.dataIssues...)
+	for _, path := range s.order {
+		f := s.files[path]
+		if f.loadErr != nil {
+			loadErrors = append(loadErrors, f.loadErr)
+			continue
+		}
+		issues = append(issues, f.issues...)
+		versions = append(versions, f.versions...)
+	}
+	if s.walkErr != nil {
+		loadErrors = append(loadErrors, s.walkErr)
+	}
+	loadErrors = append(loadErrors, s.prevErrors...)
+	issues = append(issues, s.cross...)
+	issues = append(issues, versions...)
+
+	issues, suppressed := ApplySuppressions(issues, quests)
+	issues = s.opts.config.applyRules(issues)
+	var baselined int
+	var fixed []BaselineEntry
+	if s.baseline != nil {
+		issues, baselined, fixed = s.baseline.Apply(issues)
+	}
+	for i := range issues {
+		if f, ok := s.files[issues[i].Path]; ok && f.quest != nil {
+			f.quest.locate(&issues[i])
+		}
+	}
+
+	return &Report{
+		Quests:     quests,
+		LoadErrors: loadErrors,
+		Issues:     issues,
+		Suppressed: suppressed,
+		Baselined:  baselined,
+		Fixed:      fixed,
+		FailOn:     s.opts.failOn,
+	}
+}
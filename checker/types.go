@@ -6,15 +6,54 @@ type I18nString struct {
 	DeDE string `yaml:"de-DE"`
 }
 
+// In returns the text in the given language, or "" if there is none.
+func (s I18nString) In(lang string) string {
+	switch lang {
+	case "en-US":
+		return s.EnUS
+	case "de-DE":
+		return s.DeDE
+	}
+	return ""
+}
+
 // Quest represents a complete quest definition.
 type Quest struct {
-	QuestTypeVersion int         `yaml:"QuestTypeVersion"`
-	QuestVersion     int         `yaml:"QuestVersion"`
-	QuestID          string      `yaml:"QuestID"`
-	QuestType        string      `yaml:"QuestType"`
-	DisplayName      I18nString  `yaml:"DisplayName"`
-	Repeatable       string      `yaml:"Repeatable"`
-	QuestNodes       []QuestNode `yaml:"QuestNodes"`
+	QuestTypeVersion int           `yaml:"QuestTypeVersion"`
+	QuestVersion     int           `yaml:"QuestVersion"`
+	QuestID          string        `yaml:"QuestID"`
+	QuestType        string        `yaml:"QuestType"`
+	DisplayName      I18nString    `yaml:"DisplayName"`
+	Repeatable       string        `yaml:"Repeatable"`
+	QuestNodes       []QuestNode   `yaml:"QuestNodes"`
+	Migrations       []Migration   `yaml:"Migrations,omitempty"`
+	Suppressions     []Suppression `yaml:"Suppressions,omitempty"`
+
+	// Path is the file the quest was loaded from.
+	Path string `yaml:"-"`
+	// document is the quest file as decoded YAML, for schema validation.
+	document interface{}
+	// positions locates the values of the quest file.
+	positions Positions
+}
+
+// Migration moves savegames from FromVersion to the next QuestVersion.
+type Migration struct {
+	FromVersion int                 `yaml:"FromVersion"`
+	Nodes       []NodeMigration     `yaml:"Nodes,omitempty"`
+	Variables   []VariableMigration `yaml:"Variables,omitempty"`
+}
+
+// NodeMigration maps an old NodeID to the nodes replacing it.
+type NodeMigration struct {
+	From int   `yaml:"From"`
+	To   []int `yaml:"To"`
+}
+
+// VariableMigration renames or drops a quest variable.
+type VariableMigration struct {
+	From string `yaml:"From"`
+	To   string `yaml:"To,omitempty"`
 }
 
 // QuestNode represents a node in the quest state machine.
@@ -32,6 +71,13 @@ type QuestNode struct {
 	Options             []DialogOption           `yaml:"Options,omitempty"`
 	Messages            []DialogMessage          `yaml:"Messages,omitempty"`
 	Actions             []interface{}            `yaml:"Actions,omitempty"`
+	Suppressions        []Suppression            `yaml:"Suppressions,omitempty"`
+}
+
+// Suppression turns off a validation rule for a quest or a single node.
+type Suppression struct {
+	Rule   string `yaml:"Rule"`
+	Reason string `yaml:"Reason"`
 }
 
 // DialogOption represents a player dialog choice.
@@ -49,7 +95,8 @@ type DialogMessage struct {
 
 // NPC represents a non-player character.
 type NPC struct {
-	NPCID string `yaml:"NPCID"`
+	NPCID       string     `yaml:"NPCID"`
+	DisplayName I18nString `yaml:"DisplayName"`
 }
 
 // Item represents an item type.
@@ -75,6 +122,7 @@ type Object struct {
 // ReferenceData holds all reference data for validation.
 type ReferenceData struct {
 	NPCs      map[string]bool
+	NPCNames  map[string]I18nString
 	Items     map[string]bool
 	Factions  map[string]bool
 	Resources map[string]bool
@@ -83,7 +131,19 @@ type ReferenceData struct {
 
 // ValidationError represents a single validation issue.
 type ValidationError struct {
-	QuestID string
-	NodeID  *int
+	// Code identifies the rule, see rules.go.
+	Code     string
+	Severity Severity
+	QuestID  string
+	NodeID   *int
+	// File is set instead of QuestID for problems in reference data files.
+	File string
+	// Path is the file the issue was found in, if any.
+	Path string
+	// Field is the JSON path of the value the issue is about, if known.
+	Field string
+	// Line and Column locate the issue in Path; zero if unknown.
+	Line    int
+	Column  int
 	Message string
 }
@@ -13,6 +13,7 @@ func ValidateQuest(quest *Quest, refData *ReferenceData) []ValidationError {
 	errors = append(errors, validateOutgoingEdges(quest)...)
 	errors = append(errors, validateNoCycles(quest)...)
 	errors = append(errors, validateReferences(quest, refData)...)
+	errors = append(errors, validateMigrations(quest)...)
 
 	return errors
 }
@@ -24,6 +25,7 @@ func validateUniqueNodeIDs(quest *Quest) []ValidationError {
 	for _, node := range quest.QuestNodes {
 		if seen[node.NodeID] {
 			errors = append(errors, ValidationError{
+				Code:    "PAT004",
 				QuestID: quest.QuestID,
 				NodeID:  intPtr(node.NodeID),
 				Message: "duplicate NodeID",
@@ -49,6 +51,7 @@ func validateNodeConnections(quest *Quest) []ValidationError {
 		for _, nextID := range node.NextNodes {
 			if nextID == node.NodeID {
 				errors = append(errors, ValidationError{
+					Code:    "PAT003",
 					QuestID: quest.QuestID,
 					NodeID:  intPtr(node.NodeID),
 					Message: "node references itself in NextNodes",
@@ -56,6 +59,7 @@ func validateNodeConnections(quest *Quest) []ValidationError {
 			}
 			if seen[nextID] {
 				errors = append(errors, ValidationError{
+					Code:    "PAT001",
 					QuestID: quest.QuestID,
 					NodeID:  intPtr(node.NodeID),
 					Message: fmt.Sprintf("duplicate edge to node %d", nextID),
@@ -64,6 +68,7 @@ func validateNodeConnections(quest *Quest) []ValidationError {
 			seen[nextID] = true
 			if !nodeIDs[nextID] {
 				errors = append(errors, ValidationError{
+					Code:    "PAT002",
 					QuestID: quest.QuestID,
 					NodeID:  intPtr(node.NodeID),
 					Message: fmt.Sprintf("NextNodes references non-existent node %d", nextID),
@@ -75,6 +80,7 @@ func validateNodeConnections(quest *Quest) []ValidationError {
 		for _, nextID := range node.NextNodesIfTrue {
 			if nextID == node.NodeID {
 				errors = append(errors, ValidationError{
+					Code:    "PAT003",
 					QuestID: quest.QuestID,
 					NodeID:  intPtr(node.NodeID),
 					Message: "node references itself in NextNodesIfTrue",
@@ -82,6 +88,7 @@ func validateNodeConnections(quest *Quest) []ValidationError {
 			}
 			if !nodeIDs[nextID] {
 				errors = append(errors, ValidationError{
+					Code:    "PAT002",
 					QuestID: quest.QuestID,
 					NodeID:  intPtr(node.NodeID),
 					Message: fmt.Sprintf("NextNodesIfTrue references non-existent node %d", nextID),
@@ -93,6 +100,7 @@ func validateNodeConnections(quest *Quest) []ValidationError {
 		for _, nextID := range node.NextNodesIfFalse {
 			if nextID == node.NodeID {
 				errors = append(errors, ValidationError{
+					Code:    "PAT003",
 					QuestID: quest.QuestID,
 					NodeID:  intPtr(node.NodeID),
 					Message: "node references itself in NextNodesIfFalse",
@@ -100,6 +108,7 @@ func validateNodeConnections(quest *Quest) []ValidationError {
 			}
 			if !nodeIDs[nextID] {
 				errors = append(errors, ValidationError{
+					Code:    "PAT002",
 					QuestID: quest.QuestID,
 					NodeID:  intPtr(node.NodeID),
 					Message: fmt.Sprintf("NextNodesIfFalse references non-existent node %d", nextID),
@@ -112,6 +121,7 @@ func validateNodeConnections(quest *Quest) []ValidationError {
 			for _, nextID := range opt.NextNodes {
 				if nextID == node.NodeID {
 					errors = append(errors, ValidationError{
+						Code:    "PAT003",
 						QuestID: quest.QuestID,
 						NodeID:  intPtr(node.NodeID),
 						Message: "dialog option references itself",
@@ -119,6 +129,7 @@ func validateNodeConnections(quest *Quest) []ValidationError {
 				}
 				if !nodeIDs[nextID] {
 					errors = append(errors, ValidationError{
+						Code:    "PAT002",
 						QuestID: quest.QuestID,
 						NodeID:  intPtr(node.NodeID),
 						Message: fmt.Sprintf("dialog option references non-existent node %d", nextID),
@@ -150,6 +161,7 @@ func validateNodeConnections(quest *Quest) []ValidationError {
 	for _, node := range quest.QuestNodes {
 		if node.NodeType != "EntryPoint" && !hasIncoming[node.NodeID] {
 			errors = append(errors, ValidationError{
+				Code:    "PAT005",
 				QuestID: quest.QuestID,
 				NodeID:  intPtr(node.NodeID),
 				Message: "non-EntryPoint node has no incoming connections",
@@ -173,6 +185,7 @@ func validateEntryPoints(quest *Quest) []ValidationError {
 
 	if !hasEntryPoint {
 		errors = append(errors, ValidationError{
+			Code:    "PAT006",
 			QuestID: quest.QuestID,
 			Message: "quest must have at least one EntryPoint node",
 		})
@@ -206,6 +219,7 @@ func validateTerminalNodes(quest *Quest) []ValidationError {
 
 		if terminalCount > 0 && len(node.NextNodes) > 0 {
 			errors = append(errors, ValidationError{
+				Code:    "PAT008",
 				QuestID: quest.QuestID,
 				NodeID:  intPtr(node.NodeID),
 				Message: "terminal action node must not have NextNodes",
@@ -214,6 +228,7 @@ func validateTerminalNodes(quest *Quest) []ValidationError {
 
 		if terminalCount > 1 {
 			errors = append(errors, ValidationError{
+				Code:    "PAT009",
 				QuestID: quest.QuestID,
 				NodeID:  intPtr(node.NodeID),
 				Message: "Actions node has more than one terminal action",
@@ -255,6 +270,7 @@ func validateOutgoingEdges(quest *Quest) []ValidationError {
 		// Non-terminal nodes must have outgoing edges (except Decision which uses Options)
 		if !isTerminal && outgoingCount == 0 && node.NodeType != "Decision" {
 			errors = append(errors, ValidationError{
+				Code:    "PAT010",
 				QuestID: quest.QuestID,
 				NodeID:  intPtr(node.NodeID),
 				Message: "non-terminal node has no outgoing edges",
@@ -320,6 +336,7 @@ func validateNoCycles(quest *Quest) []ValidationError {
 
 	if hasCycle {
 		errors = append(errors, ValidationError{
+			Code:    "PAT007",
 			QuestID: quest.QuestID,
 			Message: "quest contains a cycle",
 		})
@@ -335,6 +352,7 @@ func validateReferences(quest *Quest, refData *ReferenceData) []ValidationError
 		// Check conversation partners and speakers
 		if node.ConversationPartner != "" && !refData.NPCs[node.ConversationPartner] {
 			errors = append(errors, ValidationError{
+				Code:    "PAT013",
 				QuestID: quest.QuestID,
 				NodeID:  intPtr(node.NodeID),
 				Message: fmt.Sprintf("unknown NPC: %s", node.ConversationPartner),
@@ -342,6 +360,7 @@ func validateReferences(quest *Quest, refData *ReferenceData) []ValidationError
 		}
 		if node.Speaker != "" && !refData.NPCs[node.Speaker] {
 			errors = append(errors, ValidationError{
+				Code:    "PAT013",
 				QuestID: quest.QuestID,
 				NodeID:  intPtr(node.NodeID),
 				Message: fmt.Sprintf("unknown speaker: %s", node.Speaker),
@@ -352,6 +371,7 @@ func validateReferences(quest *Quest, refData *ReferenceData) []ValidationError
 		for _, msg := range node.Messages {
 			if msg.Speaker != "Player" && !refData.NPCs[msg.Speaker] {
 				errors = append(errors, ValidationError{
+					Code:    "PAT013",
 					QuestID: quest.QuestID,
 					NodeID:  intPtr(node.NodeID),
 					Message: fmt.Sprintf("unknown speaker in message: %s", msg.Speaker),
@@ -383,6 +403,7 @@ func validateConditionReferences(questID string, nodeID int, conditions []map[st
 			if resource, ok := ra["Resource"].(string); ok && resource != "" {
 				if !refData.Resources[resource] {
 					errors = append(errors, ValidationError{
+						Code:    "PAT013",
 						QuestID: questID,
 						NodeID:  intPtr(nodeID),
 						Message: fmt.Sprintf("unknown resource: %s", resource),
@@ -396,6 +417,7 @@ func validateConditionReferences(questID string, nodeID int, conditions []map[st
 			if item, ok := iuo["Item"].(string); ok && item != "" {
 				if !refData.Items[item] {
 					errors = append(errors, ValidationError{
+						Code:    "PAT013",
 						QuestID: questID,
 						NodeID:  intPtr(nodeID),
 						Message: fmt.Sprintf("unknown item: %s", item),
@@ -405,6 +427,7 @@ func validateConditionReferences(questID string, nodeID int, conditions []map[st
 			if obj, ok := iuo["Object"].(string); ok && obj != "" {
 				if !refData.Objects[obj] {
 					errors = append(errors, ValidationError{
+						Code:    "PAT013",
 						QuestID: questID,
 						NodeID:  intPtr(nodeID),
 						Message: fmt.Sprintf("unknown object: %s", obj),
@@ -418,6 +441,7 @@ func validateConditionReferences(questID string, nodeID int, conditions []map[st
 			if item, ok := iun["Item"].(string); ok && item != "" {
 				if !refData.Items[item] {
 					errors = append(errors, ValidationError{
+						Code:    "PAT013",
 						QuestID: questID,
 						NodeID:  intPtr(nodeID),
 						Message: fmt.Sprintf("unknown item: %s", item),
@@ -427,6 +451,7 @@ func validateConditionReferences(questID string, nodeID int, conditions []map[st
 			if npc, ok := iun["NPC"].(string); ok && npc != "" {
 				if !refData.NPCs[npc] {
 					errors = append(errors, ValidationError{
+						Code:    "PAT013",
 						QuestID: questID,
 						NodeID:  intPtr(nodeID),
 						Message: fmt.Sprintf("unknown NPC: %s", npc),
@@ -440,6 +465,7 @@ func validateConditionReferences(questID string, nodeID int, conditions []map[st
 			if faction, ok := fs["Faction"].(string); ok && faction != "" {
 				if !refData.Factions[faction] {
 					errors = append(errors, ValidationError{
+						Code:    "PAT013",
 						QuestID: questID,
 						NodeID:  intPtr(nodeID),
 						Message: fmt.Sprintf("unknown faction: %s", faction),
@@ -455,6 +481,7 @@ func validateConditionReferences(questID string, nodeID int, conditions []map[st
 					if itemType, ok := itemMap["Type"].(string); ok && itemType != "" {
 						if !refData.Items[itemType] {
 							errors = append(errors, ValidationError{
+								Code:    "PAT013",
 								QuestID: questID,
 								NodeID:  intPtr(nodeID),
 								Message: fmt.Sprintf("unknown item type: %s", itemType),
@@ -469,6 +496,7 @@ func validateConditionReferences(questID string, nodeID int, conditions []map[st
 		if itemLost, ok := cond["ItemLost"].(string); ok && itemLost != "" {
 			if !refData.Items[itemLost] {
 				errors = append(errors, ValidationError{
+					Code:    "PAT013",
 					QuestID: questID,
 					NodeID:  intPtr(nodeID),
 					Message: fmt.Sprintf("unknown item: %s", itemLost),
@@ -496,6 +524,7 @@ func validateActionReferences(questID string, nodeID int, actions []interface{},
 					if itemType, ok := itemMap["Type"].(string); ok && itemType != "" {
 						if !refData.Items[itemType] {
 							errors = append(errors, ValidationError{
+								Code:    "PAT013",
 								QuestID: questID,
 								NodeID:  intPtr(nodeID),
 								Message: fmt.Sprintf("unknown item type in ItemsGained: %s", itemType),
@@ -513,6 +542,7 @@ func validateActionReferences(questID string, nodeID int, actions []interface{},
 					if itemType, ok := itemMap["Type"].(string); ok && itemType != "" {
 						if !refData.Items[itemType] {
 							errors = append(errors, ValidationError{
+								Code:    "PAT013",
 								QuestID: questID,
 								NodeID:  intPtr(nodeID),
 								Message: fmt.Sprintf("unknown item type in ItemsLost: %s", itemType),
@@ -528,6 +558,7 @@ func validateActionReferences(questID string, nodeID int, actions []interface{},
 			if faction, ok := fs["Faction"].(string); ok && faction != "" {
 				if !refData.Factions[faction] {
 					errors = append(errors, ValidationError{
+						Code:    "PAT013",
 						QuestID: questID,
 						NodeID:  intPtr(nodeID),
 						Message: fmt.Sprintf("unknown faction in FactionStanding: %s", faction),
@@ -237,7 +237,7 @@ func TestValidateUniqueDisplayNames(t *testing.T) {
 		{QuestID: "Quest2", DisplayName: I18nString{EnUS: "My Quest", DeDE: "Andere Quest"}},
 	}
 
-	errors := validateUniqueDisplayNames(quests)
+	errors := validateUniqueDisplayNames(quests, supportedLanguages)
 
 	found := false
 	for _, err := range errors {
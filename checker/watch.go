@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// watchDelay is how long watch mode waits for further changes before it
+// re-checks, so that a file saved in several writes is checked once.
+const watchDelay = 100 * time.Millisecond
+
+// pollInterval is how often the polling watcher looks for changes.
+const pollInterval = time.Second
+
+// runWatch validates all quests, then keeps re-checking them whenever quest
+// or reference data files change, until it is interrupted. It returns the
+// exit code of the last check.
+func runWatch(opts checkOptions) int {
+	s := newSession(opts)
+	if err := s.load(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	// The data directory is optional, as missing reference data files are
+	// treated as empty
+	var dirs []string
+	for _, dir := range []string{opts.questsPath, opts.dataPath} {
+		if _, err := os.Stat(dir); err == nil {
+			dirs = append(dirs, dir)
+		}
+	}
+	w := newWatcher(dirs)
+	defer w.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	status := writeReport(s.report(), opts)
+	fmt.Fprintln(os.Stderr, "Watching for changes, press Ctrl-C to stop.")
+	for {
+		changed, ok := collectChanges(ctx, w.Events(), watchDelay)
+		if !ok {
+			return status
+		}
+		rc, err := s.update(changed)
+		fmt.Fprintf(os.Stderr, "\n[%s] Re-checked %s\n", time.Now().Format("15:04:05"), rc)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			continue
+		}
+		status = writeReport(s.report(), opts)
+	}
+}
+
+// collectChanges waits for a changed file, then collects further changed
+// files until none arrived for delay. It returns false once ctx is done or
+// the watcher stopped.
+func collectChanges(ctx context.Context, events <-chan string, delay time.Duration) ([]string, bool) {
+	var changed []string
+	seen := make(map[string]bool)
+	var timeout <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case path, ok := <-events:
+			if !ok {
+				return nil, false
+			}
+			if !seen[path] {
+				seen[path] = true
+				changed = append(changed, path)
+			}
+			timeout = time.After(delay)
+		case <-timeout:
+			return changed, true
+		}
+	}
+}
+
+// watcher reports the paths of files that were created, changed or removed
+// in a set of directories and their subdirectories. Changes it cannot
+// attribute to a file are reported as the watched directory itself.
+type watcher interface {
+	Events() <-chan string
+	Close() error
+}
+
+// newWatcher watches dirs with the operating system's file notifications
+// where available, and by polling otherwise.
+func newWatcher(dirs []string) watcher {
+	if w, err := newNotifyWatcher(dirs); err == nil {
+		return w
+	}
+	return newPollWatcher(dirs, pollInterval)
+}
+
+// pollWatcher finds changes by comparing the modification times and sizes
+// of all files at a regular interval.
+type pollWatcher struct {
+	dirs     []string
+	interval time.Duration
+	events   chan string
+	done     chan struct{}
+	once     sync.Once
+}
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func newPollWatcher(dirs []string, interval time.Duration) *pollWatcher {
+	w := &pollWatcher{
+		dirs:     dirs,
+		interval: interval,
+		events:   make(chan string),
+		done:     make(chan struct{}),
+	}
+	go w.run(w.scan())
+	return w
+}
+
+func (w *pollWatcher) Events() <-chan string {
+	return w.events
+}
+
+func (w *pollWatcher) Close() error {
+	w.once.Do(func() { close(w.done) })
+	return nil
+}
+
+func (w *pollWatcher) run(files map[string]fileStamp) {
+	defer close(w.events)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+
+		current := w.scan()
+		var changed []string
+		for path, stamp := range current {
+			if old, ok := files[path]; !ok || old != stamp {
+				changed = append(changed, path)
+			}
+		}
+		for path := range files {
+			if _, ok := current[path]; !ok {
+				changed = append(changed, path)
+			}
+		}
+		sort.Strings(changed)
+		files = current
+
+		for _, path := range changed {
+			select {
+			case w.events <- path:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+// scan returns the stamps of all files in the watched directories.
+// Directories that cannot be read are skipped.
+func (w *pollWatcher) scan() map[string]fileStamp {
+	files := make(map[string]fileStamp)
+	for _, dir := range w.dirs {
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if path != dir && isHidden(info.Name()) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.IsDir() {
+				files[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+			}
+			return nil
+		})
+	}
+	return files
+}
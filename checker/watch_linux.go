@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// inotifyMask selects the inotify events that change the contents or the
+// existence of a file.
+const inotifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_CREATE | syscall.IN_DELETE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+// inotifyWatcher finds changes with Linux's inotify. inotify does not watch
+// subdirectories, so each directory gets its own watch, and directories
+// created later are added as they appear.
+type inotifyWatcher struct {
+	file   *os.File
+	roots  []string
+	dirs   map[int32]string // watch descriptor to directory
+	events chan string
+	done   chan struct{}
+	once   sync.Once
+}
+
+// newNotifyWatcher watches dirs and their subdirectories with inotify.
+func newNotifyWatcher(dirs []string) (watcher, error) {
+	// A non-blocking descriptor lets the runtime poll it, so that Close
+	// interrupts a pending read
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize inotify: %w", err)
+	}
+	w := &inotifyWatcher{
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		roots:  dirs,
+		dirs:   make(map[int32]string),
+		events: make(chan string),
+		done:   make(chan struct{}),
+	}
+	for _, dir := range dirs {
+		if err := w.addTree(dir); err != nil {
+			w.file.Close()
+			return nil, err
+		}
+	}
+	go w.read()
+	return w, nil
+}
+
+func (w *inotifyWatcher) Events() <-chan string {
+	return w.events
+}
+
+func (w *inotifyWatcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		err = w.file.Close()
+	})
+	return err
+}
+
+// addTree watches dir and all directories below it.
+func (w *inotifyWatcher) addTree(dir string) error {
+	conn, err := w.file.SyscallConn()
+	if err != nil {
+		return err
+	}
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if path != dir && isHidden(info.Name()) {
+			return filepath.SkipDir
+		}
+		var wd int
+		var watchErr error
+		if err := conn.Control(func(fd uintptr) {
+			wd, watchErr = syscall.InotifyAddWatch(int(fd), path, inotifyMask)
+		}); err != nil {
+			return err
+		}
+		if watchErr != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, watchErr)
+		}
+		w.dirs[int32(wd)] = path
+		return nil
+	})
+}
+
+// read turns inotify events into changed paths until the watcher is closed.
+func (w *inotifyWatcher) read() {
+	defer close(w.events)
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			return
+		}
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			wd := int32(binary.NativeEndian.Uint32(buf[offset:]))
+			mask := binary.NativeEndian.Uint32(buf[offset+4:])
+			nameLen := int(binary.NativeEndian.Uint32(buf[offset+12:]))
+			name := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+nameLen]
+			offset += syscall.SizeofInotifyEvent + nameLen
+
+			for _, path := range w.handle(wd, mask, strings.TrimRight(string(name), "\x00")) {
+				select {
+				case w.events <- path:
+				case <-w.done:
+					return
+				}
+			}
+		}
+	}
+}
+
+// handle returns the changed paths an inotify event stands for.
+func (w *inotifyWatcher) handle(wd int32, mask uint32, name string) []string {
+	switch {
+	case mask&syscall.IN_Q_OVERFLOW != 0:
+		// Events were lost, so anything may have changed
+		return w.roots
+	case mask&syscall.IN_IGNORED != 0:
+		delete(w.dirs, wd)
+		return nil
+	}
+	dir, ok := w.dirs[wd]
+	if !ok || isHidden(name) {
+		return nil
+	}
+	path := filepath.Join(dir, name)
+	if mask&syscall.IN_ISDIR != 0 && mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+		// The directory may already be gone again; its removal is
+		// reported like any other change
+		w.addTree(path)
+	}
+	return []string{path}
+}
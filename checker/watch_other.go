@@ -0,0 +1,11 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+// newNotifyWatcher is only implemented on Linux; elsewhere the checker
+// polls for changes.
+func newNotifyWatcher(dirs []string) (watcher, error) {
+	return nil, errors.New("file notifications are not supported on this platform")
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const watchTestQuest = `
+QuestTypeVersion: 1
+QuestVersion: 1
+QuestID: %s
+QuestType: SideQuest
+DisplayName: { en-US: %s, de-DE: %s }
+QuestNodes:
+  - NodeID: 0
+    NodeType: EntryPoint
+    NextNodes: [1]
+  - NodeID: 1
+    NodeType: Actions
+    Actions:
+      - JournalEntry: { en-US: Done, de-DE: Fertig }
+      - QuestStageDescription: { en-US: Done %s, de-DE: Fertig %s }
+      - CompleteQuest
+`
+
+func writeWatchTestQuest(t *testing.T, path, questID, name string) {
+	t.Helper()
+	source := fmt.Sprintf(watchTestQuest, questID, name, name, name, name)
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSessionUpdate(t *testing.T) {
+	dir := t.TempDir()
+	questsPath := filepath.Join(dir, "quests")
+	dataPath := filepath.Join(dir, "data")
+	for _, d := range []string{questsPath, dataPath} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a := filepath.Join(questsPath, "a.yaml")
+	b := filepath.Join(questsPath, "b.yaml")
+	writeWatchTestQuest(t, a, "QuestA", "A")
+	writeWatchTestQuest(t, b, "QuestB", "B")
+
+	s := newSession(checkOptions{questsPath: questsPath, dataPath: dataPath})
+	if err := s.load(); err != nil {
+		t.Fatal(err)
+	}
+	if report := s.report(); len(report.Quests) != 2 || len(report.Issues) != 0 {
+		t.Fatalf("expected 2 quests without issues, got %d quests and %v", len(report.Quests), report.Issues)
+	}
+
+	tests := []struct {
+		name   string
+		change func() string
+		want   recheck
+		issues []string
+	}{
+		{
+			name: "unrelated change",
+			change: func() string {
+				writeWatchTestQuest(t, a, "QuestA", "A")
+				return a
+			},
+			want: recheck{quests: []string{a}},
+		},
+		{
+			name: "display name",
+			change: func() string {
+				writeWatchTestQuest(t, a, "QuestA", "B")
+				return a
+			},
+			want:   recheck{quests: []string{a}, cross: true},
+			issues: []string{"PAT021", "PAT021", "PAT022", "PAT022"},
+		},
+		{
+			name: "removed quest",
+			change: func() string {
+				if err := os.Remove(b); err != nil {
+					t.Fatal(err)
+				}
+				return b
+			},
+			want: recheck{cross: true},
+		},
+		{
+			name: "new quest",
+			change: func() string {
+				writeWatchTestQuest(t, b, "QuestA", "C")
+				return questsPath
+			},
+			want:   recheck{quests: []string{a, b}, cross: true},
+			issues: []string{"PAT020"},
+		},
+		{
+			name: "reference data",
+			change: func() string {
+				path := filepath.Join(dataPath, "items.yaml")
+				if err := os.WriteFile(path, []byte("[]\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return path
+			},
+			want:   recheck{all: true, cross: true},
+			issues: []string{"PAT020"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc, err := s.update([]string{tt.change()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(rc, tt.want) {
+				t.Errorf("expected recheck %+v, got %+v", tt.want, rc)
+			}
+			var codes []string
+			for _, issue := range s.report().Issues {
+				codes = append(codes, issue.Code)
+			}
+			if !reflect.DeepEqual(codes, tt.issues) {
+				t.Errorf("expected issues %v, got %v", tt.issues, s.report().Issues)
+			}
+		})
+	}
+}
+
+func TestPollWatcher(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "quest.yaml")
+	if err := os.WriteFile(path, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := newPollWatcher([]string{dir}, 10*time.Millisecond)
+	defer w.Close()
+
+	if err := os.WriteFile(path, []byte("ab"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case changed := <-w.Events():
+		if changed != path {
+			t.Errorf("expected change of %s, got %s", path, changed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change reported")
+	}
+
+	w.Close()
+	for range w.Events() {
+	}
+}
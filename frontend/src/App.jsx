@@ -105,12 +105,14 @@ function AppContent() {
   const [currentQuestId, setCurrentQuestId] = useState(null);
   const [quest, setQuest] = useState(null);
   const [metadata, setMetadata] = useState(null);
+  const [etag, setEtag] = useState(null); // Server version the quest was loaded from
   const [questVersion, setQuestVersion] = useState(0); // Incremented on undo to force Canvas reload
   const [validation, setValidation] = useState(null);
   const [saving, setSaving] = useState(false);
   const [saveError, setSaveError] = useState(null);
   const [highlightedNodeId, setHighlightedNodeId] = useState(null);
   const [showQuestEditor, setShowQuestEditor] = useState(false);
+  const [vcsStatus, setVcsStatus] = useState(null); // Changed quest files, null without version control
 
   // Refs to access current state in callbacks
   const questRef = useRef(quest);
@@ -118,12 +120,23 @@ function AppContent() {
   useEffect(() => { questRef.current = quest; }, [quest]);
   useEffect(() => { metadataRef.current = metadata; }, [metadata]);
 
+  // Version control status of the quest files
+  const refreshVcsStatus = useCallback(async () => {
+    try {
+      setVcsStatus(await api.fetchVcsStatus());
+    } catch (e) {
+      console.error('Failed to fetch version control status:', e);
+    }
+  }, []);
+  useEffect(() => { refreshVcsStatus(); }, [currentQuestId, refreshVcsStatus]);
+
   // Load quest when selected
   const loadQuest = useCallback(async (questId) => {
     if (!questId) {
       setCurrentQuestId(null);
       setQuest(null);
       setMetadata(null);
+      setEtag(null);
       setValidation(null);
       setSaveError(null);
       clearHistory();
@@ -135,6 +148,7 @@ function AppContent() {
       setCurrentQuestId(questId);
       setQuest(data.quest);
       setMetadata(data.metadata);
+      setEtag(data.etag);
       setSaveError(null);
       clearHistory();
       
@@ -211,10 +225,33 @@ function AppContent() {
     setSaving(true);
     setSaveError(null);
     try {
-      const result = await api.saveQuest(currentQuestId, quest, metadata);
+      let result;
+      try {
+        result = await api.saveQuest(currentQuestId, quest, metadata, etag);
+      } catch (e) {
+        if (!(e instanceof api.ConflictError)) throw e;
+        // Someone else saved the quest meanwhile: keep one of the versions
+        const overwrite = confirm(
+          'This quest was changed by someone else since you opened it.\n\n' +
+          'OK: overwrite their changes with yours.\n' +
+          'Cancel: discard your changes and load their version.'
+        );
+        if (!overwrite) {
+          setQuest(e.current.quest);
+          setMetadata(e.current.metadata);
+          setEtag(e.current.etag);
+          setQuestVersion(v => v + 1);
+          clearHistory();
+          setValidation(await api.validateQuest(e.current.quest));
+          return;
+        }
+        result = await api.saveQuest(currentQuestId, quest, metadata, e.current.etag);
+      }
+      setEtag(result.etag);
+      refreshVcsStatus();
       // Only update validation if save succeeded - preserves context
       setValidation(prev => ({
-        ...result,
+        ...result.validation,
         // Keep track that this is from a save operation
         savedAt: new Date().toISOString(),
       }));
@@ -224,7 +261,54 @@ function AppContent() {
     } finally {
       setSaving(false);
     }
-  }, [currentQuestId, quest, metadata]);
+  }, [currentQuestId, quest, metadata, etag, clearHistory, refreshVcsStatus]);
+
+  // Restore one of the saved revisions of the current quest
+  const handleRestoreRevision = useCallback(async () => {
+    try {
+      const revisions = (await api.fetchSavedRevisions(currentQuestId)).slice(0, 20);
+      if (revisions.length === 0) {
+        alert('This quest has no saved revisions yet.');
+        return;
+      }
+      const choice = prompt(
+        'Restore which revision? Unsaved changes will be lost.\n\n' +
+        revisions.map((r, i) =>
+          `${i + 1}: ${new Date(r.created).toLocaleString()}${r.author ? ` by ${r.author}` : ''}`
+        ).join('\n')
+      );
+      const revision = revisions[parseInt(choice, 10) - 1];
+      if (!revision) return;
+      try {
+        await api.restoreSavedRevision(currentQuestId, revision.id, etag);
+      } catch (e) {
+        if (!(e instanceof api.ConflictError)) throw e;
+        // Someone else saved the quest since it was loaded
+        if (!confirm('This quest was changed by someone else since you opened it.\n\nRestore the revision anyway?')) {
+          return;
+        }
+        await api.restoreSavedRevision(currentQuestId, revision.id, e.current.etag);
+      }
+      await loadQuest(currentQuestId);
+      refreshVcsStatus();
+    } catch (e) {
+      console.error('Failed to restore revision:', e);
+      setSaveError(`Failed to restore: ${e.message}`);
+    }
+  }, [currentQuestId, etag, loadQuest, refreshVcsStatus]);
+
+  // Commit the saved changes to the current quest
+  const handleCommit = useCallback(async () => {
+    const message = prompt(`Commit message for ${currentQuestId}:`);
+    if (!message) return;
+    try {
+      await api.commitQuests([currentQuestId], message);
+    } catch (e) {
+      console.error('Failed to commit quest:', e);
+      alert(e.message);
+    }
+    refreshVcsStatus();
+  }, [currentQuestId, refreshVcsStatus]);
 
   // Create new quest
   const handleNewQuest = useCallback(() => {
@@ -264,6 +348,7 @@ function AppContent() {
     setCurrentQuestId(questId);
     setQuest(newQuest);
     setMetadata({ questId, nodePositions: {} });
+    setEtag(null);
     setValidation({ valid: true });
     clearHistory();
   }, [quests, clearHistory]);
@@ -315,6 +400,9 @@ function AppContent() {
         onSelect={loadQuest}
         onNew={handleNewQuest}
         onSave={handleSave}
+        onCommit={handleCommit}
+        onRestoreRevision={handleRestoreRevision}
+        vcsStatus={vcsStatus && (vcsStatus.find(s => s.questId === currentQuestId)?.status ?? 'unchanged')}
         validation={validation}
         saving={saving}
         saveError={saveError}
@@ -2,30 +2,54 @@ const API_BASE = '/api';
 
 export async function fetchQuests() {
   const res = await fetch(`${API_BASE}/quests`);
-  if (!res.ok) throw new Error('Failed to fetch quests');
+  if (!res.ok) throw new Error(`Failed to fetch quests: ${(await res.text()).trim()}`);
   return res.json();
 }
 
+// ConflictError is thrown when a quest was changed on the server since it
+// was loaded. It carries the current server version ({ quest, metadata,
+// etag }), so that the changes can be merged.
+export class ConflictError extends Error {
+  constructor(current) {
+    super('The quest was changed by someone else');
+    this.name = 'ConflictError';
+    this.current = current;
+  }
+}
+
 export async function fetchQuest(questId) {
   const res = await fetch(`${API_BASE}/quests/${encodeURIComponent(questId)}`);
   if (!res.ok) throw new Error('Failed to fetch quest');
-  return res.json();
+  return { ...(await res.json()), etag: res.headers.get('ETag') };
 }
 
-export async function saveQuest(questId, quest, metadata) {
+// saveQuest saves a quest loaded with the given etag, or a new quest if etag
+// is null. It returns the validation result and the new etag.
+export async function saveQuest(questId, quest, metadata, etag) {
   const res = await fetch(`${API_BASE}/quests/${encodeURIComponent(questId)}`, {
     method: 'PUT',
-    headers: { 'Content-Type': 'application/json' },
+    headers: {
+      'Content-Type': 'application/json',
+      ...(etag ? { 'If-Match': etag } : { 'If-None-Match': '*' }),
+    },
     body: JSON.stringify({ quest, metadata }),
   });
+  if (res.status === 412) {
+    throw new ConflictError({ ...(await res.json()), etag: res.headers.get('ETag') });
+  }
   if (!res.ok) throw new Error('Failed to save quest');
-  return res.json();
+  return { validation: await res.json(), etag: res.headers.get('ETag') };
 }
 
-export async function deleteQuest(questId) {
+// deleteQuest deletes a quest loaded with the given etag.
+export async function deleteQuest(questId, etag) {
   const res = await fetch(`${API_BASE}/quests/${encodeURIComponent(questId)}`, {
     method: 'DELETE',
+    headers: { 'If-Match': etag },
   });
+  if (res.status === 412) {
+    throw new ConflictError({ ...(await res.json()), etag: res.headers.get('ETag') });
+  }
   if (!res.ok) throw new Error('Failed to delete quest');
 }
 
@@ -39,6 +63,109 @@ export async function validateQuest(quest) {
   return res.json();
 }
 
+// fixQuest applies the automatic fixes to a quest loaded with the given
+// etag, or only previews them with dryRun.
+export async function fixQuest(questId, etag, dryRun = false) {
+  const query = dryRun ? '?dryRun=true' : '';
+  const res = await fetch(`${API_BASE}/quests/${encodeURIComponent(questId)}/fix${query}`, {
+    method: 'POST',
+    headers: etag ? { 'If-Match': etag } : {},
+  });
+  if (res.status === 412) {
+    throw new ConflictError({ ...(await res.json()), etag: res.headers.get('ETag') });
+  }
+  if (!res.ok) throw new Error('Failed to fix quest');
+  return { ...(await res.json()), etag: res.headers.get('ETag') };
+}
+
+export async function fetchQuestBackups(questId) {
+  const res = await fetch(`${API_BASE}/quests/${encodeURIComponent(questId)}/backups`);
+  if (!res.ok) throw new Error('Failed to fetch quest backups');
+  return res.json();
+}
+
+// restoreQuestBackup replaces a quest loaded with the given etag with one
+// of its backups. A deleted quest is restored without an etag.
+export async function restoreQuestBackup(questId, backupId, etag) {
+  const res = await fetch(
+    `${API_BASE}/quests/${encodeURIComponent(questId)}/backups/${encodeURIComponent(backupId)}/restore`,
+    { method: 'POST', headers: etag ? { 'If-Match': etag } : {} },
+  );
+  if (res.status === 412) {
+    throw new ConflictError({ ...(await res.json()), etag: res.headers.get('ETag') });
+  }
+  if (!res.ok) throw new Error('Failed to restore quest backup');
+  return { validation: await res.json(), etag: res.headers.get('ETag') };
+}
+
+export async function fetchSavedRevisions(questId) {
+  const res = await fetch(`${API_BASE}/quests/${encodeURIComponent(questId)}/revisions`);
+  if (!res.ok) throw new Error('Failed to fetch quest revisions');
+  return res.json();
+}
+
+// restoreSavedRevision replaces a quest loaded with the given etag with one
+// of its saved revisions.
+export async function restoreSavedRevision(questId, revisionId, etag) {
+  const res = await fetch(
+    `${API_BASE}/quests/${encodeURIComponent(questId)}/revisions/${encodeURIComponent(revisionId)}/restore`,
+    { method: 'POST', headers: etag ? { 'If-Match': etag } : {} },
+  );
+  if (res.status === 412) {
+    throw new ConflictError({ ...(await res.json()), etag: res.headers.get('ETag') });
+  }
+  if (!res.ok) throw new Error('Failed to restore quest revision');
+  return { validation: await res.json(), etag: res.headers.get('ETag') };
+}
+
+// fetchQuestDiff compares the current quest with a saved revision
+// ({ revision }) or a git revision ({ commit }).
+export async function fetchQuestDiff(questId, { revision, commit }) {
+  const params = new URLSearchParams(revision !== undefined ? { revision } : { commit });
+  const res = await fetch(`${API_BASE}/quests/${encodeURIComponent(questId)}/diff?${params}`);
+  if (!res.ok) throw new Error('Failed to compare quest versions');
+  return res.json();
+}
+
+// fetchVcsStatus returns the quest files that differ from the last commit,
+// or null if the quests are not under version control.
+export async function fetchVcsStatus() {
+  const res = await fetch(`${API_BASE}/vcs/status`);
+  if (res.status === 501) return null;
+  if (!res.ok) throw new Error('Failed to fetch version control status');
+  return res.json();
+}
+
+export async function fetchQuestHistory(questId) {
+  const res = await fetch(`${API_BASE}/vcs/quests/${encodeURIComponent(questId)}/history`);
+  if (!res.ok) throw new Error('Failed to fetch quest history');
+  return res.json();
+}
+
+export async function fetchQuestRevision(questId, revision) {
+  const res = await fetch(
+    `${API_BASE}/vcs/quests/${encodeURIComponent(questId)}/revisions/${encodeURIComponent(revision)}`,
+  );
+  if (!res.ok) throw new Error('Failed to fetch quest revision');
+  return res.text();
+}
+
+export async function fetchQuestBlame(questId) {
+  const res = await fetch(`${API_BASE}/vcs/quests/${encodeURIComponent(questId)}/blame`);
+  if (!res.ok) throw new Error('Failed to fetch quest blame');
+  return res.json();
+}
+
+export async function commitQuests(questIds, message, author) {
+  const res = await fetch(`${API_BASE}/vcs/commit`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ questIds, message, author }),
+  });
+  if (!res.ok) throw new Error(`Failed to commit: ${(await res.text()).trim()}`);
+  return res.json();
+}
+
 export async function fetchItems() {
   const res = await fetch(`${API_BASE}/items`);
   if (!res.ok) throw new Error('Failed to fetch items');
@@ -1,7 +1,7 @@
 import { useState } from 'react';
 import { useTheme } from '../ThemeContext';
 
-export default function TopBar({ questId, quests, onSelect, onNew, onSave, validation, saving, saveError, onToggleTheme }) {
+export default function TopBar({ questId, quests, onSelect, onNew, onSave, onRestoreRevision, onCommit, vcsStatus, validation, saving, saveError, onToggleTheme }) {
   const { theme, themeName } = useTheme();
   const [search, setSearch] = useState('');
 
@@ -50,7 +50,12 @@ export default function TopBar({ questId, quests, onSelect, onNew, onSave, valid
       </div>
 
       <div style={styles.center}>
-        {questId && <span style={styles.questName}>{questId}</span>}
+        {questId && (
+          <span style={styles.questName}>
+            {questId}
+            {vcsStatus && vcsStatus !== 'unchanged' && <span style={styles.vcsStatus}> ({vcsStatus})</span>}
+          </span>
+        )}
         {saveError && (
           <span style={styles.errorMessage} title={saveError}>
             ⚠ {saveError.length > 40 ? saveError.substring(0, 40) + '...' : saveError}
@@ -65,6 +70,14 @@ export default function TopBar({ questId, quests, onSelect, onNew, onSave, valid
         <span style={{ ...styles.status, color: isValid ? '#4caf50' : '#ff9800' }}>
           {isValid ? '✓' : '⚠'}
         </span>
+        <button
+          onClick={onRestoreRevision}
+          disabled={!questId}
+          style={styles.button}
+          title="Restore an earlier saved version of the quest"
+        >
+          History
+        </button>
         <button
           onClick={onSave}
           disabled={!questId || saving}
@@ -72,6 +85,16 @@ export default function TopBar({ questId, quests, onSelect, onNew, onSave, valid
         >
           {saving ? 'Saving...' : 'Save'}
         </button>
+        {vcsStatus && (
+          <button
+            onClick={onCommit}
+            disabled={!questId || vcsStatus === 'unchanged'}
+            style={styles.button}
+            title="Commit the saved quest to version control"
+          >
+            Commit
+          </button>
+        )}
       </div>
     </div>
   );
@@ -166,6 +189,9 @@ const getStyles = (theme) => ({
     color: theme.textMuted,
     fontSize: '14px',
   },
+  vcsStatus: {
+    fontStyle: 'italic',
+  },
   errorMessage: {
     color: '#f44336',
     fontSize: '11px',
@@ -1,10 +1,16 @@
 import { useTheme } from '../ThemeContext';
 
+const severityIcons = {
+  error: { icon: '⚠', color: '#f44336' },
+  warning: { icon: '⚠', color: '#ff9800' },
+  info: { icon: 'ℹ', color: '#2196f3' },
+};
+
 export default function ValidationPanel({ validation, onHoverNode, onSelectNode }) {
   const { theme } = useTheme();
   const styles = getStyles(theme);
 
-  if (!validation || validation.valid) {
+  if (!validation || !validation.errors?.length) {
     return (
       <div style={styles.container}>
         <div style={styles.valid}>✓ Quest is valid</div>
@@ -14,7 +20,7 @@ export default function ValidationPanel({ validation, onHoverNode, onSelectNode
 
   return (
     <div style={styles.container}>
-      <h3 style={styles.title}>Warnings</h3>
+      <h3 style={styles.title}>{validation.valid ? 'Warnings' : 'Problems'}</h3>
       <div style={styles.list}>
         {validation.errors?.map((err, i) => (
           <div
@@ -27,14 +33,21 @@ export default function ValidationPanel({ validation, onHoverNode, onSelectNode
             onMouseLeave={() => onHoverNode?.(null)}
             onDoubleClick={() => err.nodeId !== undefined && onSelectNode?.(err.nodeId)}
           >
-            <span style={styles.icon}>⚠</span>
+            <span style={{ color: (severityIcons[err.severity] || severityIcons.error).color }}>
+              {(severityIcons[err.severity] || severityIcons.error).icon}
+            </span>
             <span>
               {err.nodeId !== undefined && <strong>Node {err.nodeId}: </strong>}
               {err.message}
+              {err.code && <span style={styles.code}> {err.code}</span>}
+              {err.line && <span style={styles.code}> line {err.line}</span>}
             </span>
           </div>
         ))}
       </div>
+      {validation.suppressed?.length > 0 && (
+        <div style={styles.hint}>{validation.suppressed.length} suppressed</div>
+      )}
       {validation.errors?.some(e => e.nodeId !== undefined) && (
         <div style={styles.hint}>Double-click to jump to node</div>
       )}
@@ -77,8 +90,9 @@ const getStyles = (theme) => ({
     color: theme.textSecondary,
     transition: 'background-color 0.15s',
   },
-  icon: {
-    color: '#ff9800',
+  code: {
+    color: theme.textDim,
+    fontFamily: 'monospace',
   },
   hint: {
     marginTop: '12px',